@@ -3,18 +3,23 @@ package main
 // -- AUTOGENERATED FILE -- (see scripts/generate_ast.py for details...)
 // This is a simple implementation of the Visitor pattern from OOP
 type ExprVisitor interface {
-	VisitBinaryExpr(c *BinaryExpr)
-	VisitGrouping(c *Grouping)
-	VisitLiteral(c *Literal)
-	VisitUnary(c *Unary)
-	VisitVariable(c *Variable)
-	VisitAssign(a *AssignExpr)
-	VisitLogical(l *LogicalExpr)
-	VisitCall(c *CallExpr)
+	VisitBinaryExpr(c *BinaryExpr) (interface{}, error)
+	VisitGrouping(c *Grouping) (interface{}, error)
+	VisitLiteral(c *Literal) (interface{}, error)
+	VisitUnary(c *Unary) (interface{}, error)
+	VisitVariable(c *Variable) (interface{}, error)
+	VisitAssign(a *AssignExpr) (interface{}, error)
+	VisitLogical(l *LogicalExpr) (interface{}, error)
+	VisitCall(c *CallExpr) (interface{}, error)
+	VisitTuple(t *TupleExpr) (interface{}, error)
+	VisitIs(i *IsExpr) (interface{}, error)
+	VisitIndex(i *IndexExpr) (interface{}, error)
+	VisitSet(s *SetExpr) (interface{}, error)
+	VisitBadExpr(b *BadExpr) (interface{}, error)
 }
 
 type Expr interface {
-	accept(ExprVisitor)
+	accept(ExprVisitor) (interface{}, error)
 }
 
 // CallExpr is an AST node that represents a function call in the tree
@@ -25,8 +30,8 @@ type CallExpr struct {
 }
 
 // accept stub for function calls
-func (c *CallExpr) accept(v ExprVisitor) {
-	v.VisitCall(c)
+func (c *CallExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitCall(c)
 }
 
 // LogicalExpr is a type of binary expression node used to represent logical statements
@@ -36,19 +41,20 @@ type LogicalExpr struct {
 }
 
 // accept method stub for LogicalExpr
-func (l *LogicalExpr) accept(v ExprVisitor) {
-	v.VisitLogical(l)
+func (l *LogicalExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitLogical(l)
 }
 
 // AssignExpr is a simple AST node
 type AssignExpr struct {
 	name Token
 	val  Expr
+	op   Token // the '=' token
 }
 
 // accept method stub for AssignExpr
-func (a *AssignExpr) accept(v ExprVisitor) {
-	v.VisitAssign(a)
+func (a *AssignExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitAssign(a)
 }
 
 // BinaryExpr is a simple type of AST node
@@ -59,8 +65,8 @@ type BinaryExpr struct {
 }
 
 // accept method stub for BinaryExpr
-func (c *BinaryExpr) accept(v ExprVisitor) {
-	v.VisitBinaryExpr(c)
+func (c *BinaryExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitBinaryExpr(c)
 }
 
 // Grouping is a simple type of AST node
@@ -69,18 +75,19 @@ type Grouping struct {
 }
 
 // accept method stub for Grouping
-func (c *Grouping) accept(v ExprVisitor) {
-	v.VisitGrouping(c)
+func (c *Grouping) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitGrouping(c)
 }
 
 // Literal is a simple type of AST node
 type Literal struct {
 	val interface{}
+	tkn Token // source token, empty for literals synthesized by the parser
 }
 
 // accept method stub for Literal
-func (c *Literal) accept(v ExprVisitor) {
-	v.VisitLiteral(c)
+func (c *Literal) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitLiteral(c)
 }
 
 // Unary is a simple type of AST node
@@ -90,8 +97,8 @@ type Unary struct {
 }
 
 // accept method stub for Unary
-func (c *Unary) accept(v ExprVisitor) {
-	v.VisitUnary(c)
+func (c *Unary) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitUnary(c)
 }
 
 // Variable is a simple type of AST node
@@ -100,6 +107,62 @@ type Variable struct {
 }
 
 // accept method stub for Variable
-func (c *Variable) accept(v ExprVisitor) {
-	v.VisitVariable(c)
+func (c *Variable) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitVariable(c)
+}
+
+// TupleExpr is the list of values in a 'return a, b;' statement
+type TupleExpr struct {
+	values []Expr
+}
+
+// accept method stub for TupleExpr
+func (t *TupleExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitTuple(t)
+}
+
+// IsExpr tests the type of a value: 'value is Number'
+type IsExpr struct {
+	val      Expr
+	keyword  Token
+	typeName Token
+}
+
+// accept method stub for IsExpr
+func (i *IsExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitIs(i)
+}
+
+// IndexExpr is a subscript 'value[i]' or, if slice is set, 'value[start:end]' where either bound may be nil
+type IndexExpr struct {
+	object     Expr
+	bracket    Token
+	start, end Expr
+	slice      bool
+}
+
+// accept method stub for IndexExpr
+func (i *IndexExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitIndex(i)
+}
+
+// SetExpr is a set literal: 'set{1, 2, 3}'
+type SetExpr struct {
+	keyword Token
+	elems   []Expr
+}
+
+// accept method stub for SetExpr
+func (s *SetExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitSet(s)
+}
+
+// BadExpr stands in for an expression a tolerant parser couldn't parse, tkn is where it was expected
+type BadExpr struct {
+	tkn Token
+}
+
+// accept method stub for BadExpr
+func (b *BadExpr) accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitBadExpr(b)
 }
@@ -6,73 +6,115 @@ import (
 )
 
 // ASTPrinter is an implementation of a visitor interface that "pretty-prints" AST nodes.
-// Each Visit method generates the correct call to the parenthesize() method
+// Each Visit method returns the string for its node, most of them built by the parenthesize() method
 type ASTPrinter struct {
 	str string
 }
 
-func (a2 *ASTPrinter) VisitCall(c *CallExpr) {
+func (a2 *ASTPrinter) VisitCall(c *CallExpr) (interface{}, error) {
 	panic("implement me")
 }
 
-func (a2 *ASTPrinter) VisitLogical(l *LogicalExpr) {
+func (a2 *ASTPrinter) VisitLogical(l *LogicalExpr) (interface{}, error) {
 	panic("implement me")
 }
 
-func (a2 *ASTPrinter) VisitAssign(a *AssignExpr) {
+func (a2 *ASTPrinter) VisitAssign(a *AssignExpr) (interface{}, error) {
 	panic("implement me" + a.name.lexeme)
 }
 
-func (a *ASTPrinter) VisitVariable(c *Variable) {
+func (a *ASTPrinter) VisitVariable(c *Variable) (interface{}, error) {
 	panic("implement me" + c.name.lexeme)
 }
 
 // Print passes the ASTPrinter visitor to an Expr
 func (a *ASTPrinter) Print(exp Expr) string {
-	exp.accept(a)
-	return a.String()
+	str, _ := exp.accept(a)
+	a.str = str.(string)
+	return a.str
 }
 
 // VisitBinaryExpr pprints a binary expression
-func (a *ASTPrinter) VisitBinaryExpr(b *BinaryExpr) {
-	a.parenthesize(b.op.lexeme, b.left, b.right)
+func (a *ASTPrinter) VisitBinaryExpr(b *BinaryExpr) (interface{}, error) {
+	return a.parenthesize(b.op.lexeme, b.left, b.right), nil
 }
 
 // VisitGrouping pprints a grouped expression
-func (a *ASTPrinter) VisitGrouping(g *Grouping) {
-	a.parenthesize("group", g.exp)
+func (a *ASTPrinter) VisitGrouping(g *Grouping) (interface{}, error) {
+	return a.parenthesize("group", g.exp), nil
 }
 
 // VisitLiteral pprints a literal expr
-func (a *ASTPrinter) VisitLiteral(l *Literal) {
-	if l.val == nil {
-		a.str = "nil"
-	}
+func (a *ASTPrinter) VisitLiteral(l *Literal) (interface{}, error) {
 	switch lit := l.val.(type) {
+	case nil:
+		return "nil", nil
+	case int64:
+		return fmt.Sprintf("%d", lit), nil
 	case float64:
-		a.str = fmt.Sprintf("%f", lit)
+		return fmt.Sprintf("%f", lit), nil
 	case string:
-		a.str = lit
+		return lit, nil
+	}
+	return "", nil
+}
+
+// VisitTuple pprints the values of a multiple return
+func (a *ASTPrinter) VisitTuple(t *TupleExpr) (interface{}, error) {
+	return a.parenthesize("tuple", t.values...), nil
+}
+
+// VisitIndex pprints a subscript or slice, omitted bounds are printed as nil
+func (a *ASTPrinter) VisitIndex(i *IndexExpr) (interface{}, error) {
+	name := "index"
+	if i.slice {
+		name = "slice"
+	}
+	exps := []Expr{i.object}
+	for _, bound := range []Expr{i.start, i.end} {
+		if bound == nil {
+			bound = &Literal{}
+		}
+		exps = append(exps, bound)
 	}
+	if !i.slice {
+		exps = exps[:2]
+	}
+	return a.parenthesize(name, exps...), nil
+}
+
+// VisitSet pprints the elements of a set literal
+func (a *ASTPrinter) VisitSet(s *SetExpr) (interface{}, error) {
+	return a.parenthesize("set", s.elems...), nil
+}
+
+// VisitBadExpr pprints the placeholder of an expression that didn't parse
+func (a *ASTPrinter) VisitBadExpr(b *BadExpr) (interface{}, error) {
+	return "<bad>", nil
+}
+
+// VisitIs pprints a type test
+func (a *ASTPrinter) VisitIs(i *IsExpr) (interface{}, error) {
+	return a.parenthesize("is "+i.typeName.lexeme, i.val), nil
 }
 
 // VisitUnary pprints a unary expression
-func (a *ASTPrinter) VisitUnary(u *Unary) {
-	a.parenthesize(u.op.lexeme, u.right)
+func (a *ASTPrinter) VisitUnary(u *Unary) (interface{}, error) {
+	return a.parenthesize(u.op.lexeme, u.right), nil
 }
 
 // parenthesize prints the name of an AST node and pprints its expression operands
-func (a *ASTPrinter) parenthesize(name string, exps ...Expr) {
+func (a *ASTPrinter) parenthesize(name string, exps ...Expr) string {
 	var build strings.Builder
 	build.WriteByte('(')
 	build.WriteString(name)
 	for _, exp := range exps {
 		build.WriteByte(' ')
-		exp.accept(a)
-		build.WriteString(a.String())
+		str, _ := exp.accept(a)
+		build.WriteString(str.(string))
 	}
 	build.WriteByte(')')
-	a.str = build.String()
+	return build.String()
 }
 
 // Get the string representation for the Expr to be printed
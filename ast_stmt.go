@@ -3,14 +3,19 @@ package main
 // -- AUTOGENERATED FILE -- (see scripts/generate_ast.py for details...)
 // This is a simple implementation of the Visitor pattern from OOP
 type StmtVisitor interface {
-	VisitPrintStmt(c *PrintStmt)
-	VisitExprStmt(c *ExprStmt)
-	VisitVarStmt(c *VarStmt)
-	VisitBlockStmt(b *BlockStmt)
-	VisitIfStmt(i *IfStmt)
-	VisitWhileStmt(w *WhileStmt)
-	VisitFunctionStmt(f *FunctionStmt)
-	VisitReturnStmt(r *ReturnStmt)
+	VisitPrintStmt(c *PrintStmt) error
+	VisitExprStmt(c *ExprStmt) error
+	VisitVarStmt(c *VarStmt) error
+	VisitBlockStmt(b *BlockStmt) error
+	VisitIfStmt(i *IfStmt) error
+	VisitWhileStmt(w *WhileStmt) error
+	VisitForInStmt(f *ForInStmt) error
+	VisitFunctionStmt(f *FunctionStmt) error
+	VisitReturnStmt(r *ReturnStmt) error
+	VisitYieldStmt(y *YieldStmt) error
+	VisitImportStmt(i *ImportStmt) error
+	VisitDestructureStmt(d *DestructureStmt) error
+	VisitBadStmt(b *BadStmt) error
 }
 
 // IfStmt represents a branch with an optional else
@@ -20,8 +25,8 @@ type IfStmt struct {
 }
 
 // accept method stub for an if statement
-func (i *IfStmt) accept(v StmtVisitor) {
-	v.VisitIfStmt(i)
+func (i *IfStmt) accept(v StmtVisitor) error {
+	return v.VisitIfStmt(i)
 }
 
 // ReturnStmt represents a return statement in the AST
@@ -31,31 +36,64 @@ type ReturnStmt struct {
 }
 
 // accept method stub for an return statement
-func (r *ReturnStmt) accept(v StmtVisitor) {
-	v.VisitReturnStmt(r)
+func (r *ReturnStmt) accept(v StmtVisitor) error {
+	return v.VisitReturnStmt(r)
 }
 
 // FunctionStmt represents a function declaration in the AST
 type FunctionStmt struct {
 	name   Token
 	params []Token
-	body   []Stmt
+	// paramTypes are the annotated types of the parameters and returnType the one of the result,
+	// empty tokens where there is no annotation
+	paramTypes []Token
+	returnType Token
+	body       []Stmt
+	end        Token // closing brace of the body
+	// generator is set for 'fun*' declarations, calling one returns a generator instead of running the body
+	generator bool
+	// internal is set for the functions of the prelude
+	internal bool
+	// decorators are applied to the function before it is bound to its name, the last one first
+	decorators []Decorator
+	// doc is the function's docstring, a string literal that is the first statement of its body
+	doc string
+}
+
+// Decorator is an '@expr' line above a function declaration, the value of expr is called with the function
+type Decorator struct {
+	at   Token
+	expr Expr
 }
 
 // accept method stub for an if statement
-func (f *FunctionStmt) accept(v StmtVisitor) {
-	v.VisitFunctionStmt(f)
+func (f *FunctionStmt) accept(v StmtVisitor) error {
+	return v.VisitFunctionStmt(f)
 }
 
 // WhileStmt represents a simple loop structure in the AST
 type WhileStmt struct {
 	condition Expr
 	statement Stmt
+	// forTkn is the 'for' keyword of a for loop the parser desugared into this loop, empty for a while loop
+	forTkn Token
 }
 
 // accept method stub for an if statement
-func (w *WhileStmt) accept(v StmtVisitor) {
-	v.VisitWhileStmt(w)
+func (w *WhileStmt) accept(v StmtVisitor) error {
+	return v.VisitWhileStmt(w)
+}
+
+// ForInStmt represents a loop over the elements of a collection
+type ForInStmt struct {
+	name       Token
+	collection Expr
+	body       Stmt
+}
+
+// accept method stub for a for-in loop
+func (f *ForInStmt) accept(v StmtVisitor) error {
+	return v.VisitForInStmt(f)
 }
 
 // BlockStmt is a node that represents a list of statements
@@ -64,12 +102,12 @@ type BlockStmt struct {
 }
 
 // accept method stub for BlockStmt
-func (b *BlockStmt) accept(v StmtVisitor) {
-	v.VisitBlockStmt(b)
+func (b *BlockStmt) accept(v StmtVisitor) error {
+	return v.VisitBlockStmt(b)
 }
 
 type Stmt interface {
-	accept(v StmtVisitor)
+	accept(v StmtVisitor) error
 }
 
 // PrintStmt is a simple type of AST node
@@ -78,8 +116,8 @@ type PrintStmt struct {
 }
 
 // accept method stub for PrintStmt
-func (c *PrintStmt) accept(v StmtVisitor) {
-	v.VisitPrintStmt(c)
+func (c *PrintStmt) accept(v StmtVisitor) error {
+	return v.VisitPrintStmt(c)
 }
 
 // ExprStmt is a simple type of AST node
@@ -88,17 +126,72 @@ type ExprStmt struct {
 }
 
 // accept method stub for ExprStmt
-func (c *ExprStmt) accept(v StmtVisitor) {
-	v.VisitExprStmt(c)
+func (c *ExprStmt) accept(v StmtVisitor) error {
+	return v.VisitExprStmt(c)
 }
 
 // VarStmt is a simple type of AST node
 type VarStmt struct {
 	name *Token
+	// typ is the annotated type, empty if there is none
+	typ  Token
 	init Expr
+	// keyword and end are the 'var' (or 'const') and ';' tokens delimiting the declaration
+	keyword, end Token
+	// constant bindings can't be assigned to after the declaration
+	constant bool
 }
 
 // accept method stub for VarStmt
-func (c *VarStmt) accept(v StmtVisitor) {
-	v.VisitVarStmt(c)
+func (c *VarStmt) accept(v StmtVisitor) error {
+	return v.VisitVarStmt(c)
+}
+
+// YieldStmt hands a value to the loop consuming a generator and suspends the generator until the next one is needed
+type YieldStmt struct {
+	keyword Token
+	val     Expr
+}
+
+// accept method stub for YieldStmt
+func (y *YieldStmt) accept(v StmtVisitor) error {
+	return v.VisitYieldStmt(y)
+}
+
+// ImportStmt loads another script, path is the string token naming the file
+type ImportStmt struct {
+	keyword, path Token
+	// end is the ';' closing the statement
+	end Token
+}
+
+// accept method stub for ImportStmt
+func (i *ImportStmt) accept(v StmtVisitor) error {
+	return v.VisitImportStmt(i)
+}
+
+// DestructureStmt unpacks the values returned by 'return a, b;' into several variables, either declaring
+// them ('var x, y = f();') or assigning existing ones ('x, y = f();')
+type DestructureStmt struct {
+	// keyword is the 'var' or 'const' token of a declaration, empty for an assignment
+	keyword  Token
+	names    []Token
+	init     Expr
+	declare  bool
+	constant bool
+}
+
+// accept method stub for DestructureStmt
+func (d *DestructureStmt) accept(v StmtVisitor) error {
+	return v.VisitDestructureStmt(d)
+}
+
+// BadStmt covers the tokens a tolerant parser skipped after a syntax error, from the first to the last of them
+type BadStmt struct {
+	from, to Token
+}
+
+// accept method stub for BadStmt
+func (b *BadStmt) accept(v StmtVisitor) error {
+	return v.VisitBadStmt(b)
 }
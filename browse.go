@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// browsePageSize is the number of tree lines the value browser shows at once
+const browsePageSize = 20
+
+// browseNode is a value in the REPL's value browser. Collections can be expanded to show their elements.
+type browseNode struct {
+	label    string
+	children []*browseNode
+	expanded bool
+}
+
+// newBrowseNode builds the tree for a value using inspect()'s formatting, a collection's own label
+// doesn't show its elements since they are its children. path holds the collections above v.
+func newBrowseNode(prefix string, v interface{}, path map[interface{}]bool) *browseNode {
+	node := &browseNode{label: prefix + inspectValue(v, 0, make(map[interface{}]bool))}
+	switch val := v.(type) {
+	case Tuple:
+		for i, elem := range val {
+			node.children = append(node.children, newBrowseNode("["+strconv.Itoa(i)+"] ", elem, path))
+		}
+	case *LoxSet:
+		if path[val] {
+			node.label = prefix + "<cycle>"
+			return node
+		}
+		path[val] = true
+		for _, elem := range val.elems {
+			node.children = append(node.children, newBrowseNode("", elem, path))
+		}
+		delete(path, val)
+	case *LoxBytes:
+		// 16 bytes per row, like a hex dump
+		node.label = prefix + frozenMark(val.frozen) + "bytes(" + strconv.Itoa(len(val.b)) + ")"
+		for off := 0; off < len(val.b); off += 16 {
+			end := off + 16
+			if end > len(val.b) {
+				end = len(val.b)
+			}
+			node.children = append(node.children, &browseNode{label: fmt.Sprintf("%08x  % x", off, val.b[off:end])})
+		}
+	}
+	return node
+}
+
+// browseLine is a visible line of the tree
+type browseLine struct {
+	node  *browseNode
+	depth int
+}
+
+// visible lists the lines of the tree below (and including) n that aren't hidden in a collapsed node
+func (n *browseNode) visible(depth int, lines []browseLine) []browseLine {
+	lines = append(lines, browseLine{n, depth})
+	if n.expanded {
+		for _, child := range n.children {
+			lines = child.visible(depth+1, lines)
+		}
+	}
+	return lines
+}
+
+// browseValue is the REPL's ':browse name' command. It shows a page of the value's tree with numbered
+// lines and reads commands from r: a line number expands or collapses that line, 'n' and 'p' change
+// the page and 'q' (or an empty line) goes back to the REPL.
+func browseValue(r *bufio.Reader, w io.Writer, name string, v interface{}) {
+	root := newBrowseNode(name+" = ", v, make(map[interface{}]bool))
+	root.expanded = true
+	page := 0
+	for {
+		lines := root.visible(0, nil)
+		pages := (len(lines) + browsePageSize - 1) / browsePageSize
+		if page >= pages {
+			page = pages - 1
+		}
+		for i := page * browsePageSize; i < len(lines) && i < (page+1)*browsePageSize; i++ {
+			marker := " "
+			if len(lines[i].node.children) > 0 {
+				marker = "+"
+				if lines[i].node.expanded {
+					marker = "-"
+				}
+			}
+			fmt.Fprintf(w, "%4d %v%v %v\n", i+1, strings.Repeat("  ", lines[i].depth), marker, lines[i].node.label)
+		}
+		fmt.Fprintf(w, "[page %d/%d] number: expand/collapse, n/p: next/previous page, q: quit> ", page+1, pages)
+		cmd, err := r.ReadString('\n')
+		cmd = strings.TrimSpace(cmd)
+		switch {
+		case err != nil || cmd == "" || cmd == "q":
+			return
+		case cmd == "n" && page+1 < pages:
+			page++
+		case cmd == "p" && page > 0:
+			page--
+		default:
+			if i, err := strconv.Atoi(cmd); err == nil && i >= 1 && i <= len(lines) {
+				node := lines[i-1].node
+				node.expanded = !node.expanded && len(node.children) > 0
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sourceComment marks where the following line of a bundle came from: '// glox:source lib/util.lox:1'.
+// The paths are relative to the bundled script, so bundling the same files gives the same output anywhere.
+const sourceComment = "// glox:source "
+
+// bundler inlines the modules imported by a script. A module runs in the global environment and only once,
+// so replacing the first top-level import of every module with its (bundled) source behaves the same and
+// later imports of it can be dropped.
+type bundler struct {
+	root       string
+	searchPath []string
+	// modules maps the absolute path of every module seen so far to whether it has been inlined completely
+	modules map[string]bool
+}
+
+// Bundle returns the script at path with all its imports inlined
+func Bundle(path string, searchPath []string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	b := &bundler{root: filepath.Dir(abs), searchPath: searchPath, modules: make(map[string]bool)}
+	return b.inline(abs)
+}
+
+// display returns the path of a file in source comments and errors
+func (b *bundler) display(path string) string {
+	if rel, err := filepath.Rel(b.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// inline returns the source of the file at path with its imports replaced by the modules' sources
+func (b *bundler) inline(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("can't read %v", b.display(path))
+	}
+	source := string(contents)
+	r := &ErrorReporter{out: os.Stdout}
+	stmts := parse(source, r)
+	if r.hadError {
+		return "", fmt.Errorf("%v has syntax errors", b.display(path))
+	}
+	b.modules[path] = false
+	topLevel := make(map[*ImportStmt]bool)
+	for _, stmt := range stmts {
+		if imp, ok := stmt.(*ImportStmt); ok {
+			topLevel[imp] = true
+		}
+	}
+	var nested *ImportStmt
+	Inspect(stmts, func(node interface{}) bool {
+		if imp, ok := node.(*ImportStmt); ok && !topLevel[imp] && nested == nil {
+			nested = imp
+		}
+		return nested == nil
+	})
+	if nested != nil {
+		// inlined there the module's declarations would be local instead of global
+		return "", fmt.Errorf("%v:%d: imports inside blocks and functions can't be bundled", b.display(path), nested.keyword.line)
+	}
+	fix := &Fix{description: "inline imports"}
+	for _, stmt := range stmts {
+		imp, ok := stmt.(*ImportStmt)
+		if !ok {
+			continue
+		}
+		edit := TextEdit{
+			start: Position{imp.keyword.line, imp.keyword.col},
+			end:   Position{imp.end.line, imp.end.col + len(imp.end.lexeme)},
+		}
+		module, found := resolveImport(imp.path.literal.(string), filepath.Dir(path), b.searchPath)
+		if !found {
+			return "", fmt.Errorf("%v:%d: can't find module %v", b.display(path), imp.path.line, imp.path.lexeme)
+		}
+		done, seen := b.modules[module]
+		switch {
+		case seen && !done:
+			return "", fmt.Errorf("%v:%d: circular import of %v", b.display(path), imp.path.line, imp.path.lexeme)
+		case seen:
+			// the module is already part of the bundle
+			edit.text = "// import " + imp.path.lexeme + " (bundled above)"
+		default:
+			code, err := b.inline(module)
+			if err != nil {
+				return "", err
+			}
+			// the rest of the import's line continues after the comment telling where it is from
+			edit.text = sourceComment + b.display(module) + ":1\n" + strings.TrimSuffix(code, "\n") + "\n" +
+				sourceComment + b.display(path) + ":" + strconv.Itoa(imp.end.line) + "\n"
+		}
+		fix.edits = append(fix.edits, edit)
+	}
+	b.modules[path] = true
+	bundled, _ := applyFixes(source, []*Fix{fix})
+	return bundled, nil
+}
+
+// runBundle implements the 'bundle' subcommand. The flags may come before or after the script.
+func runBundle(args []string) {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	outPath := flags.String("o", "", "write the bundle to this file instead of printing it")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		path := flags.Arg(0)
+		flags.Parse(flags.Args()[1:])
+		args = append([]string{path}, flags.Args()...)
+	} else {
+		args = flags.Args()
+	}
+	if len(args) != 1 {
+		fmt.Println("usage: glox.exe bundle [-o file] [script]")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	bundled, err := Bundle(args[0], loadProject(args[0]).searchPath())
+	if err != nil {
+		fmt.Printf("Can't bundle %v: %v.\n", args[0], err)
+		os.Exit(65)
+	}
+	if *outPath == "" {
+		fmt.Print(bundled)
+		return
+	}
+	if err := ioutil.WriteFile(*outPath, []byte(bundled), 0644); err != nil {
+		fmt.Printf("Can't write file at [%v].\n", *outPath)
+		os.Exit(74)
+	}
+}
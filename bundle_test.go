@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBundle checks that a module imported twice is inlined once, with source comments around it
+func TestBundle(t *testing.T) {
+	bundled, err := Bundle("test-files/imports.lox", nil)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v\n", err)
+	}
+	if n := strings.Count(bundled, "fun greet(name)"); n != 1 {
+		t.Errorf("greet.lox inlined %d times\n", n)
+	}
+	for _, want := range []string{"// glox:source testdata/modules/shout.lox:1\n", "// glox:source imports.lox:1\n"} {
+		if !strings.Contains(bundled, want) {
+			t.Errorf("Bundle lacks %q:\n%v", want, bundled)
+		}
+	}
+	if _, err := Bundle("test-files/importcycle.lox", nil); err == nil || !strings.Contains(err.Error(), "circular import") {
+		t.Errorf("Circular import wasn't reported: %v\n", err)
+	}
+}
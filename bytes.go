@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoxBytes is a mutable buffer of binary data created by the bytes natives. Like functions it is
+// passed by reference, setByte() on a buffer is visible through every variable holding it.
+type LoxBytes struct {
+	b []byte
+	// frozen bytes can't be changed, see freeze()
+	frozen bool
+}
+
+// String shows the length and (the start of) the contents in hex
+func (lb *LoxBytes) String() string {
+	const maxShown = 32
+	if len(lb.b) > maxShown {
+		return "<bytes " + strconv.Itoa(len(lb.b)) + ": " + hex.EncodeToString(lb.b[:maxShown]) + "...>"
+	}
+	return "<bytes " + strconv.Itoa(len(lb.b)) + ": " + hex.EncodeToString(lb.b) + ">"
+}
+
+// byteIndex checks that v is an integer index into a buffer of length n, end allows n itself
+func byteIndex(v interface{}, n int, end bool) (int, bool) {
+	i, ok := v.(int64)
+	if !ok || i < 0 || i > int64(n) || (i == int64(n) && !end) {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// maxBytesLength is the largest number of bytes a single native call allocates, asking for more is an error
+// instead of taking the interpreter down
+const maxBytesLength = 1 << 30
+
+// bytesLimitError reports a length over maxBytesLength
+func bytesLimitError(n int64) RuntimeError {
+	return RuntimeError{msg: fmt.Sprintf("Length %d is over the limit of %d bytes.", n, maxBytesLength)}
+}
+
+// bytes(n) returns a new buffer of n zero bytes
+func nativeBytes(in *Interpreter, args []interface{}) interface{} {
+	n, ok := args[0].(int64)
+	if !ok || n < 0 {
+		return RuntimeError{msg: "bytes() expects a non-negative integer length."}
+	}
+	if n > maxBytesLength {
+		return bytesLimitError(n)
+	}
+	return &LoxBytes{b: make([]byte, n)}
+}
+
+// toBytes(str) returns the UTF-8 encoding of a string
+func nativeToBytes(in *Interpreter, args []interface{}) interface{} {
+	str, ok := args[0].(string)
+	if !ok {
+		return RuntimeError{msg: "toBytes() expects a string."}
+	}
+	return &LoxBytes{b: []byte(str)}
+}
+
+// byteLength(b) returns the number of bytes in a buffer
+func nativeByteLength(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	if !ok {
+		return RuntimeError{msg: "byteLength() expects bytes."}
+	}
+	return int64(len(b.b))
+}
+
+// byteAt(b, i) returns the byte at index i as an integer from 0 to 255
+func nativeByteAt(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	if !ok {
+		return RuntimeError{msg: "byteAt() expects bytes and an index."}
+	}
+	i, ok := byteIndex(args[1], len(b.b), false)
+	if !ok {
+		return RuntimeError{msg: "Byte index out of range."}
+	}
+	return int64(b.b[i])
+}
+
+// setByte(b, i, v) stores the integer v (0 to 255) at index i
+func nativeSetByte(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	v, vok := args[2].(int64)
+	if !ok || !vok {
+		return RuntimeError{msg: "setByte() expects bytes, an index and an integer value."}
+	}
+	i, ok := byteIndex(args[1], len(b.b), false)
+	if !ok {
+		return RuntimeError{msg: "Byte index out of range."}
+	}
+	if b.frozen {
+		return frozenError("setByte")
+	}
+	if v < 0 || v > 255 {
+		return RuntimeError{msg: "Byte value must be between 0 and 255."}
+	}
+	b.b[i] = byte(v)
+	return nil
+}
+
+// slice(b, start, end) returns a copy of the bytes from start (inclusive) to end (exclusive)
+func nativeSlice(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	if !ok {
+		return RuntimeError{msg: "slice() expects bytes, a start and an end index."}
+	}
+	start, sok := byteIndex(args[1], len(b.b), true)
+	end, eok := byteIndex(args[2], len(b.b), true)
+	if !sok || !eok || start > end {
+		return RuntimeError{msg: "Slice bounds out of range."}
+	}
+	return &LoxBytes{b: append([]byte(nil), b.b[start:end]...)}
+}
+
+// toString(b, encoding) decodes bytes as "utf-8" (invalid sequences are an error) or "latin1"
+func nativeToString(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	enc, eok := args[1].(string)
+	if !ok || !eok {
+		return RuntimeError{msg: "toString() expects bytes and an encoding name."}
+	}
+	switch strings.ToLower(enc) {
+	case "utf-8", "utf8":
+		if !utf8.Valid(b.b) {
+			return RuntimeError{msg: "Bytes are not valid UTF-8."}
+		}
+		return string(b.b)
+	case "latin1", "iso-8859-1":
+		runes := make([]rune, len(b.b))
+		for i, c := range b.b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+	return RuntimeError{msg: "Unknown encoding '" + enc + "', expected \"utf-8\" or \"latin1\"."}
+}
+
+// readFileBytes(path) returns the contents of a file
+func nativeReadFileBytes(in *Interpreter, args []interface{}) interface{} {
+	path, ok := args[0].(string)
+	if !ok {
+		return RuntimeError{msg: "readFileBytes() expects a path."}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RuntimeError{msg: "Can't read file '" + path + "'."}
+	}
+	return &LoxBytes{b: data}
+}
+
+// writeFileBytes(path, b) replaces the contents of a file with the given bytes
+func nativeWriteFileBytes(in *Interpreter, args []interface{}) interface{} {
+	path, ok := args[0].(string)
+	b, bok := args[1].(*LoxBytes)
+	if !ok || !bok {
+		return RuntimeError{msg: "writeFileBytes() expects a path and bytes."}
+	}
+	if err := ioutil.WriteFile(path, b.b, 0644); err != nil {
+		return RuntimeError{msg: "Can't write file '" + path + "'."}
+	}
+	return nil
+}
+
+// hexEncode(b) returns the bytes as a lowercase hex string
+func nativeHexEncode(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	if !ok {
+		return RuntimeError{msg: "hexEncode() expects bytes."}
+	}
+	return hex.EncodeToString(b.b)
+}
+
+// hexDecode(str) parses a hex string into bytes
+func nativeHexDecode(in *Interpreter, args []interface{}) interface{} {
+	str, ok := args[0].(string)
+	if !ok {
+		return RuntimeError{msg: "hexDecode() expects a string."}
+	}
+	data, err := hex.DecodeString(str)
+	if err != nil {
+		return RuntimeError{msg: "Invalid hex string."}
+	}
+	return &LoxBytes{b: data}
+}
+
+// base64Encode(b) returns the bytes in standard base64 with padding
+func nativeBase64Encode(in *Interpreter, args []interface{}) interface{} {
+	b, ok := args[0].(*LoxBytes)
+	if !ok {
+		return RuntimeError{msg: "base64Encode() expects bytes."}
+	}
+	return base64.StdEncoding.EncodeToString(b.b)
+}
+
+// base64Decode(str) parses standard base64 into bytes
+func nativeBase64Decode(in *Interpreter, args []interface{}) interface{} {
+	str, ok := args[0].(string)
+	if !ok {
+		return RuntimeError{msg: "base64Decode() expects a string."}
+	}
+	data, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return RuntimeError{msg: "Invalid base64 string."}
+	}
+	return &LoxBytes{b: data}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// scriptNodeID names the node that stands for the top-level code of a script
+const scriptNodeID = "<script>"
+
+// CallGraphNode is a function (or the top-level script) in the static call graph
+type CallGraphNode struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Line      int    `json:"line,omitempty"`
+	Native    bool   `json:"native,omitempty"`
+	Recursive bool   `json:"recursive,omitempty"`
+}
+
+// CallGraphEdge is a possible call from one node to another.
+// Indirect edges come from calls through variables or parameters, which may hold any function used as a value.
+type CallGraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Line     int    `json:"line"`
+	Indirect bool   `json:"indirect,omitempty"`
+}
+
+// CallGraph is the static call graph of a script
+type CallGraph struct {
+	Nodes []*CallGraphNode `json:"nodes"`
+	Edges []*CallGraphEdge `json:"edges"`
+}
+
+// NewCallGraph builds the call graph of a script from its symbol table
+func NewCallGraph(table *SymbolTable) *CallGraph {
+	g := &CallGraph{
+		Nodes: []*CallGraphNode{{ID: scriptNodeID, Name: scriptNodeID}},
+		Edges: make([]*CallGraphEdge, 0),
+	}
+	// functions used as values (rather than called by name) might be called from anywhere
+	escaping := make([]*Symbol, 0)
+	for _, sym := range table.symbols {
+		if sym.kind != FunSymbol {
+			continue
+		}
+		g.Nodes = append(g.Nodes, &CallGraphNode{ID: nodeID(sym), Name: sym.name.lexeme, Line: sym.name.line})
+		for _, ref := range sym.refs {
+			if !ref.call && !ref.write {
+				escaping = append(escaping, sym)
+				break
+			}
+		}
+	}
+	natives := make(map[string]bool)
+	seen := make(map[CallGraphEdge]bool)
+	addEdge := func(from, to string, line int, indirect bool) {
+		key := CallGraphEdge{From: from, To: to, Indirect: indirect}
+		if !seen[key] {
+			seen[key] = true
+			g.Edges = append(g.Edges, &CallGraphEdge{From: from, To: to, Line: line, Indirect: indirect})
+		}
+	}
+	for _, site := range table.calls {
+		from := nodeID(site.from)
+		switch {
+		case site.callee != nil && site.callee.target == nil:
+			// not declared in the script, assume a native function
+			name := site.callee.tkn.lexeme
+			if !natives[name] {
+				natives[name] = true
+				g.Nodes = append(g.Nodes, &CallGraphNode{ID: name, Name: name, Native: true})
+			}
+			addEdge(from, name, site.paren.line, false)
+		case site.callee != nil && site.callee.target.kind == FunSymbol:
+			addEdge(from, nodeID(site.callee.target), site.paren.line, false)
+		default:
+			for _, sym := range escaping {
+				addEdge(from, nodeID(sym), site.paren.line, true)
+			}
+		}
+	}
+	g.markRecursion()
+	return g
+}
+
+// nodeID returns a unique id for a function symbol, names alone aren't unique once functions are nested
+func nodeID(fun *Symbol) string {
+	if fun == nil {
+		return scriptNodeID
+	}
+	return fun.name.lexeme + ":" + strconv.Itoa(fun.name.line)
+}
+
+// markRecursion flags every node that can reach itself through one or more calls
+func (g *CallGraph) markRecursion() {
+	succ := make(map[string][]string)
+	for _, e := range g.Edges {
+		succ[e.From] = append(succ[e.From], e.To)
+	}
+	for _, node := range g.Nodes {
+		visited := make(map[string]bool)
+		work := append([]string(nil), succ[node.ID]...)
+		for len(work) > 0 && !node.Recursive {
+			next := work[len(work)-1]
+			work = work[:len(work)-1]
+			if next == node.ID {
+				node.Recursive = true
+			} else if !visited[next] {
+				visited[next] = true
+				work = append(work, succ[next]...)
+			}
+		}
+	}
+}
+
+// Dot renders the call graph in graphviz format, indirect calls are dashed and recursive functions are red
+func (g *CallGraph) Dot() string {
+	out := "digraph callgraph {\n"
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf("label=%q", n.Name)
+		if n.Native {
+			attrs += ", shape=box"
+		}
+		if n.Recursive {
+			attrs += ", color=red"
+		}
+		out += fmt.Sprintf("\t%q [%v];\n", n.ID, attrs)
+	}
+	for _, e := range g.Edges {
+		if e.Indirect {
+			out += fmt.Sprintf("\t%q -> %q [style=dashed];\n", e.From, e.To)
+		} else {
+			out += fmt.Sprintf("\t%q -> %q;\n", e.From, e.To)
+		}
+	}
+	return out + "}"
+}
+
+// runCallGraph implements the 'callgraph' subcommand
+func runCallGraph(args []string) {
+	flags := flag.NewFlagSet("callgraph", flag.ExitOnError)
+	format := flags.String("format", "dot", "output format: dot or json")
+	flags.Parse(args)
+	if flags.NArg() != 1 || (*format != "dot" && *format != "json") {
+		fmt.Println("usage: glox.exe callgraph [-format dot|json] [script]")
+		os.Exit(64)
+	}
+	g := NewCallGraph(NewSymbolTable(parseFile(flags.Arg(0))))
+	if *format == "json" {
+		printJSON(g)
+	} else {
+		fmt.Println(g.Dot())
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severity decides what a diagnostic does to a 'glox check' run
+type Severity int
+
+const (
+	// SeverityIgnore drops the diagnostic
+	SeverityIgnore Severity = iota
+	// SeverityWarning prints it
+	SeverityWarning
+	// SeverityError prints it and fails the check
+	SeverityError
+)
+
+// severityNames are the levels accepted in glox.toml
+var severityNames = map[string]Severity{"ignore": SeverityIgnore, "warning": SeverityWarning, "error": SeverityError}
+
+// optInWarnings are only reported by 'glox check' when their severity is configured, the code they flag is
+// often intended
+var optInWarnings = map[string]bool{"W007": true}
+
+// Severities maps diagnostic ids to their configured severity, ids that aren't in it keep their default
+type Severities map[string]Severity
+
+// of returns the severity of the diagnostic with the given id, warnings outside the catalog have no id and
+// stay warnings
+func (s Severities) of(id string) Severity {
+	if sev, ok := s[id]; ok {
+		return sev
+	}
+	if optInWarnings[id] {
+		return SeverityIgnore
+	}
+	if id == "" || strings.HasPrefix(id, "W") {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// set configures the severity of a comma separated list of ids. Only warnings can be configured,
+// a script with a syntax error can't run whatever level it is given.
+func (s Severities) set(ids string, sev Severity) error {
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+		if diagnosticByID(id) == nil {
+			return fmt.Errorf("unknown diagnostic %v", id)
+		}
+		if !strings.HasPrefix(id, "W") {
+			return fmt.Errorf("%v is an error and can't be reconfigured, only warnings (W...) can", id)
+		}
+		s[id] = sev
+	}
+	return nil
+}
+
+// warningsAsErrors makes every warning of the catalog an error, except the opt-in ones
+func (s Severities) warningsAsErrors() {
+	for _, d := range catalog {
+		if strings.HasPrefix(d.id, "W") && !optInWarnings[d.id] {
+			s[d.id] = SeverityError
+		}
+	}
+}
+
+// severitiesFromConfig reads the [diagnostics] table of a glox.toml, which maps ids to "error",
+// "warning" or "ignore", plus 'warnings-as-errors = true' in the same table
+func severitiesFromConfig(config Config) (Severities, error) {
+	s := make(Severities)
+	table := config["diagnostics"]
+	if on, ok := table["warnings-as-errors"].(bool); ok && on {
+		s.warningsAsErrors()
+	}
+	// sorted so the first invalid entry reported is always the same one
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "warnings-as-errors" {
+			continue
+		}
+		level, _ := table[key].(string)
+		sev, ok := severityNames[level]
+		if !ok {
+			return nil, fmt.Errorf("the severity of %v must be \"error\", \"warning\" or \"ignore\"", key)
+		}
+		if err := s.set(key, sev); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// lintAll runs every lint over a parsed script, it is what 'glox check' reports
+func lintAll(stmts []Stmt) []Warning {
+	table := NewSymbolTable(stmts)
+	warnings := lintFloatEquality(stmts)
+	warnings = append(warnings, lintDeadCode(table)...)
+	warnings = append(warnings, lintAssignCondition(stmts)...)
+	warnings = append(warnings, lintUnused(table)...)
+	warnings = append(warnings, lintShadowing(table)...)
+	warnings = append(warnings, lintArity(table)...)
+	warnings = append(warnings, lintPlugins(stmts)...)
+	return uniqueWarnings(warnings)
+}
+
+// runCheck implements the 'check' subcommand: it parses and type checks a script and runs every lint over it, reporting
+// each diagnostic at its configured severity. The exit status is 1 if anything is reported as an error,
+// so it can gate a build. Flags override the project's configuration file.
+func runCheck(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	werror := flags.Bool("werror", false, "report every warning as an error")
+	errorIDs := flags.String("error", "", "comma separated diagnostic ids to report as errors")
+	warningIDs := flags.String("warning", "", "comma separated diagnostic ids to report as warnings")
+	ignoreIDs := flags.String("ignore", "", "comma separated diagnostic ids to drop")
+	noConfig := flags.Bool("no-config", false, "don't read "+configFile)
+	watch := flags.Bool("watch", false, "with several files, keep checking the files affected by every edit")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Println("usage: glox.exe check [flags] [script | dir/... | glob]...")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	path := flags.Arg(0)
+	severities := make(Severities)
+	// the project of the first argument configures the whole check
+	configPath := path
+	if isPattern(path) {
+		configPath = filepath.Join(patternDir(path), configFile)
+	}
+	project := loadProject(configPath)
+	if !*noConfig {
+		var err error
+		if severities, err = severitiesFromConfig(project.config); err != nil {
+			fmt.Printf("Invalid configuration: %v.\n", err)
+			os.Exit(64)
+		}
+	}
+	if *werror {
+		severities.warningsAsErrors()
+	}
+	for _, level := range []struct {
+		ids string
+		sev Severity
+	}{{*errorIDs, SeverityError}, {*warningIDs, SeverityWarning}, {*ignoreIDs, SeverityIgnore}} {
+		if err := severities.set(level.ids, level.sev); err != nil {
+			fmt.Printf("Invalid flag: %v.\n", err)
+			os.Exit(64)
+		}
+	}
+	if flags.NArg() > 1 || isPattern(path) {
+		if checkWorkspace(flags.Args(), project.searchPath(), severities, *watch) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	stmts := parseFile(path)
+	errors := 0
+	if !checkTypes(stmts, reporter) {
+		errors++
+	}
+	for _, w := range lintAll(stmts) {
+		id, _ := localize(w.msg, "")
+		switch severities.of(id) {
+		case SeverityError:
+			errors++
+			fmt.Println(w.formatAs("Error", reporter.lang))
+		case SeverityWarning:
+			fmt.Println(w.format(reporter.lang))
+		}
+	}
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import "time"
+
+// Clock is the interpreter's source of time, every time-related native goes through it
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the real wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// VirtualClock is a Clock that only moves when told to, so time-dependent scripts can be tested
+// deterministically and instantly. Sleeping on a VirtualClock advances it without blocking.
+type VirtualClock struct {
+	now time.Time
+}
+
+// NewVirtualClock returns a VirtualClock stopped at the given time
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+func (v *VirtualClock) Now() time.Time {
+	return v.now
+}
+
+func (v *VirtualClock) Sleep(d time.Duration) {
+	v.Advance(d)
+}
+
+// Advance moves the clock forward by d
+func (v *VirtualClock) Advance(d time.Duration) {
+	v.now = v.now.Add(d)
+}
+
+// NewTestInterpreter returns an interpreter running on a VirtualClock that starts at the Unix epoch.
+// It additionally defines the advanceTime(ms) native so scripts can move time forward themselves.
+func NewTestInterpreter() *Interpreter {
+	in := NewInterpreter()
+	vclock := NewVirtualClock(time.Unix(0, 0))
+	in.clock = vclock
+	in.globals.Define("advanceTime", &NativeFunction{"advanceTime", 1, func(in *Interpreter, args []interface{}) interface{} {
+		ms, ok := toFloat(args[0])
+		if !ok || ms < 0 {
+			return RuntimeError{msg: "advanceTime() expects a non-negative number of milliseconds."}
+		}
+		vclock.Advance(millis(ms))
+		return nil
+	}})
+	return in
+}
+
+// millis converts a (fractional) number of milliseconds into a Duration
+func millis(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestVirtualClock checks that test interpreters never block and only see time they advanced themselves
+func TestVirtualClock(t *testing.T) {
+	in := NewTestInterpreter()
+	parser := NewParser(NewLexScanner("var start = clock(); sleep(60000); advanceTime(1500); var elapsed = clock() - start;"))
+	begin := time.Now()
+	in.Interpret(parser.Parse())
+	if time.Since(begin) > time.Second {
+		t.Errorf("sleep() blocked on a virtual clock\n")
+	}
+	elapsed, err := in.globals.Get(Token{lexeme: "elapsed"})
+	if err != nil || elapsed != 61.5 {
+		t.Errorf("Wrong elapsed virtual time. Wanted: 61.5, Got: %v (%v)\n", elapsed, err)
+	}
+}
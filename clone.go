@@ -0,0 +1,69 @@
+package main
+
+// clone() and freeze() work on the mutable values: sets and bytes. Everything else is immutable, so
+// cloning returns it unchanged and freezing it is a no-op.
+
+// cloneValue returns a deep copy of v, copies are never frozen. seen maps values already copied to
+// their copy, so a value reachable twice is copied once.
+func cloneValue(v interface{}, seen map[interface{}]interface{}) interface{} {
+	// only the mutable values can be shared, other values (like tuples) aren't even valid map keys
+	switch v.(type) {
+	case *LoxBytes, *LoxSet:
+		if c, ok := seen[v]; ok {
+			return c
+		}
+	}
+	switch val := v.(type) {
+	case *LoxBytes:
+		c := &LoxBytes{b: append([]byte(nil), val.b...)}
+		seen[v] = c
+		return c
+	case *LoxSet:
+		c := newSet()
+		seen[v] = c
+		for _, elem := range val.elems {
+			key, _ := setKey(elem)
+			c.add(key, cloneValue(elem, seen))
+		}
+		return c
+	case Tuple:
+		c := make(Tuple, len(val))
+		for i, elem := range val {
+			c[i] = cloneValue(elem, seen)
+		}
+		return c
+	}
+	return v
+}
+
+// clone(v) returns a deep copy of a set or bytes that can be changed (and isn't frozen)
+func nativeClone(in *Interpreter, args []interface{}) interface{} {
+	return cloneValue(args[0], make(map[interface{}]interface{}))
+}
+
+// freeze(v) makes a set or bytes read-only, changing it afterwards is a runtime error. Returns v.
+func nativeFreeze(in *Interpreter, args []interface{}) interface{} {
+	switch val := args[0].(type) {
+	case *LoxBytes:
+		val.frozen = true
+	case *LoxSet:
+		val.frozen = true
+	}
+	return args[0]
+}
+
+// isFrozen(v) reports whether v was frozen
+func nativeIsFrozen(in *Interpreter, args []interface{}) interface{} {
+	switch val := args[0].(type) {
+	case *LoxBytes:
+		return val.frozen
+	case *LoxSet:
+		return val.frozen
+	}
+	return false
+}
+
+// frozenError is returned by the natives that would change a frozen value
+func frozenError(name string) RuntimeError {
+	return RuntimeError{msg: "Can't " + name + "() a frozen value."}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Completion is a candidate for the name being typed at the cursor.
+// The exported fields double as the JSON format printed by 'glox complete -json'.
+type Completion struct {
+	Label string `json:"label"`
+	// Kind is one of local, parameter, function, variable, native or keyword
+	Kind string `json:"kind"`
+}
+
+// cursorName stands in for the name being completed while the code before the cursor is parsed
+const cursorName = "__cursor__"
+
+// completionRank orders the candidates, names from the closest scope come first
+var completionRank = map[string]int{"local": 0, "parameter": 0, "function": 1, "variable": 1, "native": 2, "keyword": 3}
+
+// Complete returns the candidates for the identifier that ends at offset in source, in order: the locals
+// in scope there, the script's globals, the bindings of env (the REPL's globals, natives and prelude if nil)
+// and keywords. The source doesn't have to parse, everything after the cursor is ignored for locals.
+func Complete(source string, offset int, env *Environment) []Completion {
+	if offset > len(source) {
+		offset = len(source)
+	}
+	start := offset
+	for start > 0 && isAlphaNumeric(source[start-1]) {
+		start--
+	}
+	prefix := source[start:offset]
+	seen := make(map[string]bool)
+	candidates := make([]Completion, 0)
+	add := func(name, kind string) {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, Completion{Label: name, Kind: kind})
+		}
+	}
+	quiet := &ErrorReporter{out: ioutil.Discard}
+	// the tolerant parser closes the blocks the cursor is in, so with a name standing in for the one being
+	// typed the statement containing the cursor is the last one
+	scopes := make([][]Completion, 0)
+	seen[cursorName] = true
+	if stmts := parseTolerant(source[:start]+cursorName, quiet); len(stmts) > 0 && stmts[len(stmts)-1] != nil {
+		// top-level names are globals, they come from the whole script below
+		collectInner(stmts[len(stmts)-1], &scopes)
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		for _, c := range scopes[i] {
+			add(c.Label, c.Kind)
+		}
+	}
+	// globals are late bound, functions declared below the cursor can be called above it
+	for _, c := range declaredNames(parseTolerant(source, quiet), false) {
+		add(c.Label, c.Kind)
+	}
+	if env == nil {
+		for _, native := range natives {
+			add(native.name, "native")
+		}
+		for _, c := range declaredNames(prelude(), false) {
+			add(c.Label, "native")
+		}
+	} else {
+		for name, val := range env.bindings {
+			kind := "variable"
+			switch fn := val.(type) {
+			case *LoxFunction:
+				kind = "function"
+				if fn.internal {
+					kind = "native"
+				}
+			case LoxCaller:
+				kind = "native"
+			}
+			add(name, kind)
+		}
+	}
+	for word, typ := range reservedWords {
+		// reserved for classes, which glox doesn't have
+		if typ != Class && typ != Super && typ != ThisTok {
+			add(word, "keyword")
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := completionRank[candidates[i].Kind], completionRank[candidates[j].Kind]
+		if ri != rj {
+			return ri < rj
+		}
+		return candidates[i].Label < candidates[j].Label
+	})
+	return candidates
+}
+
+// collectScopes walks down to the statement containing the cursor, the last one of every statement list,
+// and appends the names of each local scope it enters, the outermost first. scope holds the names the
+// scope of stmts starts with (a function's parameters).
+func collectScopes(stmts []Stmt, scope []Completion, scopes *[][]Completion) {
+	last := len(stmts) - 1
+	for last >= 0 && stmts[last] == nil {
+		last--
+	}
+	if last < 0 {
+		*scopes = append(*scopes, scope)
+		return
+	}
+	scope = append(scope, declaredNames(stmts[:last], true)...)
+	if fn, ok := stmts[last].(*FunctionStmt); ok {
+		// a function can call itself
+		scope = append(scope, Completion{Label: fn.name.lexeme, Kind: "function"})
+	}
+	*scopes = append(*scopes, scope)
+	collectInner(stmts[last], scopes)
+}
+
+// collectInner enters the scopes of a statement that contains the cursor
+func collectInner(stmt Stmt, scopes *[][]Completion) {
+	switch s := stmt.(type) {
+	case *BlockStmt:
+		collectScopes(s.statements, nil, scopes)
+	case *IfStmt:
+		if s.elsePart != nil {
+			collectInner(s.elsePart, scopes)
+		} else {
+			collectInner(s.thenPart, scopes)
+		}
+	case *WhileStmt:
+		collectInner(s.statement, scopes)
+	case *ForInStmt:
+		*scopes = append(*scopes, []Completion{{Label: s.name.lexeme, Kind: "local"}})
+		collectInner(s.body, scopes)
+	case *FunctionStmt:
+		params := make([]Completion, 0, len(s.params))
+		for _, param := range s.params {
+			params = append(params, Completion{Label: param.lexeme, Kind: "parameter"})
+		}
+		collectScopes(s.body, params, scopes)
+	}
+}
+
+// declaredNames returns the names the given statements declare in their own scope
+func declaredNames(stmts []Stmt, local bool) []Completion {
+	names := make([]Completion, 0)
+	kind := "variable"
+	if local {
+		kind = "local"
+	}
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *VarStmt:
+			names = append(names, Completion{Label: s.name.lexeme, Kind: kind})
+		case *DestructureStmt:
+			if s.declare {
+				for _, name := range s.names {
+					names = append(names, Completion{Label: name.lexeme, Kind: kind})
+				}
+			}
+		case *FunctionStmt:
+			names = append(names, Completion{Label: s.name.lexeme, Kind: "function"})
+		}
+	}
+	return names
+}
+
+// runComplete implements the 'complete' subcommand: given file.lox:line:col it lists the candidates for
+// the name ending right before that column
+func runComplete(args []string) {
+	flags := flag.NewFlagSet("complete", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the candidates as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: glox.exe complete [-json] [script]:[line]:[col]")
+		os.Exit(64)
+	}
+	path, line, col, err := splitPosition(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	source := string(contents)
+	candidates := Complete(source, positionOffset(source, line, col), nil)
+	if *asJSON {
+		printJSON(candidates)
+		return
+	}
+	for _, c := range candidates {
+		fmt.Printf("%v\t%v\n", c.Label, c.Kind)
+	}
+}
+
+// positionOffset converts a 1-based line and column into a byte offset into source, positions outside it
+// are clamped to its start or end
+func positionOffset(source string, line, col int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		nl := strings.IndexByte(source[offset:], '\n')
+		if nl < 0 {
+			return len(source)
+		}
+		offset += nl + 1
+	}
+	switch {
+	case offset+col-1 < 0:
+		return 0
+	case offset+col-1 > len(source):
+		return len(source)
+	}
+	return offset + col - 1
+}
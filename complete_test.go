@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+// TestComplete checks that only the locals in scope at the cursor are offered, innermost first
+func TestComplete(t *testing.T) {
+	source := "var total;\nfun f(count) {\n  { var hidden; }\n  var cost;\n  co\n}\nfun compute() {}\n"
+	got := Complete(source, len(source)-len("\n}\nfun compute() {}\n"), nil)
+	want := []Completion{{"cost", "local"}, {"count", "parameter"}, {"compute", "function"}, {"contains", "native"}, {"coroutine", "native"}, {"coroutineStatus", "native"}, {"const", "keyword"}}
+	if len(got) != len(want) {
+		t.Fatalf("Wanted %v, got %v\n", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Candidate %d is %v, wanted %v\n", i, got[i], want[i])
+		}
+	}
+}
+
+// TestPositionOffset checks that positions are validated and that offsets never leave the source
+func TestPositionOffset(t *testing.T) {
+	for _, pos := range []string{"c.lox:1:0", "c.lox:1:-5", "c.lox:0:0", "c.lox:-1:3", "c.lox:1"} {
+		if _, _, _, err := splitPosition(pos); err == nil {
+			t.Errorf("%v was accepted\n", pos)
+		}
+	}
+	if path, line, col, err := splitPosition(`C:\lox\c.lox:2:3`); err != nil || path != `C:\lox\c.lox` || line != 2 || col != 3 {
+		t.Errorf("Wrong split: %v %v %v %v\n", path, line, col, err)
+	}
+	source := "var a;\nprint a;"
+	for _, c := range []struct{ line, col, want int }{
+		{1, 1, 0}, {2, 3, 9}, {1, 0, 0}, {1, -5, 0}, {0, 0, 0}, {2, 100, len(source)}, {9, 1, len(source)},
+	} {
+		if got := positionOffset(source, c.line, c.col); got != c.want {
+			t.Errorf("Offset of %d:%d is %d, wanted %d\n", c.line, c.col, got, c.want)
+		}
+	}
+}
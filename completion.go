@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// commandFlags lists the flags of every subcommand for shell completion, keep it in sync with their FlagSets.
+// Words that aren't flags (like notebook's 'run') are completed as the first argument of the subcommand.
+var commandFlags = map[string][]string{
+	"vet":        {"-float-eq", "-dead-code", "-assign-cond", "-unused", "-shadow", "-arity", "-fix", "-format"},
+	"refs":       {},
+	"outline":    {"-json", "-tolerant"},
+	"callgraph":  {"-format"},
+	"metrics":    {"-json"},
+	"test":       {"-run", "-p", "-v", "-update"},
+	"mutate":     {"-run"},
+	"fmt":        {"-organize-imports", "-w", "-d", "-format"},
+	"schedule":   {"-log-dir"},
+	"notebook":   {"run", "-format", "-o"},
+	"grammar":    {"-format"},
+	"explain":    {},
+	"bundle":     {"-o"},
+	"pack":       {"-o"},
+	"check":      {"-werror", "-error", "-warning", "-ignore", "-no-config", "-watch"},
+	"complete":   {"-json"},
+	"signature":  {"-json"},
+	"hover":      {"-json"},
+	"inlay":      {"-json"},
+	"completion": {"bash", "zsh", "fish", "powershell"},
+}
+
+// the completion command is registered here instead of in the commands map because it reads that map itself
+func init() {
+	commands["completion"] = runCompletion
+}
+
+// completionWriters generate the completion script for each supported shell
+var completionWriters = map[string]func(w io.Writer, prog string){
+	"bash":       writeBashCompletion,
+	"zsh":        writeZshCompletion,
+	"fish":       writeFishCompletion,
+	"powershell": writePowerShellCompletion,
+}
+
+// completionCommands returns the sorted subcommand names
+func completionCommands() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// globalFlags returns the flags accepted before the script or subcommand
+func globalFlags() []string {
+	names := make([]string, 0)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return names
+}
+
+func writeBashCompletion(w io.Writer, prog string) {
+	fn := "_" + strings.Replace(prog, "-", "_", -1)
+	fmt.Fprintf(w, "# bash completion for %v, load with: source <(%v completion bash)\n", prog, prog)
+	fmt.Fprintf(w, "%v() {\n", fn)
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" opts=""`)
+	fmt.Fprintln(w, `    if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(w, "        opts=%q\n", strings.Join(append(completionCommands(), globalFlags()...), " "))
+	fmt.Fprintln(w, `    else`)
+	fmt.Fprintln(w, `        case "${COMP_WORDS[1]}" in`)
+	for _, name := range completionCommands() {
+		fmt.Fprintf(w, "            %v) opts=%q ;;\n", name, strings.Join(commandFlags[name], " "))
+	}
+	fmt.Fprintln(w, `        esac`)
+	fmt.Fprintln(w, `    fi`)
+	fmt.Fprintln(w, `    COMPREPLY=( $(compgen -W "$opts" -- "$cur") )`)
+	fmt.Fprintln(w, `    if [[ "$cur" != -* ]]; then`)
+	fmt.Fprintln(w, `        COMPREPLY+=( $(compgen -f -X '!*.lox' -- "$cur") $(compgen -d -- "$cur") )`)
+	fmt.Fprintln(w, `    fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -o filenames -F %v %v\n", fn, prog)
+}
+
+// zsh can run bash completion functions, which keeps both shells in sync
+func writeZshCompletion(w io.Writer, prog string) {
+	fmt.Fprintf(w, "# zsh completion for %v, load with: source <(%v completion zsh)\n", prog, prog)
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	writeBashCompletion(w, prog)
+}
+
+func writeFishCompletion(w io.Writer, prog string) {
+	fmt.Fprintf(w, "# fish completion for %v, load with: %v completion fish | source\n", prog, prog)
+	fmt.Fprintf(w, "complete -c %v -f\n", prog)
+	fmt.Fprintf(w, "complete -c %v -n __fish_use_subcommand -a %q\n", prog, strings.Join(completionCommands(), " "))
+	for _, f := range globalFlags() {
+		fmt.Fprintf(w, "complete -c %v -n __fish_use_subcommand -o %v\n", prog, f[1:])
+	}
+	for _, name := range completionCommands() {
+		for _, f := range commandFlags[name] {
+			if strings.HasPrefix(f, "-") {
+				fmt.Fprintf(w, "complete -c %v -n '__fish_seen_subcommand_from %v' -o %v\n", prog, name, f[1:])
+			} else {
+				fmt.Fprintf(w, "complete -c %v -n '__fish_seen_subcommand_from %v' -a %v\n", prog, name, f)
+			}
+		}
+	}
+	fmt.Fprintf(w, "complete -c %v -a '(__fish_complete_suffix .lox)'\n", prog)
+}
+
+func writePowerShellCompletion(w io.Writer, prog string) {
+	fmt.Fprintf(w, "# PowerShell completion for %v, load with: %v completion powershell | Out-String | Invoke-Expression\n", prog, prog)
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName '%v', '%v.exe' -ScriptBlock {\n", prog, prog)
+	fmt.Fprintln(w, "    param($wordToComplete, $commandAst, $cursorPosition)")
+	fmt.Fprintln(w, "    $commands = @{")
+	for _, name := range completionCommands() {
+		quoted := make([]string, 0, len(commandFlags[name]))
+		for _, f := range commandFlags[name] {
+			quoted = append(quoted, "'"+f+"'")
+		}
+		fmt.Fprintf(w, "        '%v' = @(%v)\n", name, strings.Join(quoted, ", "))
+	}
+	fmt.Fprintln(w, "    }")
+	globals := make([]string, 0)
+	for _, f := range globalFlags() {
+		globals = append(globals, "'"+f+"'")
+	}
+	fmt.Fprintln(w, "    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })")
+	fmt.Fprintln(w, "    if ($words.Count -eq 1 -or ($words.Count -eq 2 -and $wordToComplete)) {")
+	fmt.Fprintf(w, "        $candidates = @($commands.Keys) + @(%v)\n", strings.Join(globals, ", "))
+	fmt.Fprintln(w, "    } else {")
+	fmt.Fprintln(w, "        $candidates = $commands[$words[1]]")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | Sort-Object | ForEach-Object {")
+	fmt.Fprintln(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    if (-not $wordToComplete.StartsWith('-')) {")
+	fmt.Fprintln(w, "        Get-ChildItem -Path \"$wordToComplete*\" -ErrorAction SilentlyContinue |")
+	fmt.Fprintln(w, "            Where-Object { $_.PSIsContainer -or $_.Extension -eq '.lox' } | ForEach-Object {")
+	fmt.Fprintln(w, "                [System.Management.Automation.CompletionResult]::new($_.Name, $_.Name, 'ProviderItem', $_.Name)")
+	fmt.Fprintln(w, "            }")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "}")
+}
+
+// runCompletion implements the 'completion' subcommand
+func runCompletion(args []string) {
+	if len(args) != 1 || completionWriters[args[0]] == nil {
+		fmt.Println("usage: glox.exe completion bash|zsh|fish|powershell")
+		os.Exit(64)
+	}
+	prog := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	completionWriters[args[0]](os.Stdout, prog)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFile is the name of the optional project configuration, configFiles are all the names it may have.
+// The first one found in the script's directory or the closest directory above it configures the run.
+const configFile = "glox.toml"
+
+var configFiles = []string{configFile, ".gloxrc.json"}
+
+// Config is a parsed glox.toml, it maps table names to their keys. Keys before the first table header
+// are in the "" table.
+type Config map[string]map[string]interface{}
+
+// ParseConfig reads the subset of TOML glox.toml is written in: '[table]' headers, '#' comments and
+// 'key = value' lines where the value is a string, an integer, a boolean or an array of strings.
+func ParseConfig(source string) (Config, error) {
+	config := Config{"": {}}
+	table := ""
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", i+1)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := config[table]; !ok {
+				config[table] = make(map[string]interface{})
+			}
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected 'key = value'", i+1)
+		}
+		key := strings.Trim(strings.TrimSpace(line[:eq]), "\"")
+		val, err := parseConfigValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		config[table][key] = val
+	}
+	return config, nil
+}
+
+// stripComment removes a '#' comment that isn't inside a string
+func stripComment(line string) string {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '#' && !quoted:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigValue parses the right-hand side of a 'key = value' line
+func parseConfigValue(s string) (interface{}, error) {
+	switch {
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case strings.HasPrefix(s, "\""):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("arrays must be written on one line")
+		}
+		elems := make([]string, 0)
+		for _, elem := range strings.Split(s[1:len(s)-1], ",") {
+			if elem = strings.TrimSpace(elem); elem == "" {
+				continue
+			}
+			str, err := strconv.Unquote(elem)
+			if err != nil {
+				return nil, fmt.Errorf("arrays may only hold strings")
+			}
+			elems = append(elems, str)
+		}
+		return elems, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid value %v", s)
+}
+
+// parseJSONConfig reads a .gloxrc.json, an object holding an object for every table
+func parseJSONConfig(source string) (Config, error) {
+	var tables map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(source), &tables); err != nil {
+		return nil, err
+	}
+	config := Config{"": {}}
+	for name, table := range tables {
+		config[name] = make(map[string]interface{})
+		for key, val := range table {
+			switch v := val.(type) {
+			case float64:
+				// the same types as in glox.toml
+				val = int64(v)
+			case []interface{}:
+				elems := make([]string, len(v))
+				for i, elem := range v {
+					str, ok := elem.(string)
+					if !ok {
+						return nil, fmt.Errorf("%v.%v: arrays may only hold strings", name, key)
+					}
+					elems[i] = str
+				}
+				val = elems
+			}
+			config[name][key] = val
+		}
+	}
+	return config, nil
+}
+
+// LoadConfig reads and parses the configuration file at path
+func LoadConfig(path string) (Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parse := ParseConfig
+	if strings.HasSuffix(path, ".json") {
+		parse = parseJSONConfig
+	}
+	config, err := parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return config, nil
+}
+
+// Project is the configuration of the directory tree a script is in. Its settings are defaults, the
+// command line flags override them.
+type Project struct {
+	// path is the configuration file, empty if none was found
+	path   string
+	config Config
+}
+
+// project configures the script run from the command line (or the REPL), set up by main()
+var project = &Project{config: Config{"": {}}}
+
+// FindProject looks for a configuration file in the directory of the script at path and then upwards
+func FindProject(path string) (*Project, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		for _, name := range configFiles {
+			configPath := filepath.Join(dir, name)
+			if _, err := os.Stat(configPath); err != nil {
+				continue
+			}
+			config, err := LoadConfig(configPath)
+			if err != nil {
+				return nil, err
+			}
+			p := &Project{path: configPath, config: config}
+			if err := p.validate(); err != nil {
+				return nil, fmt.Errorf("%v: %v", configPath, err)
+			}
+			return p, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return &Project{config: Config{"": {}}}, nil
+		}
+		dir = parent
+	}
+}
+
+// loadProject is FindProject for the commands, which can't continue with an invalid configuration
+func loadProject(path string) *Project {
+	p, err := FindProject(path)
+	if err != nil {
+		fmt.Printf("Invalid configuration: %v.\n", err)
+		os.Exit(64)
+	}
+	return p
+}
+
+// validate checks the settings glox reads, so a typo fails every command instead of being ignored
+func (p *Project) validate() error {
+	if name, ok := p.config["language"]["dialect"].(string); ok {
+		if _, err := ParseDialect(name); err != nil {
+			return err
+		}
+	}
+	if _, err := severitiesFromConfig(p.config); err != nil {
+		return err
+	}
+	for _, name := range p.strings("sandbox", "deny") {
+		found := false
+		for _, native := range natives {
+			found = found || native.name == name
+		}
+		if !found {
+			return fmt.Errorf("the sandbox can't deny %v, it isn't a native function", name)
+		}
+	}
+	return nil
+}
+
+// bool returns a boolean setting, false if it isn't set
+func (p *Project) bool(table, key string) bool {
+	on, _ := p.config[table][key].(bool)
+	return on
+}
+
+// string returns a string setting, def if it isn't set
+func (p *Project) string(table, key, def string) string {
+	if str, ok := p.config[table][key].(string); ok {
+		return str
+	}
+	return def
+}
+
+// strings returns an array setting
+func (p *Project) strings(table, key string) []string {
+	elems, _ := p.config[table][key].([]string)
+	return elems
+}
+
+// searchPath returns the library directories of the project's '[imports] path', which are relative to
+// the configuration file, followed by the ones in the GLOX_PATH environment variable
+func (p *Project) searchPath() []string {
+	dirs := make([]string, 0)
+	for _, dir := range p.strings("imports", "path") {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(p.path), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return append(dirs, filepath.SplitList(os.Getenv(importPathEnv))...)
+}
+
+// sandbox replaces the natives the project denies with functions that raise a runtime error
+func (p *Project) sandbox(in *Interpreter) {
+	for _, name := range p.strings("sandbox", "deny") {
+		for _, native := range natives {
+			if native.name != name {
+				continue
+			}
+			msg := fmt.Sprintf("%v is denied by the sandbox of %v.", name, filepath.Base(p.path))
+			in.globals.Define(name, &NativeFunction{name, native.nargs, func(*Interpreter, []interface{}) interface{} {
+				return RuntimeError{msg: msg}
+			}})
+		}
+	}
+}
+
+// flagSet reports whether a global flag was given on the command line, so it takes precedence over the project
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
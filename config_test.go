@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSeveritiesFromConfig checks that a [diagnostics] table overrides warnings-as-errors for single ids
+func TestSeveritiesFromConfig(t *testing.T) {
+	config, err := ParseConfig("# strict\n[diagnostics]\nwarnings-as-errors = true\nW002 = \"ignore\" # library\n")
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v\n", err)
+	}
+	s, err := severitiesFromConfig(config)
+	if err != nil {
+		t.Fatalf("severitiesFromConfig failed: %v\n", err)
+	}
+	// warnings-as-errors covers every warning of the catalog except the opt-in ones
+	want := Severities{}
+	for _, d := range catalog {
+		if strings.HasPrefix(d.id, "W") && !optInWarnings[d.id] {
+			want[d.id] = SeverityError
+		}
+	}
+	want["W002"] = SeverityIgnore
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Got %v, want %v\n", s, want)
+	}
+	for _, src := range []string{"[diagnostics]\nE011 = \"ignore\"\n", "[diagnostics]\nW001 = \"fatal\"\n", "[diagnostics\n"} {
+		if config, err := ParseConfig(src); err == nil {
+			if _, err := severitiesFromConfig(config); err == nil {
+				t.Errorf("%q was accepted\n", src)
+			}
+		}
+	}
+}
+
+// TestParseJSONConfig checks that .gloxrc.json values get the same types as in glox.toml
+func TestParseJSONConfig(t *testing.T) {
+	config, err := parseJSONConfig(`{"imports": {"path": ["lib"]}, "language": {"checked-int": true}, "fmt": {"width": 80}}`)
+	if err != nil {
+		t.Fatalf("parseJSONConfig failed: %v\n", err)
+	}
+	p := &Project{path: "glox/.gloxrc.json", config: config}
+	if !p.bool("language", "checked-int") || config["fmt"]["width"] != int64(80) {
+		t.Errorf("Wrong settings: %v\n", config)
+	}
+	if dirs := p.searchPath(); len(dirs) == 0 || dirs[0] != filepath.Join("glox", "lib") {
+		t.Errorf("Import path not relative to the configuration file: %v\n", dirs)
+	}
+}
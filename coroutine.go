@@ -0,0 +1,168 @@
+package main
+
+/*
+A coroutine runs a function a piece at a time: resume(co, value) runs it until it calls suspend(value) (at
+any depth of calls) or returns, and hands that value back. Like a generator's body, the function runs on its
+own goroutine with its own copy of the interpreter, and the two sides take turns over channels. Hosts drive
+the coroutines of a script with Resume() and Status(), e.g. a few steps of every entity's script per frame.
+*/
+
+// The states of a coroutine, as returned by Status() and coroutineStatus()
+const (
+	CoroutineSuspended = "suspended"
+	CoroutineRunning   = "running"
+	CoroutineDead      = "dead"
+)
+
+// LoxCoroutine is a function that can be suspended and resumed, created by coroutine(fn)
+type LoxCoroutine struct {
+	fn *LoxFunction
+	// resumes carries the value of each resume to the function, it is closed by Close()
+	resumes chan interface{}
+	// steps carries what the function suspended with or returned back to the resumer
+	steps  chan coroutineStep
+	status string
+}
+
+// coroutineStep is what a coroutine's function did when it stopped running: suspend, return or fail
+type coroutineStep struct {
+	val  interface{}
+	done bool
+	err  error
+}
+
+// newCoroutine prepares a coroutine running fn, which takes the value of the first resume if it has a parameter
+func newCoroutine(in *Interpreter, fn *LoxFunction) *LoxCoroutine {
+	coIn := *in
+	c := &LoxCoroutine{fn: fn, resumes: make(chan interface{}), steps: make(chan coroutineStep), status: CoroutineSuspended}
+	coIn.coroutine = c
+	coIn.generator = nil
+	// the body runs on its own goroutine, its calls are traced separately
+	coIn.frames = nil
+	go func() {
+		// wait for the first resume
+		first, ok := <-c.resumes
+		if !ok {
+			return
+		}
+		args := make([]interface{}, len(fn.params))
+		if len(args) > 0 {
+			args[0] = first
+		}
+		result := fn.call(&coIn, args)
+		step := coroutineStep{val: result, done: true}
+		if err, ok := result.(RuntimeError); ok {
+			step = coroutineStep{done: true, err: err}
+		}
+		select {
+		case c.steps <- step:
+		case <-c.resumes:
+			// closed while suspended, the function unwound and nobody waits for its result. Whatever it
+			// unwound through may have turned coroutineClosed into another error, so don't look at it.
+		}
+	}()
+	return c
+}
+
+// coroutineClosed unwinds the function of a coroutine that was closed while it was suspended
+type coroutineClosed struct {
+	RuntimeError
+}
+
+// Resume runs the coroutine until it suspends or returns. val is what the suspend() it is waiting in returns,
+// or the argument of its function the first time. The result is the value it suspended with or returned.
+// Resuming a coroutine that is dead or running (e.g. from inside itself) is an error.
+func (c *LoxCoroutine) Resume(val interface{}) (interface{}, error) {
+	switch c.status {
+	case CoroutineDead:
+		return nil, RuntimeError{msg: "Can't resume a dead coroutine."}
+	case CoroutineRunning:
+		return nil, RuntimeError{msg: "Can't resume a running coroutine."}
+	}
+	c.status = CoroutineRunning
+	c.resumes <- val
+	step := <-c.steps
+	c.status = CoroutineSuspended
+	if step.done {
+		c.status = CoroutineDead
+	}
+	return step.val, step.err
+}
+
+// Status returns whether the coroutine is suspended (new coroutines are), running or dead: it returned,
+// failed or was closed
+func (c *LoxCoroutine) Status() string {
+	return c.status
+}
+
+// Close kills a suspended coroutine so its goroutine can exit, hosts close the coroutines they stop
+// resuming. It does nothing to a coroutine that is already dead.
+func (c *LoxCoroutine) Close() {
+	if c.status == CoroutineSuspended {
+		c.status = CoroutineDead
+		close(c.resumes)
+	}
+}
+
+func (c *LoxCoroutine) String() string {
+	return "<coroutine " + c.fn.name.lexeme + ">"
+}
+
+// suspend(value) hands a value to the resume() running the current coroutine and waits to be resumed again,
+// it returns the value of that resume
+func nativeSuspend(in *Interpreter, args []interface{}) interface{} {
+	c := in.coroutine
+	if c == nil {
+		return RuntimeError{msg: "Can't suspend outside a coroutine."}
+	}
+	c.steps <- coroutineStep{val: args[0]}
+	val, ok := <-c.resumes
+	if !ok {
+		return coroutineClosed{RuntimeError{msg: "Coroutine was closed."}}
+	}
+	return val
+}
+
+// coroutine(fn) creates a suspended coroutine running a function of at most one parameter
+func nativeCoroutine(in *Interpreter, args []interface{}) interface{} {
+	fn, ok := args[0].(*LoxFunction)
+	if !ok || fn.generator || len(fn.params) > 1 {
+		return RuntimeError{msg: "coroutine() expects a function of at most one parameter."}
+	}
+	return newCoroutine(in, fn)
+}
+
+// resume(co, value) resumes a coroutine, see LoxCoroutine.Resume
+func nativeResume(in *Interpreter, args []interface{}) interface{} {
+	c, ok := args[0].(*LoxCoroutine)
+	if !ok {
+		return RuntimeError{msg: "resume() expects a coroutine."}
+	}
+	val, err := c.Resume(args[1])
+	if err != nil {
+		return err
+	}
+	return val
+}
+
+// close(co) ends a suspended coroutine that won't be resumed anymore, see LoxCoroutine.Close
+func nativeClose(in *Interpreter, args []interface{}) interface{} {
+	c, ok := args[0].(*LoxCoroutine)
+	if !ok {
+		return RuntimeError{msg: "close() expects a coroutine."}
+	}
+	if c.Status() == CoroutineRunning {
+		return RuntimeError{msg: "Can't close a running coroutine."}
+	}
+	c.Close()
+	return nil
+}
+
+// coroutineStatus(co) returns "suspended", "running" or "dead"
+func nativeCoroutineStatus(in *Interpreter, args []interface{}) interface{} {
+	c, ok := args[0].(*LoxCoroutine)
+	if !ok {
+		return RuntimeError{msg: "coroutineStatus() expects a coroutine."}
+	}
+	return c.Status()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestCoroutineHost checks that a host can drive a script's coroutine a step at a time and close it
+func TestCoroutineHost(t *testing.T) {
+	in, out := bufferedInterpreter()
+	in.Interpret(parse("fun walk(speed) { var x = 0; while (true) { x = x + speed; speed = suspend(x); } } var co = coroutine(walk);", in.reporter))
+	val, _ := in.globals.Get(Token{lexeme: "co"})
+	co, ok := val.(*LoxCoroutine)
+	if !ok {
+		t.Fatalf("coroutine() returned %v\n%v", val, out.String())
+	}
+	for i, want := range []int64{2, 5, 6} {
+		x, err := co.Resume([]int64{2, 3, 1}[i])
+		if err != nil || x != want || co.Status() != CoroutineSuspended {
+			t.Errorf("Resume %d returned %v, %v and left the coroutine %v\n", i, x, err, co.Status())
+		}
+	}
+	co.Close()
+	if _, err := co.Resume(int64(1)); err == nil || co.Status() != CoroutineDead {
+		t.Errorf("Closed coroutine could be resumed, status %v\n", co.Status())
+	}
+}
+
+// TestCoroutineCloseLeak checks that closing a coroutine suspended inside a variable's initializer lets
+// its goroutine exit
+func TestCoroutineCloseLeak(t *testing.T) {
+	in, out := bufferedInterpreter()
+	in.Interpret(parse("fun body() { var x = suspend(1); print x; } var co = coroutine(body);", in.reporter))
+	val, _ := in.globals.Get(Token{lexeme: "co"})
+	co, ok := val.(*LoxCoroutine)
+	if !ok {
+		t.Fatalf("coroutine() returned %v\n%v", val, out.String())
+	}
+	before := runtime.NumGoroutine()
+	co.Resume(nil)
+	co.Close()
+	for i := 0; i < 100 && runtime.NumGoroutine() >= before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n >= before {
+		t.Errorf("The closed coroutine's goroutine is still running, %d goroutines\n", n)
+	}
+}
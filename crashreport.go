@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+/*
+A crash report bundle is a directory holding everything needed to reproduce an internal error
+(a Go panic in the interpreter, not a Lox runtime error):
+
+	source.lox    the script that was running
+	tokens.txt    the token stream the lexer produced for it
+	ast.json      the statements the parser got through before failing (if it did)
+	stack.txt     the Go stack at the point of the panic
+	version.txt   the glox and Go versions, the platform and the panic value
+
+Nothing is sent anywhere, the bundle is only written to disk to be attached to an issue.
+*/
+
+// recoverCrash is deferred by runFile when crash reports are enabled. It turns a panic into a
+// crash report bundle for the script at 'path' and exits instead of printing a raw Go trace.
+func recoverCrash(dir, path, source string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	if p, ok := r.(goroutinePanic); ok {
+		r, stack = p.val, p.stack
+	}
+	bundle, err := writeCrashBundle(dir, path, source, r, stack)
+	fmt.Printf("Internal error: %v\n", r)
+	if err != nil {
+		fmt.Printf("Can't write crash report: %v\n", err)
+		os.Stdout.Write(stack)
+	} else {
+		fmt.Printf("A crash report was written to [%v], please attach it to an issue.\n", bundle)
+	}
+	os.Exit(70)
+}
+
+// writeCrashBundle writes a crash report bundle into a new directory below dir and returns its path
+func writeCrashBundle(dir, path, source string, panicVal interface{}, stack []byte) (string, error) {
+	bundle := filepath.Join(dir, "glox-crash-"+time.Now().Format("20060102-150405"))
+	for i := 2; ; i++ {
+		if _, err := os.Stat(bundle); os.IsNotExist(err) {
+			break
+		}
+		bundle = filepath.Join(dir, fmt.Sprintf("glox-crash-%v-%d", time.Now().Format("20060102-150405"), i))
+	}
+	if err := os.MkdirAll(bundle, 0755); err != nil {
+		return "", err
+	}
+	tokens, stmts, complete := partialParse(source)
+	var dump strings.Builder
+	for _, t := range tokens {
+		dump.WriteString(t.String())
+		dump.WriteByte('\n')
+	}
+	ast, err := json.MarshalIndent(map[string]interface{}{
+		"complete":   complete,
+		"statements": astJSON(reflect.ValueOf(stmts)),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	info := fmt.Sprintf("glox %v\n%v %v/%v\nscript: %v\npanic: %v\n",
+		Version, runtime.Version(), runtime.GOOS, runtime.GOARCH, path, panicVal)
+	files := map[string][]byte{
+		"source.lox":  []byte(source),
+		"tokens.txt":  []byte(dump.String()),
+		"ast.json":    ast,
+		"stack.txt":   stack,
+		"version.txt": []byte(info),
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(bundle, name), contents, 0644); err != nil {
+			return "", err
+		}
+	}
+	return bundle, nil
+}
+
+// partialParse lexes and parses source one declaration at a time, keeping whatever it got through
+// if the lexer or parser itself panics. complete reports whether the whole script was parsed.
+func partialParse(source string) (tokens []*Token, stmts []Stmt, complete bool) {
+	quiet := &ErrorReporter{out: ioutil.Discard}
+	defer func() {
+		if recover() != nil {
+			complete = false
+		}
+	}()
+	lexer := NewLexScanner(source)
+	lexer.reporter = quiet
+	tokens = lexer.ScanTokens()
+	p := Parser{inputTokens: tokens, reporter: quiet}
+	for !p.isAtEnd() {
+		stmts = append(stmts, p.declaration())
+	}
+	return tokens, stmts, true
+}
+
+// astJSON converts a syntax tree node (or a slice of them) into values encoding/json can write.
+// Nodes become objects holding their type name and fields, tokens are reduced to lexeme and line.
+func astJSON(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return astJSON(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(Token{}) {
+			return map[string]interface{}{"lexeme": v.FieldByName("lexeme").String(), "line": v.FieldByName("line").Int()}
+		}
+		node := map[string]interface{}{"node": v.Type().Name()}
+		for i := 0; i < v.NumField(); i++ {
+			node[v.Type().Field(i).Name] = astJSON(v.Field(i))
+		}
+		return node
+	case reflect.Slice:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = astJSON(v.Index(i))
+		}
+		return list
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int64:
+		return v.Int()
+	case reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	}
+	return nil
+}
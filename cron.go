@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five field cron expression: minute hour day-of-month month day-of-week.
+// Every field is a set of allowed values stored as a bitmask.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// restricted day fields are or'ed together like in classic cron: "0 0 1 * 1" runs on the 1st and on Mondays
+	domAny, dowAny bool
+}
+
+// cronFields are the names and ranges of the five fields of a cron expression
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron parses an expression like "*/5 * * * *". Fields may be '*', numbers, ranges ("1-5") and
+// lists of them ("1,15"), each optionally with a step ("*/15", "0-30/10"). Day of week 7 is Sunday like 0.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression needs %d fields, got %d", len(cronFields), len(fields))
+	}
+	masks := make([]uint64, len(fields))
+	for i, field := range fields {
+		mask, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v field '%v': %v", cronFields[i].name, field, err)
+		}
+		masks[i] = mask
+	}
+	// fold Sunday (7) into 0
+	if masks[4]&(1<<7) != 0 {
+		masks[4] = masks[4]&^(1<<7) | 1
+	}
+	return &CronSchedule{
+		minute: masks[0],
+		hour:   masks[1],
+		dom:    masks[2],
+		month:  masks[3],
+		dow:    masks[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if slash := strings.IndexByte(part, '/'); slash >= 0 {
+			n, err := strconv.Atoi(part[slash+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step '%v'", part[slash+1:])
+			}
+			rng, step = part[:slash], n
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("bad value '%v'", bounds[0])
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("bad value '%v'", bounds[1])
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 starting at 5
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("values must be between %d and %d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// maxCronSearch bounds the search for the next run, an expression like "0 0 31 2 *" never matches
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after 't' (at a whole minute) that matches the schedule, in t's timezone.
+// ok is false if nothing matches within the next five years.
+func (c *CronSchedule) Next(t time.Time) (time.Time, bool) {
+	limit := t.Add(maxCronSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
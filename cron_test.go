@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCronNext checks next run times for a few typical expressions
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, 2, 28, 23, 58, 30, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		expr, next string
+	}{
+		{"*/5 * * * *", "2024-02-29T00:00:00Z"},
+		{"30 9 * * 1-5", "2024-02-29T09:30:00Z"},
+		{"0 0 1 * *", "2024-03-01T00:00:00Z"},
+		{"0 12 * * 0", "2024-03-03T12:00:00Z"},
+		{"0 12 * * 7", "2024-03-03T12:00:00Z"},
+		{"0 0 13 * 5", "2024-03-01T00:00:00Z"},
+		{"0 0 29 2 *", "2024-02-29T00:00:00Z"},
+	}
+	for _, test := range tests {
+		sched, err := ParseCron(test.expr)
+		if err != nil {
+			t.Errorf("Can't parse %q: %v\n", test.expr, err)
+			continue
+		}
+		next, ok := sched.Next(from)
+		if !ok || next.Format(time.RFC3339) != test.next {
+			t.Errorf("Wrong next run for %q. Wanted: %v, Got: %v\n", test.expr, test.next, next.Format(time.RFC3339))
+		}
+	}
+	for _, bad := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("Invalid expression %q was accepted\n", bad)
+		}
+	}
+}
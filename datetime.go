@@ -0,0 +1,109 @@
+package main
+
+import (
+	"time"
+	// embed the timezone database so toZone() also works on machines without one (e.g. Windows)
+	_ "time/tzdata"
+)
+
+// LoxTime is a point in time in a particular timezone, created by the date/time natives.
+// Durations are plain numbers of milliseconds, the same unit sleep() uses.
+type LoxTime struct {
+	t time.Time
+}
+
+// String formats the time as ISO-8601
+func (lt LoxTime) String() string {
+	return lt.t.Format(time.RFC3339Nano)
+}
+
+// isoLayouts are the ISO-8601 forms parseTime() accepts, times without an offset are taken as UTC
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// now() returns the current time (virtual in tests) in UTC
+func nativeNow(in *Interpreter, args []interface{}) interface{} {
+	return LoxTime{in.clock.Now().UTC()}
+}
+
+// toZone(t, zone) returns the same instant in an IANA timezone such as "Europe/Berlin" (or "UTC", "Local")
+func nativeToZone(in *Interpreter, args []interface{}) interface{} {
+	t, ok := args[0].(LoxTime)
+	zone, zok := args[1].(string)
+	if !ok || !zok {
+		return RuntimeError{msg: "toZone() expects a time and a timezone name."}
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return RuntimeError{msg: "Unknown timezone '" + zone + "'."}
+	}
+	return LoxTime{t.t.In(loc)}
+}
+
+// parseTime(str) parses an ISO-8601 date or date and time
+func nativeParseTime(in *Interpreter, args []interface{}) interface{} {
+	str, ok := args[0].(string)
+	if !ok {
+		return RuntimeError{msg: "parseTime() expects a string."}
+	}
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return LoxTime{t}
+		}
+	}
+	return RuntimeError{msg: "Can't parse '" + str + "' as an ISO-8601 time."}
+}
+
+// formatTime(t) formats a time as ISO-8601, including its offset from UTC
+func nativeFormatTime(in *Interpreter, args []interface{}) interface{} {
+	t, ok := args[0].(LoxTime)
+	if !ok {
+		return RuntimeError{msg: "formatTime() expects a time."}
+	}
+	return t.String()
+}
+
+// addDuration(t, ms) moves a time by a (possibly negative) number of milliseconds
+func nativeAddDuration(in *Interpreter, args []interface{}) interface{} {
+	t, ok := args[0].(LoxTime)
+	ms, mok := toFloat(args[1])
+	if !ok || !mok {
+		return RuntimeError{msg: "addDuration() expects a time and a number of milliseconds."}
+	}
+	return LoxTime{t.t.Add(millis(ms))}
+}
+
+// timeDiff(a, b) returns the milliseconds from b to a, negative if a is earlier
+func nativeTimeDiff(in *Interpreter, args []interface{}) interface{} {
+	a, aok := args[0].(LoxTime)
+	b, bok := args[1].(LoxTime)
+	if !aok || !bok {
+		return RuntimeError{msg: "timeDiff() expects two times."}
+	}
+	return durationMillis(a.t.Sub(b.t))
+}
+
+// parseDuration(str) converts a duration like "1h30m" or "250ms" into milliseconds
+func nativeParseDuration(in *Interpreter, args []interface{}) interface{} {
+	str, ok := args[0].(string)
+	if !ok {
+		return RuntimeError{msg: "parseDuration() expects a string."}
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return RuntimeError{msg: "Can't parse '" + str + "' as a duration."}
+	}
+	return durationMillis(d)
+}
+
+// durationMillis converts a Duration into milliseconds, an integer unless there are fractions of a millisecond
+func durationMillis(d time.Duration) interface{} {
+	if d%time.Millisecond == 0 {
+		return int64(d / time.Millisecond)
+	}
+	return float64(d) / float64(time.Millisecond)
+}
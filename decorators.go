@@ -0,0 +1,62 @@
+package main
+
+import "fmt"
+
+// decorate applies the decorators of a function declaration to the function, the one closest to the
+// declaration first. The result of the outermost decorator is what the function's name is bound to.
+func (in *Interpreter) decorate(function *LoxFunction, decorators []Decorator) (interface{}, error) {
+	var val interface{} = function
+	for i := len(decorators) - 1; i >= 0; i-- {
+		d := decorators[i]
+		callee, err := in.evaluate(d.expr)
+		if err != nil {
+			return nil, err
+		}
+		decorator, ok := callee.(LoxCaller)
+		if !ok || decorator.arity() != 1 {
+			return nil, RuntimeError{tkn: d.at, msg: "A decorator must be a function of one argument."}
+		}
+		val = decorator.call(in, []interface{}{val})
+		if rerr, ok := val.(RuntimeError); ok {
+			if rerr.tkn.lexeme == "" {
+				rerr.tkn = d.at
+			}
+			return nil, rerr
+		}
+	}
+	return val, nil
+}
+
+// memoize(f) returns a function that calls f once for every distinct list of arguments and remembers
+// the result. Arguments that can't be set elements (sets, bytes and tuples) always call f.
+func nativeMemoize(in *Interpreter, args []interface{}) interface{} {
+	f, ok := args[0].(LoxCaller)
+	if !ok {
+		return RuntimeError{msg: "memoize() expects a function."}
+	}
+	cache := make(map[string]interface{})
+	return &NativeFunction{
+		name:  fmt.Sprintf("memoize(%v)", f),
+		nargs: f.arity(),
+		fn: func(in *Interpreter, args []interface{}) interface{} {
+			keys := make([]interface{}, len(args))
+			for i, arg := range args {
+				key, ok := setKey(arg)
+				if !ok {
+					return f.call(in, args)
+				}
+				keys[i] = key
+			}
+			// the keys are numbers, strings, booleans, nil, times and pointers, so %#v tells them apart
+			key := fmt.Sprintf("%#v", keys)
+			if res, ok := cache[key]; ok {
+				return res
+			}
+			res := f.call(in, args)
+			if _, failed := res.(RuntimeError); !failed {
+				cache[key] = res
+			}
+			return res
+		},
+	}
+}
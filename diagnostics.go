@@ -0,0 +1,309 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// diagnostic is an entry of the message catalog. text is the English message exactly as the lexer, parser,
+// interpreter or vet reports it, '%s' stands for the parts that vary (names, numbers, paths).
+// The catalog is looked up by that text, so code keeps reporting plain English messages.
+type diagnostic struct {
+	id   string
+	text string
+	// translations maps a language to the message in that language, with the same '%s' placeholders
+	translations map[string]string
+	pattern      *regexp.Regexp
+}
+
+// languages are the values of the -lang flag, English is the language the messages are written in
+var languages = []string{"en", "de", "es"}
+
+// catalog holds every diagnostic of the language itself: E0xx are syntax errors, E1xx runtime errors
+// and W0xx warnings of 'glox vet'. Errors raised by native functions aren't in the catalog.
+var catalog = []*diagnostic{
+	{id: "E001", text: "Unexpected character.",
+		translations: map[string]string{"de": "Unerwartetes Zeichen.", "es": "Carácter inesperado."}},
+	{id: "E002", text: "Unterminated string.",
+		translations: map[string]string{"de": "Nicht abgeschlossene Zeichenkette.", "es": "Cadena sin terminar."}},
+	{id: "E003", text: "Unterminated raw string.",
+		translations: map[string]string{"de": "Nicht abgeschlossene Rohzeichenkette.", "es": "Cadena literal sin terminar."}},
+	{id: "E004", text: "Integer literal out of range.",
+		translations: map[string]string{"de": "Ganzzahl-Literal außerhalb des Wertebereichs.", "es": "Literal entero fuera de rango."}},
+	{id: "E005", text: "Error reading floating point value.",
+		translations: map[string]string{"de": "Fehler beim Lesen der Gleitkommazahl.", "es": "Error al leer el número de coma flotante."}},
+	{id: "E006", text: "Numeric separator '_' must be between two digits.",
+		translations: map[string]string{"de": "Das Zifferntrennzeichen '_' muss zwischen zwei Ziffern stehen.", "es": "El separador numérico '_' debe estar entre dos dígitos."}},
+	{id: "E007", text: "Expect hexadecimal digits after '0x'.",
+		translations: map[string]string{"de": "Hexadezimalziffern nach '0x' erwartet.", "es": "Se esperan dígitos hexadecimales después de '0x'."}},
+	{id: "E010", text: "Expected expression.",
+		translations: map[string]string{"de": "Ausdruck erwartet.", "es": "Se esperaba una expresión."}},
+	{id: "E011", text: "Expect ')' after expression",
+		translations: map[string]string{"de": "')' nach Ausdruck erwartet", "es": "Se esperaba ')' después de la expresión"}},
+	{id: "E012", text: "Expect ';' after value",
+		translations: map[string]string{"de": "';' nach Wert erwartet", "es": "Se esperaba ';' después del valor"}},
+	{id: "E013", text: "Expect '}' after block",
+		translations: map[string]string{"de": "'}' nach Block erwartet", "es": "Se esperaba '}' después del bloque"}},
+	{id: "E014", text: "Invalid assignment target",
+		translations: map[string]string{"de": "Ungültiges Zuweisungsziel", "es": "Destino de asignación no válido"}},
+	{id: "E015", text: "Expect variable name.",
+		translations: map[string]string{"de": "Variablenname erwartet.", "es": "Se esperaba el nombre de la variable."}},
+	{id: "E016", text: "Expect semicolon after variable declaration.",
+		translations: map[string]string{"de": "Semikolon nach Variablendeklaration erwartet.", "es": "Se esperaba punto y coma después de la declaración de variable."}},
+	{id: "E017", text: "Expect '=' after constant name, constants must be initialized.",
+		translations: map[string]string{"de": "'=' nach Konstantenname erwartet, Konstanten müssen initialisiert werden.", "es": "Se esperaba '=' después del nombre de la constante, las constantes deben inicializarse."}},
+	{id: "E018", text: "Expect %s name.",
+		translations: map[string]string{"de": "Name (%s) erwartet.", "es": "Se esperaba el nombre (%s)."}},
+	{id: "E019", text: "Expect '(' after %s name.",
+		translations: map[string]string{"de": "'(' nach Name (%s) erwartet.", "es": "Se esperaba '(' después del nombre (%s)."}},
+	{id: "E020", text: "Expect parameter name.",
+		translations: map[string]string{"de": "Parametername erwartet.", "es": "Se esperaba el nombre del parámetro."}},
+	{id: "E021", text: "Expect ')' after parameter list.",
+		translations: map[string]string{"de": "')' nach Parameterliste erwartet.", "es": "Se esperaba ')' después de la lista de parámetros."}},
+	{id: "E022", text: "Expect '{' before %s body.",
+		translations: map[string]string{"de": "'{' vor Rumpf (%s) erwartet.", "es": "Se esperaba '{' antes del cuerpo (%s)."}},
+	{id: "E023", text: "Can't have more than 255 parameters.",
+		translations: map[string]string{"de": "Mehr als 255 Parameter sind nicht erlaubt.", "es": "No se pueden tener más de 255 parámetros."}},
+	{id: "E024", text: "Can't have more than 255 arguments.",
+		translations: map[string]string{"de": "Mehr als 255 Argumente sind nicht erlaubt.", "es": "No se pueden tener más de 255 argumentos."}},
+	{id: "E025", text: "Expect ')' after function call arguments.",
+		translations: map[string]string{"de": "')' nach den Argumenten des Aufrufs erwartet.", "es": "Se esperaba ')' después de los argumentos de la llamada."}},
+	{id: "E026", text: "Expect '(' after 'if'",
+		translations: map[string]string{"de": "'(' nach 'if' erwartet", "es": "Se esperaba '(' después de 'if'"}},
+	{id: "E027", text: "Expect ')' after if condition",
+		translations: map[string]string{"de": "')' nach if-Bedingung erwartet", "es": "Se esperaba ')' después de la condición del if"}},
+	{id: "E028", text: "Expect '(' after 'while'.",
+		translations: map[string]string{"de": "'(' nach 'while' erwartet.", "es": "Se esperaba '(' después de 'while'."}},
+	{id: "E029", text: "Expect ')' after while loop condition.",
+		translations: map[string]string{"de": "')' nach while-Bedingung erwartet.", "es": "Se esperaba ')' después de la condición del while."}},
+	{id: "E030", text: "Expect '(' after 'for'.",
+		translations: map[string]string{"de": "'(' nach 'for' erwartet.", "es": "Se esperaba '(' después de 'for'."}},
+	{id: "E031", text: "Expect ';' after loop condition.",
+		translations: map[string]string{"de": "';' nach Schleifenbedingung erwartet.", "es": "Se esperaba ';' después de la condición del bucle."}},
+	{id: "E032", text: "Expect ')' after for clauses.",
+		translations: map[string]string{"de": "')' nach for-Klauseln erwartet.", "es": "Se esperaba ')' después de las cláusulas del for."}},
+	{id: "E033", text: "Expect loop variable name.",
+		translations: map[string]string{"de": "Name der Schleifenvariable erwartet.", "es": "Se esperaba el nombre de la variable del bucle."}},
+	{id: "E034", text: "Expect ')' after for-in collection.",
+		translations: map[string]string{"de": "')' nach der Sammlung der for-in-Schleife erwartet.", "es": "Se esperaba ')' después de la colección del for-in."}},
+	{id: "E035", text: "Expect ';' after 'return'",
+		translations: map[string]string{"de": "';' nach 'return' erwartet", "es": "Se esperaba ';' después de 'return'"}},
+	{id: "E036", text: "Expect ';' after yield value.",
+		translations: map[string]string{"de": "';' nach yield-Wert erwartet.", "es": "Se esperaba ';' después del valor de yield."}},
+	{id: "E037", text: "Expect module path after 'import'.",
+		translations: map[string]string{"de": "Modulpfad nach 'import' erwartet.", "es": "Se esperaba la ruta del módulo después de 'import'."}},
+	{id: "E038", text: "Expect ';' after import.",
+		translations: map[string]string{"de": "';' nach import erwartet.", "es": "Se esperaba ';' después de import."}},
+	{id: "E039", text: "Expect variable name after ','.",
+		translations: map[string]string{"de": "Variablenname nach ',' erwartet.", "es": "Se esperaba el nombre de la variable después de ','."}},
+	{id: "E040", text: "Expect '=' after the names to destructure into.",
+		translations: map[string]string{"de": "'=' nach den Namen der Zerlegung erwartet.", "es": "Se esperaba '=' después de los nombres de la desestructuración."}},
+	{id: "E041", text: "Expect semicolon after destructuring.",
+		translations: map[string]string{"de": "Semikolon nach Zerlegung erwartet.", "es": "Se esperaba punto y coma después de la desestructuración."}},
+	{id: "E042", text: "Expect type name after 'is'.",
+		translations: map[string]string{"de": "Typname nach 'is' erwartet.", "es": "Se esperaba el nombre de un tipo después de 'is'."}},
+	{id: "E043", text: "Expect ']' after index.",
+		translations: map[string]string{"de": "']' nach Index erwartet.", "es": "Se esperaba ']' después del índice."}},
+	{id: "E044", text: "Expect '}' after set elements.",
+		translations: map[string]string{"de": "'}' nach den Elementen der Menge erwartet.", "es": "Se esperaba '}' después de los elementos del conjunto."}},
+	{id: "E045", text: "Expect function declaration after decorator.",
+		translations: map[string]string{"de": "Funktionsdeklaration nach Dekorator erwartet.", "es": "Se esperaba una declaración de función después del decorador."}},
+	{id: "E046", text: "Can't read local variable in its own initializer.",
+		translations: map[string]string{"de": "Lokale Variable kann nicht in ihrem eigenen Initialisierer gelesen werden.", "es": "No se puede leer una variable local en su propio inicializador."}},
+	{id: "E047", text: "Already a variable named '%s' in this scope (declared on line %s).",
+		translations: map[string]string{"de": "Es gibt in diesem Gültigkeitsbereich bereits eine Variable namens '%s' (deklariert in Zeile %s).", "es": "Ya existe una variable llamada '%s' en este ámbito (declarada en la línea %s)."}},
+	{id: "E048", text: "Can't return from top-level code.",
+		translations: map[string]string{"de": "Rückkehr aus Code auf oberster Ebene nicht möglich.", "es": "No se puede retornar desde código de nivel superior."}},
+	{id: "E049", text: "Expect type name after ':'.",
+		translations: map[string]string{"de": "Typname nach ':' erwartet.", "es": "Se esperaba un nombre de tipo después de ':'."}},
+	{id: "E050", text: "Type mismatch: expected %s but got %s.",
+		translations: map[string]string{"de": "Typfehler: %s erwartet, aber %s erhalten.", "es": "Tipos incompatibles: se esperaba %s pero se obtuvo %s."}},
+	{id: "E051", text: "Unknown type '%s' in annotation.",
+		translations: map[string]string{"de": "Unbekannter Typ '%s' in Annotation.", "es": "Tipo desconocido '%s' en la anotación."}},
+	{id: "E052", text: "Assignment to undeclared variable '%s'.",
+		translations: map[string]string{"de": "Zuweisung an nicht deklarierte Variable '%s'.", "es": "Asignación a la variable no declarada '%s'."}},
+	{id: "E053", text: "'%s' is called with the wrong number of arguments: %s given, %s expected.",
+		translations: map[string]string{"de": "'%s' wird mit der falschen Anzahl von Argumenten aufgerufen: %s übergeben, %s erwartet.", "es": "'%s' se llama con un número incorrecto de argumentos: %s dados, %s esperados."}},
+	{id: "E100", text: "Undefined variable %s.",
+		translations: map[string]string{"de": "Undefinierte Variable %s.", "es": "Variable no definida %s."}},
+	{id: "E101", text: "Can't assign to constant '%s' (declared on line %s).",
+		translations: map[string]string{"de": "Zuweisung an Konstante '%s' (deklariert in Zeile %s) nicht möglich.", "es": "No se puede asignar a la constante '%s' (declarada en la línea %s)."}},
+	{id: "E102", text: "Can only call functions and classes.",
+		translations: map[string]string{"de": "Nur Funktionen und Klassen können aufgerufen werden.", "es": "Solo se pueden llamar funciones y clases."}},
+	{id: "E103", text: "Expected %s arguments but got %s.",
+		translations: map[string]string{"de": "%s Argumente erwartet, aber %s erhalten.", "es": "Se esperaban %s argumentos pero se recibieron %s."}},
+	{id: "E104", text: "operand must be a number",
+		translations: map[string]string{"de": "Operand muss eine Zahl sein", "es": "el operando debe ser un número"}},
+	{id: "E105", text: "both operands must be numbers",
+		translations: map[string]string{"de": "Beide Operanden müssen Zahlen sein", "es": "ambos operandos deben ser números"}},
+	{id: "E106", text: "Addition operands must be two numbers or include a string",
+		translations: map[string]string{"de": "Die Operanden der Addition müssen zwei Zahlen sein oder eine Zeichenkette enthalten", "es": "Los operandos de la suma deben ser dos números o incluir una cadena"}},
+	{id: "E107", text: "Integer division by zero.",
+		translations: map[string]string{"de": "Ganzzahlige Division durch null.", "es": "División entera por cero."}},
+	{id: "E108", text: "Integer overflow in %s.",
+		translations: map[string]string{"de": "Ganzzahlüberlauf in %s.", "es": "Desbordamiento de entero en %s."}},
+	{id: "E109", text: "Step limit exceeded.",
+		translations: map[string]string{"de": "Schrittgrenze überschritten.", "es": "Límite de pasos superado."}},
+	{id: "E110", text: "Can only iterate over strings, sets and generators.",
+		translations: map[string]string{"de": "Nur über Zeichenketten, Mengen und Generatoren kann iteriert werden.", "es": "Solo se puede iterar sobre cadenas, conjuntos y generadores."}},
+	{id: "E111", text: "Can't yield outside a generator.",
+		translations: map[string]string{"de": "'yield' ist außerhalb eines Generators nicht möglich.", "es": "No se puede usar yield fuera de un generador."}},
+	{id: "E112", text: "Expected %s values to destructure, got %s.",
+		translations: map[string]string{"de": "%s Werte zum Zerlegen erwartet, %s erhalten.", "es": "Se esperaban %s valores para desestructurar, se recibieron %s."}},
+	{id: "E113", text: "Can only index strings, bytes and tuples.",
+		translations: map[string]string{"de": "Nur Zeichenketten, Bytes und Tupel können indiziert werden.", "es": "Solo se pueden indexar cadenas, bytes y tuplas."}},
+	{id: "E114", text: "Indices must be integers.",
+		translations: map[string]string{"de": "Indizes müssen Ganzzahlen sein.", "es": "Los índices deben ser enteros."}},
+	{id: "E115", text: "Index out of range.",
+		translations: map[string]string{"de": "Index außerhalb des Bereichs.", "es": "Índice fuera de rango."}},
+	{id: "E116", text: "Unknown type '%s'.",
+		translations: map[string]string{"de": "Unbekannter Typ '%s'.", "es": "Tipo desconocido '%s'."}},
+	{id: "E117", text: "Right operand of 'in' must be a set.",
+		translations: map[string]string{"de": "Der rechte Operand von 'in' muss eine Menge sein.", "es": "El operando derecho de 'in' debe ser un conjunto."}},
+	{id: "E118", text: "Sets, bytes and tuples can't be set elements.",
+		translations: map[string]string{"de": "Mengen, Bytes und Tupel können keine Elemente einer Menge sein.", "es": "Los conjuntos, los bytes y las tuplas no pueden ser elementos de un conjunto."}},
+	{id: "E119", text: "Can't %s() a frozen value.",
+		translations: map[string]string{"de": "%s() ist auf einem eingefrorenen Wert nicht möglich.", "es": "No se puede aplicar %s() a un valor congelado."}},
+	{id: "E120", text: "Can't find module %s.",
+		translations: map[string]string{"de": "Modul %s nicht gefunden.", "es": "No se encuentra el módulo %s."}},
+	{id: "E121", text: "Circular import: %s.",
+		translations: map[string]string{"de": "Zirkulärer Import: %s.", "es": "Importación circular: %s."}},
+	{id: "E122", text: "Can't read module %s.",
+		translations: map[string]string{"de": "Modul %s kann nicht gelesen werden.", "es": "No se puede leer el módulo %s."}},
+	{id: "E123", text: "Module %s has syntax errors.",
+		translations: map[string]string{"de": "Modul %s enthält Syntaxfehler.", "es": "El módulo %s tiene errores de sintaxis."}},
+	{id: "E124", text: "A decorator must be a function of one argument.",
+		translations: map[string]string{"de": "Ein Dekorator muss eine Funktion mit einem Argument sein.", "es": "Un decorador debe ser una función de un argumento."}},
+	{id: "E125", text: "Variable '%s' is read before it is initialized.",
+		translations: map[string]string{"de": "Variable '%s' wird gelesen, bevor sie initialisiert ist.", "es": "La variable '%s' se lee antes de inicializarse."}},
+	{id: "E126", text: "Length %s is over the limit of %s bytes.",
+		translations: map[string]string{"de": "Länge %s überschreitet die Grenze von %s Bytes.", "es": "La longitud %s supera el límite de %s bytes."}},
+	{id: "W001", text: "Exact comparison of computed numbers; consider approxEqual(a, b, eps).",
+		translations: map[string]string{"de": "Exakter Vergleich berechneter Zahlen; erwäge approxEqual(a, b, eps).", "es": "Comparación exacta de números calculados; considera approxEqual(a, b, eps)."}},
+	{id: "W002", text: "Function '%s' is never used.",
+		translations: map[string]string{"de": "Funktion '%s' wird nie verwendet.", "es": "La función '%s' nunca se usa."}},
+	{id: "W003", text: "Global variable '%s' is never read.",
+		translations: map[string]string{"de": "Globale Variable '%s' wird nie gelesen.", "es": "La variable global '%s' nunca se lee."}},
+	{id: "W004", text: "Assignment used as a condition; did you mean '=='?",
+		translations: map[string]string{"de": "Zuweisung als Bedingung verwendet; war '==' gemeint?", "es": "Asignación usada como condición; ¿quisiste decir '=='?"}},
+	{id: "W005", text: "Local variable '%s' is never read.",
+		translations: map[string]string{"de": "Lokale Variable '%s' wird nie gelesen.", "es": "La variable local '%s' nunca se lee."}},
+	{id: "W006", text: "Parameter '%s' is never read.",
+		translations: map[string]string{"de": "Parameter '%s' wird nie gelesen.", "es": "El parámetro '%s' nunca se lee."}},
+	{id: "W007", text: "Declaration of '%s' shadows the one on line %s.",
+		translations: map[string]string{"de": "Die Deklaration von '%s' verdeckt die in Zeile %s.", "es": "La declaración de '%s' oculta la de la línea %s."}},
+	{id: "W008", text: "Call of '%s' with the wrong number of arguments: %s given, %s expected.",
+		translations: map[string]string{"de": "Aufruf von '%s' mit der falschen Anzahl von Argumenten: %s übergeben, %s erwartet.", "es": "Llamada a '%s' con un número incorrecto de argumentos: %s dados, %s esperados."}},
+}
+
+func init() {
+	for _, d := range catalog {
+		d.pattern = regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(d.text), "%s", "(.*)", -1) + "$")
+	}
+}
+
+// ParseLanguage checks the value of the -lang flag
+func ParseLanguage(name string) (string, error) {
+	for _, lang := range languages {
+		if lang == name {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown language %q, expected one of %v", name, strings.Join(languages, ", "))
+}
+
+// lookupDiagnostic finds the catalog entry of an English message, and the values of its placeholders.
+// Entries without placeholders are tried first, 'Expect %s name.' would match 'Expect parameter name.' too.
+func lookupDiagnostic(msg string) (*diagnostic, []interface{}) {
+	for _, d := range catalog {
+		if d.text == msg {
+			return d, nil
+		}
+	}
+	// several patterns can match, 'Expect %s name.' matches "Expect '(' after function name." too.
+	// The one with the most fixed text is the most specific.
+	var best *diagnostic
+	var args []interface{}
+	for _, d := range catalog {
+		m := d.pattern.FindStringSubmatch(msg)
+		if m == nil || (best != nil && len(d.text) <= len(best.text)) {
+			continue
+		}
+		best, args = d, make([]interface{}, len(m)-1)
+		for i, arg := range m[1:] {
+			args[i] = arg
+		}
+	}
+	return best, args
+}
+
+// diagnosticByID returns the catalog entry with the given id, or nil
+func diagnosticByID(id string) *diagnostic {
+	for _, d := range catalog {
+		if d.id == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// localize returns the catalog id of msg and msg in the given language. Messages that aren't in the
+// catalog (or have no translation) are returned unchanged, id is "" if msg isn't in the catalog.
+func localize(msg, lang string) (id, text string) {
+	d, args := lookupDiagnostic(msg)
+	if d == nil {
+		return "", msg
+	}
+	translation, ok := d.translations[lang]
+	if !ok {
+		return d.id, msg
+	}
+	return d.id, fmt.Sprintf(translation, args...)
+}
+
+// explanations are the pages printed by 'glox explain', one markdown file per diagnostic id with an
+// explanation, an erroneous example and its fixed version. They are embedded so explain works offline.
+//
+//go:embed docs/explain/*.md
+var explanations embed.FS
+
+// explanation returns the documentation page of a diagnostic
+func explanation(id string) (string, error) {
+	page, err := explanations.ReadFile("docs/explain/" + id + ".md")
+	return string(page), err
+}
+
+// runExplain implements the 'explain' subcommand, it prints the documentation page of a diagnostic id.
+// Without an id it lists the whole catalog.
+func runExplain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		ids := make([]string, len(catalog))
+		for i, d := range catalog {
+			ids[i] = d.id + "  " + d.text
+		}
+		sort.Strings(ids)
+		fmt.Println(strings.Join(ids, "\n"))
+		return
+	}
+	d := diagnosticByID(strings.ToUpper(flags.Arg(0)))
+	if d == nil {
+		fmt.Printf("Unknown diagnostic %v, run 'glox explain' for the list.\n", flags.Arg(0))
+		os.Exit(64)
+	}
+	page, err := explanation(d.id)
+	if err != nil {
+		fmt.Printf("%v: %v\n", d.id, d.text)
+		return
+	}
+	fmt.Print(page)
+	if translation, ok := d.translations[reporter.lang]; ok {
+		fmt.Printf("\n(%v: %v)\n", reporter.lang, translation)
+	}
+}
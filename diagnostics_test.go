@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestCatalogTranslations checks that every diagnostic is translated into every language with the same placeholders
+func TestCatalogTranslations(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, d := range catalog {
+		if seen[d.id] {
+			t.Errorf("Duplicate diagnostic id %v\n", d.id)
+		}
+		seen[d.id] = true
+		for _, lang := range languages[1:] {
+			translation, ok := d.translations[lang]
+			if !ok {
+				t.Errorf("%v has no %v translation\n", d.id, lang)
+			} else if strings.Count(translation, "%s") != strings.Count(d.text, "%s") {
+				t.Errorf("%v: %v translation has different placeholders: %q\n", d.id, lang, translation)
+			}
+		}
+	}
+}
+
+// TestLocalize checks that exact messages win over placeholder patterns and that placeholders are carried over
+func TestLocalize(t *testing.T) {
+	if id, _ := localize("Expect parameter name.", "de"); id != "E020" {
+		t.Errorf("Wrong id for an exact message: %v\n", id)
+	}
+	if id, msg := localize("Undefined variable x.", "de"); id != "E100" || msg != "Undefinierte Variable x." {
+		t.Errorf("Wrong localization: %v %v\n", id, msg)
+	}
+	if id, msg := localize("toBytes() expects a string.", "de"); id != "" || msg != "toBytes() expects a string." {
+		t.Errorf("Message outside the catalog changed: %v %v\n", id, msg)
+	}
+}
+
+// TestUncataloguedWarning checks that a warning outside the catalog is shown without an id and stays a warning
+func TestUncataloguedWarning(t *testing.T) {
+	w := Warning{tkn: Token{lexeme: "x", line: 3}, msg: "Something odd about x."}
+	if got := w.String(); got != "[line 3] Warning at 'x': Something odd about x." {
+		t.Errorf("Wrong format: %v\n", got)
+	}
+	if sev := (Severities{}).of(""); sev != SeverityWarning {
+		t.Errorf("Warnings without an id have severity %v\n", sev)
+	}
+}
+
+// explainExamplesSkipped are diagnostics whose examples can't run on their own, they need other files or are abbreviated
+var explainExamplesSkipped = map[string]bool{"E023": true, "E024": true, "E120": true, "E121": true, "E122": true, "E123": true}
+
+// TestExplanationExamples checks that every diagnostic has a page, that its erroneous example reports
+// the diagnostic and that the fixed version doesn't
+func TestExplanationExamples(t *testing.T) {
+	for _, d := range catalog {
+		page, err := explanation(d.id)
+		if err != nil {
+			t.Errorf("%v has no explanation: %v\n", d.id, err)
+			continue
+		}
+		blocks := strings.Split(page, "```lox\n")
+		if len(blocks) != 3 {
+			t.Errorf("%v: explanation needs an erroneous and a fixed example\n", d.id)
+			continue
+		}
+		if explainExamplesSkipped[d.id] {
+			continue
+		}
+		bad := strings.SplitN(blocks[1], "```", 2)[0]
+		fixed := strings.SplitN(blocks[2], "```", 2)[0]
+		if out := runExample(d.id, bad); !strings.Contains(out, "["+d.id+"]") {
+			t.Errorf("%v: erroneous example doesn't report it, output:\n%v", d.id, out)
+		}
+		if out := runExample(d.id, fixed); strings.Contains(out, "["+d.id+"]") {
+			t.Errorf("%v: fixed example still reports it, output:\n%v", d.id, out)
+		}
+	}
+}
+
+// strictDiagnostics are only reported with -strict, their examples are checked and run in strict mode
+var strictDiagnostics = map[string]bool{"E052": true, "E053": true, "E125": true}
+
+// runExample parses an example and, depending on the kind of diagnostic, runs or lints it
+func runExample(id, script string) string {
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out}
+	stmts := parse(script, r)
+	if !r.hadError && resolve(stmts, make(map[Expr]int), r) {
+		checkTypes(stmts, r)
+		if strictDiagnostics[id] {
+			checkStrict(stmts, NewInterpreter().globals, r)
+		}
+	}
+	switch {
+	case r.hadError || strings.HasPrefix(id, "E0"):
+	case strings.HasPrefix(id, "W"):
+		for _, w := range lintAll(stmts) {
+			fmt.Fprintln(&out, w)
+		}
+	default:
+		in := NewTestInterpreter()
+		in.out = &out
+		in.reporter = r
+		in.maxSteps = 1000
+		in.strict = strictDiagnostics[id]
+		in.Interpret(stmts)
+	}
+	return out.String()
+}
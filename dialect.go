@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect selects the few places where Lox implementations behave differently, so scripts and test
+// suites written for jlox (the book's Java interpreter) or clox (its C virtual machine) run unmodified
+type Dialect int
+
+const (
+	// GloxDialect is glox's own behaviour
+	GloxDialect Dialect = iota
+	// JloxDialect follows jlox: every number is a double, '+' doesn't mix strings and numbers,
+	// numbers print with all their digits and runtime errors are worded (and laid out) like jlox's
+	JloxDialect
+	// CloxDialect is like JloxDialect but prints numbers with C's %g and ends runtime errors with "in script"
+	CloxDialect
+)
+
+// dialectNames maps the values of the -dialect flag to dialects
+var dialectNames = map[string]Dialect{"glox": GloxDialect, "jlox": JloxDialect, "clox": CloxDialect}
+
+// ParseDialect returns the dialect with the given name
+func ParseDialect(name string) (Dialect, error) {
+	if d, ok := dialectNames[name]; ok {
+		return d, nil
+	}
+	return GloxDialect, fmt.Errorf("unknown dialect %q, expected glox, jlox or clox", name)
+}
+
+// bookMessages are the book's wording of glox's runtime error messages
+var bookMessages = map[string]string{
+	"operand must be a number":                                  "Operand must be a number.",
+	"both operands must be numbers":                             "Operands must be numbers.",
+	"Addition operands must be two numbers or include a string": "Operands must be two numbers or two strings.",
+}
+
+// message returns a runtime error message in the dialect's wording
+func (d Dialect) message(msg string) string {
+	if d == GloxDialect {
+		return msg
+	}
+	if book, ok := bookMessages[msg]; ok {
+		return book
+	}
+	return msg
+}
+
+// formatFloat prints a double the way the dialect's print statement does
+func (d Dialect) formatFloat(num float64) string {
+	switch d {
+	case JloxDialect:
+		return strings.TrimSuffix(strconv.FormatFloat(num, 'f', -1, 64), ".0")
+	case CloxDialect:
+		// C's %g has a precision of 6 significant digits, Go's prints as many as needed
+		return fmt.Sprintf("%.6g", num)
+	}
+	str := fmt.Sprintf("%.1f", num)
+	// strip decimal from int floats
+	return strings.TrimSuffix(str, ".0")
+}
+
+// formatRuntimeError lays out a runtime error report: the message, the line, whatever follows the first
+// line of the message and the calls the error happened in (jlox doesn't list them)
+func (d Dialect) formatRuntimeError(msg string, line int, details string, trace []callFrame) string {
+	switch d {
+	case JloxDialect:
+		return fmt.Sprintf("%s\n[line %d]%s\n", msg, line, details)
+	case CloxDialect:
+		// clox lists every function with the line it is at, the script last
+		var b strings.Builder
+		b.WriteString(msg + "\n")
+		for i := len(trace) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "[line %d] in %s()\n", line, trace[i].name)
+			line = trace[i].line
+		}
+		fmt.Fprintf(&b, "[line %d] in script%s\n", line, details)
+		return b.String()
+	}
+	return fmt.Sprintf("%s [line %d]%s%s\n", msg, line, details, formatTrace(trace))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// diffLines returns a line-based diff between two texts in the style of a unified diff body:
+// unchanged lines start with ' ', removed lines with '-' and added lines with '+'.
+// It is a plain longest-common-subsequence diff, fine for test output sized inputs.
+func diffLines(a, b string) []string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	out := make([]string, 0)
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			out = append(out, " "+x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "-"+x[i])
+			i++
+		default:
+			out = append(out, "+"+y[j])
+			j++
+		}
+	}
+	return out
+}
+
+// diffContext is the number of unchanged lines a unified diff shows around its changes
+const diffContext = 3
+
+// unifiedDiff returns the changes from before to after as a unified diff of the file at path, the changes
+// are grouped into hunks with diffContext lines of context. Equal texts have no diff.
+func unifiedDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+	lines := diffLines(before, after)
+	if lines[len(lines)-1] == " " {
+		// both texts end with a newline, there is no line after it
+		lines = lines[:len(lines)-1]
+	}
+	// oldAt and newAt hold the number each line has in the old and the new text (the next one for lines
+	// that aren't in it)
+	oldAt, newAt := make([]int, len(lines)), make([]int, len(lines))
+	oldLine, newLine := 1, 1
+	for i, line := range lines {
+		oldAt[i], newAt[i] = oldLine, newLine
+		if line[0] != '+' {
+			oldLine++
+		}
+		if line[0] != '-' {
+			newLine++
+		}
+	}
+	var b strings.Builder
+	b.WriteString("--- " + path + "\n+++ " + path + "\n")
+	for i := 0; i < len(lines); {
+		if lines[i][0] == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		// changes separated by less than twice the context share a hunk
+		last := i
+		for j := i; j < len(lines) && j-last <= 2*diffContext; j++ {
+			if lines[j][0] != ' ' {
+				last = j
+			}
+		}
+		end := last + diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[start:end] {
+			if line[0] != '+' {
+				oldCount++
+			}
+			if line[0] != '-' {
+				newCount++
+			}
+		}
+		// an empty range starts at the line before it
+		oldStart, newStart := oldAt[start], newAt[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		b.WriteString("@@ -" + strconv.Itoa(oldStart) + "," + strconv.Itoa(oldCount) +
+			" +" + strconv.Itoa(newStart) + "," + strconv.Itoa(newCount) + " @@\n")
+		for _, line := range lines[start:end] {
+			b.WriteString(line + "\n")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// diffValues describes how actual differs from expected, one line per difference in the same style
+// as diffLines: line-based for two strings, element-based for two sets and index-based for two tuples.
+// Values are shown the way inspect() shows them, so 1 and "1" don't look alike. Equal values have no diff.
+func diffValues(in *Interpreter, expected, actual interface{}) []string {
+	if in.isEqual(expected, actual) {
+		return nil
+	}
+	show := func(v interface{}) string {
+		return inspectValue(v, 1, make(map[interface{}]bool))
+	}
+	switch exp := expected.(type) {
+	case string:
+		if act, ok := actual.(string); ok {
+			return diffLines(exp, act)
+		}
+	case *LoxSet:
+		if act, ok := actual.(*LoxSet); ok {
+			out := make([]string, 0)
+			for _, v := range exp.elems {
+				if key, _ := setKey(v); !act.has(key) {
+					out = append(out, "-"+show(v))
+				}
+			}
+			for _, v := range act.elems {
+				if key, _ := setKey(v); !exp.has(key) {
+					out = append(out, "+"+show(v))
+				}
+			}
+			return out
+		}
+	case Tuple:
+		if act, ok := actual.(Tuple); ok {
+			out := make([]string, 0)
+			for i := 0; i < len(exp) || i < len(act); i++ {
+				prefix := "[" + strconv.Itoa(i) + "] "
+				switch {
+				case i >= len(act):
+					out = append(out, "-"+prefix+show(exp[i]))
+				case i >= len(exp):
+					out = append(out, "+"+prefix+show(act[i]))
+				case in.isEqual(exp[i], act[i]):
+					out = append(out, " "+prefix+show(exp[i]))
+				default:
+					out = append(out, "-"+prefix+show(exp[i]), "+"+prefix+show(act[i]))
+				}
+			}
+			return out
+		}
+	}
+	return []string{"-" + show(expected), "+" + show(actual)}
+}
+
+// diff(expected, actual) returns the differences between two values as lines of '-' (expected)
+// and '+' (actual), or an empty string if they're equal
+func nativeDiff(in *Interpreter, args []interface{}) interface{} {
+	return strings.Join(diffValues(in, args[0], args[1]), "\n")
+}
+
+// assertEqual(expected, actual) raises a runtime error showing the diff if the values aren't equal
+func nativeAssertEqual(in *Interpreter, args []interface{}) interface{} {
+	lines := diffValues(in, args[0], args[1])
+	if lines == nil {
+		return nil
+	}
+	return RuntimeError{msg: "assertEqual failed, -expected +actual:\n" + indent(strings.Join(lines, "\n"), "    ")}
+}
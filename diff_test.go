@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiffLines checks that removals come before additions and unchanged lines are kept
+func TestDiffLines(t *testing.T) {
+	expected := []string{" a", " b", "-c", "+d", " e"}
+	got := diffLines("a\nb\nc\ne", "a\nb\nd\ne")
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Wrong diff.\nWanted: %q\nGot: %q\n", expected, got)
+	}
+}
+
+// TestUnifiedDiff checks the hunk headers, that close changes share a hunk and that distant ones don't
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := "--- f.lox\n+++ f.lox\n" +
+		"@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n" +
+		"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n"
+	if got := unifiedDiff("f.lox", before, after); got != want {
+		t.Errorf("Wrong diff.\nWanted:\n%v\nGot:\n%v\n", want, got)
+	}
+	want = "--- f.lox\n+++ f.lox\n@@ -0,0 +1,1 @@\n+x\n"
+	if got := unifiedDiff("f.lox", "", "x\n"); got != want {
+		t.Errorf("Wrong diff of a new file.\nWanted:\n%v\nGot:\n%v\n", want, got)
+	}
+}
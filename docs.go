@@ -0,0 +1,32 @@
+package main
+
+// docString returns the docstring of a function body: its first statement if that is a string literal
+func docString(body []Stmt) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if stmt, ok := body[0].(*ExprStmt); ok {
+		if lit, ok := stmt.exp.(*Literal); ok {
+			if doc, ok := lit.val.(string); ok {
+				return doc
+			}
+		}
+	}
+	return ""
+}
+
+// docOf returns the docstring of a value, ok is false for values that aren't documented functions
+func docOf(v interface{}) (doc string, ok bool) {
+	if fn, isFn := v.(*LoxFunction); isFn && fn.doc != "" {
+		return fn.doc, true
+	}
+	return "", false
+}
+
+// doc(f) returns the docstring of the function f, or nil if it has none
+func nativeDoc(in *Interpreter, args []interface{}) interface{} {
+	if doc, ok := docOf(args[0]); ok {
+		return doc
+	}
+	return nil
+}
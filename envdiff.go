@@ -0,0 +1,117 @@
+package main
+
+import "sort"
+
+// The kinds of Change
+const (
+	Added ChangeKind = iota
+	Changed
+	Removed
+)
+
+// ChangeKind tells whether a binding was added, changed or removed
+type ChangeKind int
+
+func (k ChangeKind) String() string {
+	return [...]string{"added", "changed", "removed"}[k]
+}
+
+// Change is a binding of an environment that is different from before. Old is nil for added bindings,
+// New for removed ones.
+type Change struct {
+	Name     string
+	Kind     ChangeKind
+	Old, New interface{}
+}
+
+// ChangeListener is told about every write that changes a binding of an Environment, see SetChangeListener
+type ChangeListener func(c Change)
+
+// EnvSnapshot is a copy of the bindings of an environment at one point in time, taken by Snapshot
+type EnvSnapshot struct {
+	bindings map[string]interface{}
+}
+
+// Snapshot copies the environment's own bindings (not those of enclosing scopes) so they can be compared
+// with later ones. Sets and bytes are copied deeply, changing them afterwards shows up in the diff.
+func (e *Environment) Snapshot() *EnvSnapshot {
+	s := &EnvSnapshot{bindings: make(map[string]interface{}, len(e.bindings))}
+	seen := make(map[interface{}]interface{})
+	for name, val := range e.bindings {
+		s.bindings[name] = cloneValue(val, seen)
+	}
+	return s
+}
+
+// Diff returns what changed in the environment since the snapshot was taken, sorted by name. A host syncing
+// the state a script produced into its own data model takes a snapshot of the globals before the run and
+// applies the diff afterwards. New values are the environment's own, not copies.
+func (s *EnvSnapshot) Diff(e *Environment) []Change {
+	changes := make([]Change, 0)
+	for name, val := range e.bindings {
+		old, ok := s.bindings[name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Name: name, Kind: Added, New: val})
+		case !sameValue(old, val):
+			changes = append(changes, Change{Name: name, Kind: Changed, Old: old, New: val})
+		}
+	}
+	for name, old := range s.bindings {
+		if _, ok := e.bindings[name]; !ok {
+			changes = append(changes, Change{Name: name, Kind: Removed, Old: old})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// SetChangeListener installs a hook told about every definition or assignment of this environment's own
+// bindings that changes them, after it happened. Unlike the write interceptor it can't veto writes, and
+// writes of an equal value aren't reported. Changing a set or bytes in place isn't a write. nil removes it.
+func (e *Environment) SetChangeListener(fn ChangeListener) {
+	e.listen = fn
+}
+
+// notify tells the change listener about a write, existed is false if the binding was added by it
+func (e *Environment) notify(name string, old, val interface{}, existed bool) {
+	switch {
+	case e.listen == nil:
+	case !existed:
+		e.listen(Change{Name: name, Kind: Added, New: val})
+	case !sameValue(old, val):
+		e.listen(Change{Name: name, Kind: Changed, Old: old, New: val})
+	}
+}
+
+// sameValue reports whether a binding holding a still holds the same value when it holds b. Unlike '==' it
+// tells 1 from 1.0, and values that can change in place are compared by their contents, everything else is
+// the same if it is identical.
+func sameValue(a, b interface{}) bool {
+	switch av := a.(type) {
+	case *LoxSet:
+		bv, ok := b.(*LoxSet)
+		return ok && av.equal(bv)
+	case *LoxBytes:
+		bv, ok := b.(*LoxBytes)
+		return ok && string(av.b) == string(bv.b)
+	case LoxTime:
+		bv, ok := b.(LoxTime)
+		return ok && av.t.Equal(bv.t) && av.t.Location() == bv.t.Location()
+	case Tuple:
+		bv, ok := b.(Tuple)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !sameValue(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := b.(Tuple); ok {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestEnvironmentDiff checks that a snapshot's diff and the change listener see what a script changed
+func TestEnvironmentDiff(t *testing.T) {
+	in, _ := bufferedInterpreter()
+	in.Interpret(parse("var hp = 10; var mp = 5; var seen = set(); var level = 1;", in.reporter))
+	snap := in.Globals().Snapshot()
+	changes := make([]string, 0)
+	in.Globals().SetChangeListener(func(c Change) {
+		changes = append(changes, fmt.Sprintf("%v %v %v->%v", c.Kind, c.Name, c.Old, c.New))
+	})
+	in.Interpret(parse("hp = hp - 3; mp = 5; add(seen, \"cave\"); level = 1.0; var gold = 7;", in.reporter))
+	got := make([]string, 0)
+	for _, c := range snap.Diff(in.Globals()) {
+		got = append(got, c.Kind.String()+" "+c.Name)
+	}
+	if want := "added gold,changed hp,changed level,changed seen"; strings.Join(got, ",") != want {
+		t.Errorf("Diff was %v, want %v\n", got, want)
+	}
+	if want := "changed hp 10->7,changed level 1->1,added gold <nil>->7"; strings.Join(changes, ",") != want {
+		t.Errorf("Listener saw %v, want %v\n", changes, want)
+	}
+}
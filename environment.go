@@ -1,9 +1,23 @@
 package main
 
+import "fmt"
+
+// WriteInterceptor is consulted before a binding in an Environment is defined or assigned. old is nil if the
+// name isn't bound yet. Returning an error vetoes the write, the error message is reported as a runtime error.
+type WriteInterceptor func(name string, old, new interface{}) error
+
 // Environment DOES NOT have usable default values. Please initialize with a call to New()
 type Environment struct {
 	enclosing *Environment // pointer to enclosing scope
 	bindings  map[string]interface{}
+	// intercept is an optional hook for embedding hosts, see SetWriteInterceptor
+	intercept WriteInterceptor
+	// listen is an optional hook for embedding hosts, see SetChangeListener
+	listen ChangeListener
+	// consts maps the names of constant bindings to the token that declared them
+	consts map[string]Token
+	// reads records every name looked up in this environment while it isn't nil (see FormulaSet)
+	reads map[string]bool
 }
 
 // NewEnvironment() returns a pointer to a properly initialized Environment
@@ -15,13 +29,45 @@ func NewEnvironment(enclosing *Environment) *Environment {
 	return env
 }
 
-// Define() adds a new entry to the given environment bindings
-func (e *Environment) Define(name string, val interface{}) {
+// SetWriteInterceptor installs a hook that sees (and may reject) every write to this environment's own
+// bindings, e.g. to expose host data read-only or to track which globals a script changed. nil removes it.
+func (e *Environment) SetWriteInterceptor(fn WriteInterceptor) {
+	e.intercept = fn
+}
+
+// Define() adds a new entry to the given environment bindings, unless the write interceptor rejects it
+func (e *Environment) Define(name string, val interface{}) error {
+	if decl, ok := e.consts[name]; ok {
+		return fmt.Errorf("Can't redeclare constant '%v' (declared on line %d).", name, decl.line)
+	}
+	old, existed := e.bindings[name]
+	if e.intercept != nil {
+		if err := e.intercept(name, old, val); err != nil {
+			return err
+		}
+	}
 	e.bindings[name] = val
+	e.notify(name, old, val, existed)
+	return nil
+}
+
+// DefineConst() adds a binding that can't be assigned to or redeclared afterwards
+func (e *Environment) DefineConst(name Token, val interface{}) error {
+	if err := e.Define(name.lexeme, val); err != nil {
+		return err
+	}
+	if e.consts == nil {
+		e.consts = make(map[string]Token)
+	}
+	e.consts[name.lexeme] = name
+	return nil
 }
 
 // Get() searches the scope chain for a given name and throws an error if it's not found
 func (e *Environment) Get(name Token) (interface{}, error) {
+	if e.reads != nil {
+		e.reads[name.lexeme] = true
+	}
 	if val, ok := e.bindings[name.lexeme]; ok {
 		return val, nil
 	}
@@ -38,8 +84,17 @@ func (e *Environment) Get(name Token) (interface{}, error) {
 
 // Assign() attempts to change the value bound to 'name' in the scope chain, throws a RuntimeError if 'name' isn't present.
 func (e *Environment) Assign(name Token, val interface{}) error {
-	if _, ok := e.bindings[name.lexeme]; ok {
+	if old, ok := e.bindings[name.lexeme]; ok {
+		if decl, ok := e.consts[name.lexeme]; ok {
+			return RuntimeError{tkn: name, msg: fmt.Sprintf("Can't assign to constant '%v' (declared on line %d).", name.lexeme, decl.line)}
+		}
+		if e.intercept != nil {
+			if err := e.intercept(name.lexeme, old, val); err != nil {
+				return RuntimeError{tkn: name, msg: err.Error()}
+			}
+		}
 		e.bindings[name.lexeme] = val
+		e.notify(name.lexeme, old, val, true)
 		return nil
 	}
 	if e.enclosing != nil {
@@ -47,6 +102,33 @@ func (e *Environment) Assign(name Token, val interface{}) error {
 	}
 	return RuntimeError{
 		tkn: name,
-		msg: "Undefined Variable " + name.lexeme + ".",
+		msg: "Undefined variable " + name.lexeme + ".",
+	}
+}
+
+// ancestor returns the environment distance scopes up the chain, 0 is e itself
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for i := 0; i < distance; i++ {
+		env = env.enclosing
+	}
+	return env
+}
+
+// GetAt() reads a name from the environment the Resolver found its declaration in, distance scopes up the chain
+func (e *Environment) GetAt(distance int, name Token) (interface{}, error) {
+	env := e.ancestor(distance)
+	if val, ok := env.bindings[name.lexeme]; ok {
+		return val, nil
+	}
+	// a local that is resolved but not defined yet, e.g. used in its own initializer
+	return nil, RuntimeError{
+		tkn: name,
+		msg: "Undefined variable " + name.lexeme + ".",
 	}
 }
+
+// AssignAt() is Assign() for a name the Resolver found declared distance scopes up the chain
+func (e *Environment) AssignAt(distance int, name Token, val interface{}) error {
+	return e.ancestor(distance).Assign(name, val)
+}
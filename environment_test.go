@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// bufferedInterpreter returns an interpreter that writes its output and error messages into out
+func bufferedInterpreter() (in *Interpreter, out *bytes.Buffer) {
+	out = &bytes.Buffer{}
+	in = NewInterpreter()
+	in.out = out
+	in.reporter = &ErrorReporter{out: out}
+	return in, out
+}
+
+// TestWriteInterceptor checks that a host can veto writes to its globals and observe the ones it allows
+func TestWriteInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	in := NewInterpreter()
+	in.out = &out
+	in.reporter = &ErrorReporter{out: &out}
+	in.Globals().Define("limit", int64(10))
+	dirty := make(map[string]bool)
+	in.Globals().SetWriteInterceptor(func(name string, old, new interface{}) error {
+		if name == "limit" {
+			return errors.New("limit is read-only.")
+		}
+		dirty[name] = true
+		return nil
+	})
+	in.Interpret(parse("var total = limit * 2; total = total + 1; limit = 0;", in.reporter))
+	if !dirty["total"] || len(dirty) != 1 {
+		t.Errorf("Interceptor saw the wrong writes: %v\n", dirty)
+	}
+	if limit, _ := in.globals.Get(Token{lexeme: "limit"}); limit != int64(10) {
+		t.Errorf("Vetoed write went through, limit is %v\n", limit)
+	}
+	if !strings.Contains(out.String(), "limit is read-only.") {
+		t.Errorf("Vetoed write wasn't reported as a runtime error: %q\n", out.String())
+	}
+}
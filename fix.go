@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Position is a 1-based line and column in a source file
+type Position struct {
+	line, col int
+}
+
+// TextEdit replaces the source text between start (inclusive) and end (exclusive)
+type TextEdit struct {
+	start, end Position
+	text       string
+}
+
+// Fix is a machine-applicable change that resolves a diagnostic
+type Fix struct {
+	description string
+	edits       []TextEdit
+}
+
+// editSpan is a TextEdit resolved to byte offsets in a particular source string
+type editSpan struct {
+	start, end int
+	text       string
+}
+
+// applyFixes applies the edits of every fix to the source and returns the result along with the
+// number of fixes applied. A fix that overlaps one applied earlier is skipped.
+func applyFixes(source string, fixes []*Fix) (string, int) {
+	lineStarts := []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(pos Position) int {
+		if pos.line < 1 || pos.line > len(lineStarts) {
+			return len(source)
+		}
+		off := lineStarts[pos.line-1] + pos.col - 1
+		if off > len(source) {
+			return len(source)
+		}
+		return off
+	}
+	taken := make([]editSpan, 0)
+	overlaps := func(s editSpan) bool {
+		for _, t := range taken {
+			if (s.start < t.end && t.start < s.end) || s.start == t.start {
+				return true
+			}
+		}
+		return false
+	}
+	applied := 0
+	for _, fix := range fixes {
+		spans := make([]editSpan, 0, len(fix.edits))
+		ok := true
+		for _, e := range fix.edits {
+			s := editSpan{offset(e.start), offset(e.end), e.text}
+			if s.text == "" {
+				s = wholeLine(source, s)
+			}
+			if overlaps(s) {
+				ok = false
+				break
+			}
+			spans = append(spans, s)
+		}
+		if ok {
+			taken = append(taken, spans...)
+			applied++
+		}
+	}
+	// apply back to front so earlier offsets stay valid
+	sort.Slice(taken, func(i, j int) bool { return taken[i].start > taken[j].start })
+	for _, s := range taken {
+		source = source[:s.start] + s.text + source[s.end:]
+	}
+	return source, applied
+}
+
+// wholeLine widens a deletion to cover its entire line (newline included) if nothing else is on it,
+// so removing a declaration doesn't leave a blank line behind. Otherwise it takes the blanks after the
+// deletion along, or those before it at the end of a line, so no double or trailing space is left.
+func wholeLine(source string, s editSpan) editSpan {
+	lineStart := strings.LastIndexByte(source[:s.start], '\n') + 1
+	lineEnd := len(source)
+	if nl := strings.IndexByte(source[s.end:], '\n'); nl >= 0 {
+		lineEnd = s.end + nl + 1
+	}
+	if strings.TrimSpace(source[lineStart:s.start]) == "" && strings.TrimSpace(source[s.end:lineEnd]) == "" {
+		return editSpan{lineStart, lineEnd, ""}
+	}
+	if strings.TrimSpace(source[s.end:lineEnd]) != "" {
+		rest := source[s.end:lineEnd]
+		return editSpan{s.start, s.end + len(rest) - len(strings.TrimLeft(rest, " \t")), ""}
+	}
+	before := source[lineStart:s.start]
+	return editSpan{s.start - len(before) + len(strings.TrimRight(before, " \t")), s.end, ""}
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+// TestApplyFixesDeletion checks that deleting a declaration removes its line, or the blanks next to it
+// if other code shares the line
+func TestApplyFixesDeletion(t *testing.T) {
+	source := "var a = 1; var b = 2; print a;\nprint a; var c = 3;\nvar d = 4;\nprint a;\n"
+	del := func(line, start, end int) *Fix {
+		return &Fix{edits: []TextEdit{{start: Position{line, start}, end: Position{line, end}}}}
+	}
+	got, n := applyFixes(source, []*Fix{del(1, 12, 22), del(2, 10, 20), del(3, 1, 11)})
+	want := "var a = 1; print a;\nprint a;\nprint a;\n"
+	if n != 3 || got != want {
+		t.Errorf("Wrong result of the deletions. Wanted: %q, Got: %q (%v fixes)\n", want, got, n)
+	}
+}
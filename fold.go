@@ -0,0 +1,162 @@
+package main
+
+import "io/ioutil"
+
+// constantFolder works out the value of expressions that are known before a script runs: literals
+// combined with operators and references to constants whose initializers are constant themselves.
+// The expressions are evaluated by a scratch interpreter, so folding can't disagree with running them.
+type constantFolder struct {
+	in    *Interpreter
+	table *SymbolTable
+	// refs maps the name token of every resolved reference to the symbol it refers to
+	refs map[Token]*Symbol
+	// folding guards against constants whose initializers refer to each other
+	folding map[*Symbol]bool
+}
+
+// newConstantFolder prepares folding expressions of the script the symbol table was built from
+func newConstantFolder(table *SymbolTable) *constantFolder {
+	in := NewInterpreter()
+	in.out = ioutil.Discard
+	in.reporter = &ErrorReporter{out: ioutil.Discard}
+	f := &constantFolder{in: in, table: table, refs: make(map[Token]*Symbol), folding: make(map[*Symbol]bool)}
+	for _, ref := range table.refs {
+		f.refs[ref.tkn] = ref.target
+	}
+	return f
+}
+
+// fold returns the value of e, ok is false if it isn't a constant expression or evaluating it fails
+func (f *constantFolder) fold(e Expr) (val interface{}, ok bool) {
+	if e == nil {
+		return nil, false
+	}
+	constant := true
+	Inspect([]Stmt{&ExprStmt{exp: e}}, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *ExprStmt, *Literal, *Unary, *BinaryExpr, *Grouping, *LogicalExpr:
+		case *Variable:
+			// the scratch interpreter has no scopes, the constant is defined as a global under its name
+			val, ok := f.constant(f.refs[n.name])
+			if ok {
+				f.in.globals.Define(n.name.lexeme, val)
+			}
+			constant = constant && ok
+		default:
+			constant = false
+		}
+		return constant
+	})
+	if !constant {
+		return nil, false
+	}
+	val, err := f.in.evaluate(e)
+	return val, err == nil
+}
+
+// constant returns the value of a symbol declared with 'const' and a constant initializer
+func (f *constantFolder) constant(sym *Symbol) (interface{}, bool) {
+	if sym == nil || f.folding[sym] {
+		return nil, false
+	}
+	decl, isVar := sym.decl.(*VarStmt)
+	if !isVar || !decl.constant {
+		return nil, false
+	}
+	f.folding[sym] = true
+	defer delete(f.folding, sym)
+	return f.fold(decl.init)
+}
+
+// foldConstants implements -O: it replaces every constant subexpression of a resolved script with a literal
+// holding its value, so loops don't compute it again on every iteration. Only literals combined with operators
+// are folded, a name might not be bound yet when the expression runs. Expressions that fail to evaluate (like
+// '1 / 0') are left alone, running them reports the error where it used to happen.
+func foldConstants(stmts []Stmt, in *Interpreter) {
+	f := newConstantFolder(&SymbolTable{})
+	// the scratch interpreter has to compute the same values as the one running the script
+	f.in.dialect, f.in.checkedInts = in.dialect, in.checkedInts
+	for _, stmt := range stmts {
+		f.foldStmt(stmt)
+	}
+}
+
+// foldStmt folds the expressions of a statement and the statements nested in it
+func (f *constantFolder) foldStmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *PrintStmt:
+		s.exp = f.foldExpr(s.exp)
+	case *ExprStmt:
+		s.exp = f.foldExpr(s.exp)
+	case *VarStmt:
+		s.init = f.foldExpr(s.init)
+	case *DestructureStmt:
+		s.init = f.foldExpr(s.init)
+	case *ReturnStmt:
+		s.val = f.foldExpr(s.val)
+	case *YieldStmt:
+		s.val = f.foldExpr(s.val)
+	case *IfStmt:
+		s.exp = f.foldExpr(s.exp)
+		f.foldStmt(s.thenPart)
+		f.foldStmt(s.elsePart)
+	case *WhileStmt:
+		s.condition = f.foldExpr(s.condition)
+		f.foldStmt(s.statement)
+	case *ForInStmt:
+		s.collection = f.foldExpr(s.collection)
+		f.foldStmt(s.body)
+	case *BlockStmt:
+		for _, inner := range s.statements {
+			f.foldStmt(inner)
+		}
+	case *FunctionStmt:
+		for i := range s.decorators {
+			s.decorators[i].expr = f.foldExpr(s.decorators[i].expr)
+		}
+		for _, inner := range s.body {
+			f.foldStmt(inner)
+		}
+	}
+}
+
+// foldExpr returns the literal e folds into, or e with its constant subexpressions folded
+func (f *constantFolder) foldExpr(e Expr) Expr {
+	if _, ok := e.(*Literal); ok || e == nil {
+		return e
+	}
+	if val, ok := f.fold(e); ok {
+		// the literal keeps the position of the expression, exprLine() still finds its line
+		return &Literal{val: val, tkn: exprStart(e)}
+	}
+	switch n := e.(type) {
+	case *BinaryExpr:
+		n.left, n.right = f.foldExpr(n.left), f.foldExpr(n.right)
+	case *LogicalExpr:
+		n.left, n.right = f.foldExpr(n.left), f.foldExpr(n.right)
+	case *Unary:
+		n.right = f.foldExpr(n.right)
+	case *Grouping:
+		n.exp = f.foldExpr(n.exp)
+	case *AssignExpr:
+		n.val = f.foldExpr(n.val)
+	case *CallExpr:
+		n.callee = f.foldExpr(n.callee)
+		for i, arg := range n.arguments {
+			n.arguments[i] = f.foldExpr(arg)
+		}
+	case *TupleExpr:
+		for i, val := range n.values {
+			n.values[i] = f.foldExpr(val)
+		}
+	case *IsExpr:
+		n.val = f.foldExpr(n.val)
+	case *IndexExpr:
+		n.object, n.start, n.end = f.foldExpr(n.object), f.foldExpr(n.start), f.foldExpr(n.end)
+	case *SetExpr:
+		for i, elem := range n.elems {
+			n.elems[i] = f.foldExpr(elem)
+		}
+	}
+	return e
+}
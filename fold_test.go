@@ -0,0 +1,22 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// TestFoldConstants checks that constant subexpressions become literals and failing ones are kept
+func TestFoldConstants(t *testing.T) {
+	stmts := parse("print x + (1 + 2 * 3);\nprint \"a\" + \"b\";\nprint 1 / 0;\n", &ErrorReporter{out: ioutil.Discard})
+	foldConstants(stmts, NewInterpreter())
+	sum := stmts[0].(*PrintStmt).exp.(*BinaryExpr)
+	if lit, ok := sum.right.(*Literal); !ok || lit.val != int64(7) || lit.tkn.line != 1 {
+		t.Errorf("The constant operand wasn't folded: %#v\n", sum.right)
+	}
+	if lit, ok := stmts[1].(*PrintStmt).exp.(*Literal); !ok || lit.val != "ab" {
+		t.Errorf("The concatenation wasn't folded: %#v\n", stmts[1].(*PrintStmt).exp)
+	}
+	if _, ok := stmts[2].(*PrintStmt).exp.(*BinaryExpr); !ok {
+		t.Errorf("The division by zero was folded\n")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OrganizeImports rewrites the top-level imports of a script in 'dir' into a single block at the position of
+// the first one: duplicates and unused imports are removed, library modules (found through the search path)
+// come first and project modules second, each group sorted by path.
+// An import is unused if the script never refers to anything the module (or the modules it imports) declares,
+// modules that run more than declarations at the top level are always kept for their side effects.
+func OrganizeImports(source string, stmts []Stmt, dir string, searchPath []string) string {
+	imports := make([]*ImportStmt, 0)
+	for _, s := range stmts {
+		if imp, ok := s.(*ImportStmt); ok {
+			imports = append(imports, imp)
+		}
+	}
+	if len(imports) == 0 {
+		return source
+	}
+	used := make(map[string]bool)
+	for _, ref := range NewSymbolTable(stmts).unresolved {
+		used[ref.tkn.lexeme] = true
+	}
+	seen := make(map[string]bool)
+	library, project := make([]string, 0), make([]string, 0)
+	for _, imp := range imports {
+		name := imp.path.literal.(string)
+		path, local := resolveImport(name, dir, nil)
+		if !local && !isRelativeImport(name) {
+			path, _ = resolveImport(name, dir, searchPath)
+		}
+		key := path
+		if key == "" {
+			// unresolvable imports are kept as they are, there's no telling what they provide
+			key = name
+		} else if !importUsed(path, used, searchPath) {
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if path != "" && !local && !isRelativeImport(name) {
+			library = append(library, imp.path.lexeme)
+		} else {
+			project = append(project, imp.path.lexeme)
+		}
+	}
+	sort.Strings(library)
+	sort.Strings(project)
+	lines := make([]string, 0, len(library)+len(project)+1)
+	for _, p := range library {
+		lines = append(lines, "import "+p+";")
+	}
+	if len(library) > 0 && len(project) > 0 {
+		lines = append(lines, "")
+	}
+	for _, p := range project {
+		lines = append(lines, "import "+p+";")
+	}
+	fix := &Fix{description: "organize imports"}
+	for i, imp := range imports {
+		edit := TextEdit{
+			start: Position{imp.keyword.line, imp.keyword.col},
+			end:   Position{imp.end.line, imp.end.col + len(imp.end.lexeme)},
+		}
+		if i == 0 {
+			edit.text = strings.Join(lines, "\n")
+		}
+		fix.edits = append(fix.edits, edit)
+	}
+	organized, _ := applyFixes(source, []*Fix{fix})
+	return organized
+}
+
+// importUsed reports whether the module at path declares any of the used names or has top-level side effects
+func importUsed(path string, used map[string]bool, searchPath []string) bool {
+	names, effects := moduleExports(path, searchPath, make(map[string]bool))
+	if effects {
+		return true
+	}
+	for name := range names {
+		if used[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleExports collects the top-level names declared by a module and the modules it imports. effects is
+// true if any of them runs anything besides declarations, or can't be read, at the top level.
+func moduleExports(path string, searchPath []string, visited map[string]bool) (names map[string]bool, effects bool) {
+	names = make(map[string]bool)
+	if visited[path] {
+		return names, false
+	}
+	visited[path] = true
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return names, true
+	}
+	r := &ErrorReporter{out: ioutil.Discard}
+	stmts := parse(string(contents), r)
+	if r.hadError {
+		return names, true
+	}
+	for _, s := range stmts {
+		switch n := s.(type) {
+		case *FunctionStmt:
+			names[n.name.lexeme] = true
+		case *VarStmt:
+			names[n.name.lexeme] = true
+			effects = effects || hasSideEffects(n.init)
+		case *DestructureStmt:
+			for _, name := range n.names {
+				names[name.lexeme] = n.declare
+			}
+			// assigning to existing variables is a side effect
+			effects = effects || !n.declare || hasSideEffects(n.init)
+		case *ImportStmt:
+			sub, ok := resolveImport(n.path.literal.(string), filepath.Dir(path), searchPath)
+			if !ok {
+				return names, true
+			}
+			subNames, subEffects := moduleExports(sub, searchPath, visited)
+			for name := range subNames {
+				names[name] = true
+			}
+			effects = effects || subEffects
+		default:
+			effects = true
+		}
+	}
+	return names, effects
+}
+
+// runFmt implements the 'fmt' subcommand. Like gofmt the result is printed unless -w or -d is given.
+func runFmt(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	organize := flags.Bool("organize-imports", false, "sort, group and deduplicate imports and remove unused ones")
+	write := flags.Bool("w", false, "write the result back to the files instead of printing it")
+	diff := flags.Bool("d", false, "print the changes as a diff instead of the result")
+	format := flags.String("format", "text", "output format of -d: text or sarif")
+	flags.Parse(args)
+	paths, err := expandArgs(flags.Args())
+	if err != nil {
+		fmt.Printf("Invalid argument: %v.\n", err)
+		os.Exit(64)
+	}
+	var p *Project
+	if len(paths) > 0 {
+		// organizing imports can be the project's style instead of a flag
+		p = loadProject(paths[0])
+		*organize = *organize || p.bool("fmt", "organize-imports")
+	}
+	if len(paths) == 0 || !*organize || (len(paths) > 1 && !*write && !*diff) || (*format != "text" && *format != "sarif") {
+		fmt.Println("usage: glox.exe fmt -organize-imports [-w | -d [-format text|sarif]] [script | dir/... | glob]...")
+		fmt.Println("several files can only be formatted in place, with -w, or compared, with -d")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	log := newSARIF()
+	for _, path := range paths {
+		contents, source := formatFile(path, p)
+		switch {
+		case *write && source != contents:
+			if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+				fmt.Printf("Can't write file at [%v].\n", path)
+				os.Exit(74)
+			}
+		case *diff && *format == "sarif":
+			if fix := rewriteFix("organize imports", contents, source); fix != nil {
+				log.addRule("fmt", "Imports aren't organized.")
+				edit := fix.edits[0]
+				region := sarifRegion{edit.start.line, 1, edit.end.line, 1}
+				log.addResult(path, sarifResult{RuleID: "fmt", Level: "warning", Message: sarifMessage{"Imports aren't organized."}}, region, fix)
+			}
+		case *diff:
+			printDiff(path, contents, source)
+		case !*write:
+			fmt.Print(source)
+		}
+	}
+	if *diff && *format == "sarif" {
+		printJSON(log)
+	}
+}
+
+// printDiff prints the changes organizing a file's imports makes as a unified diff
+func printDiff(path, before, after string) {
+	fmt.Print(unifiedDiff(path, before, after))
+}
+
+// formatFile organizes the imports of one script, it returns the script before and after
+func formatFile(path string, p *Project) (string, string) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	stmts := parse(string(contents), reporter)
+	if reporter.hadError {
+		os.Exit(65)
+	}
+	return string(contents), OrganizeImports(string(contents), stmts, filepath.Dir(path), p.searchPath())
+}
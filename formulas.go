@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// Formula is a named expression that a host (e.g. a spreadsheet) evaluates against the interpreter's globals.
+// Its value is stored in the global of the same name, so formulas can build on each other.
+type Formula struct {
+	name  string
+	expr  Expr
+	value interface{}
+	err   error
+	// deps are the globals read during the last evaluation, including those read by called functions
+	deps map[string]bool
+}
+
+// FormulaSet re-evaluates formulas only when a global they read changed since their last evaluation.
+// Changes are picked up through a write interceptor on the globals, so it doesn't matter whether the host
+// (through Globals().Define) or a script changed them.
+type FormulaSet struct {
+	in       *Interpreter
+	formulas []*Formula
+	byName   map[string]*Formula
+	changed  map[string]bool
+}
+
+// NewFormulaSet starts tracking writes to the interpreter's globals. An interceptor that was already
+// installed keeps working, it is consulted first.
+func NewFormulaSet(in *Interpreter) *FormulaSet {
+	fs := &FormulaSet{in: in, byName: make(map[string]*Formula), changed: make(map[string]bool)}
+	prev := in.globals.intercept
+	in.globals.SetWriteInterceptor(func(name string, old, new interface{}) error {
+		if prev != nil {
+			if err := prev(name, old, new); err != nil {
+				return err
+			}
+		}
+		fs.changed[name] = true
+		return nil
+	})
+	return fs
+}
+
+// Add parses and evaluates a new formula (or replaces the one with the same name)
+func (fs *FormulaSet) Add(name, source string) error {
+	var errs bytes.Buffer
+	r := &ErrorReporter{out: &errs}
+	lexer := NewLexScanner(source)
+	lexer.reporter = r
+	p := NewParser(lexer)
+	p.reporter = r
+	expr, err := p.expression()
+	if err == nil && !p.isAtEnd() {
+		err = p.getError(*p.Peek(), "Expect end of formula.")
+	}
+	if err != nil || r.hadError {
+		return errors.New(strings.TrimSpace(errs.String()))
+	}
+	f, ok := fs.byName[name]
+	if !ok {
+		f = &Formula{name: name}
+		fs.formulas = append(fs.formulas, f)
+		fs.byName[name] = f
+	}
+	f.expr = expr
+	fs.evaluate(f)
+	return fs.Recompute()
+}
+
+// Value returns the current value of a formula, or the runtime error its last evaluation raised
+func (fs *FormulaSet) Value(name string) (interface{}, error) {
+	f, ok := fs.byName[name]
+	if !ok {
+		return nil, errors.New("Unknown formula " + name + ".")
+	}
+	return f.value, f.err
+}
+
+// Recompute re-evaluates every formula that read a global changed since the last call. A formula whose
+// value changes marks its own global as changed, so dependent formulas are updated in the same call.
+// Formulas that keep changing each other are reported as a cycle.
+func (fs *FormulaSet) Recompute() error {
+	for rounds := 0; len(fs.changed) > 0; rounds++ {
+		if rounds > len(fs.formulas) {
+			fs.changed = make(map[string]bool)
+			return errors.New("Formulas depend on each other in a cycle.")
+		}
+		changed := fs.changed
+		fs.changed = make(map[string]bool)
+		for _, f := range fs.formulas {
+			for dep := range f.deps {
+				if changed[dep] {
+					fs.evaluate(f)
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// evaluate runs a formula with read tracking enabled and publishes its value if it changed
+func (fs *FormulaSet) evaluate(f *Formula) {
+	in := fs.in
+	prevEnv := in.env
+	in.env = in.globals
+	in.globals.reads = make(map[string]bool)
+	val, err := in.evaluate(f.expr)
+	f.deps = in.globals.reads
+	in.globals.reads = nil
+	in.env = prevEnv
+	f.err = err
+	if err != nil {
+		val = nil
+	}
+	if _, defined := in.globals.bindings[f.name]; defined && in.isEqual(val, f.value) {
+		return
+	}
+	f.value = val
+	if err := in.globals.Define(f.name, val); err != nil && f.err == nil {
+		f.err = err
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+// TestFormulaRecompute checks that only formulas reading a changed global are evaluated again
+func TestFormulaRecompute(t *testing.T) {
+	in := NewInterpreter()
+	in.Interpret(parse("var price = 10; var qty = 2; var rate = 2; fun taxed(x) { return x * rate; }", in.reporter))
+	calls := 0
+	in.Globals().Define("count", &NativeFunction{"count", 1, func(in *Interpreter, args []interface{}) interface{} {
+		calls++
+		return args[0]
+	}})
+	fs := NewFormulaSet(in)
+	for name, source := range map[string]string{"total": "price * qty", "gross": "count(taxed(total))", "label": `"qty: " + qty`} {
+		if err := fs.Add(name, source); err != nil {
+			t.Fatalf("Can't add formula %v: %v\n", name, err)
+		}
+	}
+	in.Globals().Define("price", int64(15))
+	in.Globals().Define("qty", int64(2))
+	if err := fs.Recompute(); err != nil {
+		t.Fatalf("Recompute failed: %v\n", err)
+	}
+	if gross, _ := fs.Value("gross"); gross != int64(60) {
+		t.Errorf("Dependent formula wasn't updated. Wanted: 60, Got: %v\n", gross)
+	}
+	if calls != 2 {
+		t.Errorf("Wrong number of evaluations of 'gross'. Wanted: 2, Got: %v\n", calls)
+	}
+	if err := fs.Add("price", "price + 1"); err == nil {
+		t.Errorf("Self-referencing formula wasn't reported as a cycle\n")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+/*
+Calling a function declared with 'fun*' doesn't run its body, it returns a LoxGenerator. The body runs
+on its own goroutine (with its own copy of the interpreter, so its environment doesn't leak into the
+caller's) the first time a value is needed. Each 'yield' hands a value to the consumer over a channel
+and blocks until the next one is requested, so only one of the two goroutines is running at any time.
+A for-in loop stops its generator however it ends, so no goroutine outlives the loop; a generator that is
+never iterated over never starts one.
+*/
+
+// LoxGenerator is the lazily evaluated sequence of values yielded by a call to a generator function
+type LoxGenerator struct {
+	fn *LoxFunction
+	// in is the interpreter the body runs on, copied from the caller's when the generator was created
+	in *Interpreter
+	// env holds the arguments of the call
+	env *Environment
+	// site is the call a generator of the prelude is blamed for errors on, see VisitCall
+	site Token
+	// values carries the yielded values to the consumer, it is closed when the body finishes
+	values chan interface{}
+	// resume tells a suspended body to continue (true) or to stop because nobody needs more values (false)
+	resume           chan bool
+	started, stopped bool
+	// err is the runtime error that ended the body, if any
+	err error
+	// crash is the panic that ended the body, if any
+	crash *goroutinePanic
+}
+
+// goroutinePanic is a panic of the interpreter on another goroutine, raised again on the goroutine waiting
+// for it with the stack of the original, so -crash-report sees where it happened
+type goroutinePanic struct {
+	val   interface{}
+	stack []byte
+}
+
+func (p goroutinePanic) String() string {
+	return fmt.Sprint(p.val)
+}
+
+// generatorStopped unwinds the body of a generator whose consumer stopped asking for values
+type generatorStopped struct{}
+
+func (generatorStopped) Error() string {
+	return "generator stopped"
+}
+
+// newGenerator prepares a generator running fn with the given arguments, its goroutine is only started
+// when the first value is requested so a generator that is never iterated over costs nothing
+func newGenerator(in *Interpreter, fn *LoxFunction, args []interface{}) *LoxGenerator {
+	genIn := *in
+	env := NewEnvironment(fn.closure)
+	for i, param := range fn.params {
+		env.Define(param.lexeme, args[i])
+	}
+	g := &LoxGenerator{fn: fn, in: &genIn, env: env, values: make(chan interface{}), resume: make(chan bool)}
+	genIn.generator = g
+	// the body runs on its own goroutine, its calls are traced separately
+	genIn.frames = nil
+	return g
+}
+
+// start runs the body on its own goroutine, which waits for the first value to be requested
+func (g *LoxGenerator) start() {
+	g.started = true
+	go func() {
+		defer close(g.values)
+		defer func() {
+			// a panic would kill the process without a crash report, the consumer raises it again in next()
+			if r := recover(); r != nil {
+				p, ok := r.(goroutinePanic)
+				if !ok {
+					p = goroutinePanic{val: r, stack: debug.Stack()}
+				}
+				g.crash = &p
+			}
+		}()
+		if !<-g.resume {
+			return
+		}
+		if err, ok := g.in.executeBlock(g.fn.body, g.env).(RuntimeError); ok {
+			g.err = err
+		}
+	}()
+}
+
+// next runs the body up to its next yield, more is false once it has finished
+func (g *LoxGenerator) next() (val interface{}, more bool, err error) {
+	if g.stopped {
+		return nil, false, nil
+	}
+	if !g.started {
+		g.start()
+	}
+	g.resume <- true
+	val, more = <-g.values
+	if !more {
+		g.stopped = true
+		if g.crash != nil {
+			panic(*g.crash)
+		}
+		if err, ok := g.err.(RuntimeError); ok && g.fn.internal && !err.user {
+			// errors raised by the prelude would point into its source
+			err.tkn = g.site
+			return nil, false, err
+		}
+		return nil, false, g.err
+	}
+	return val, true, nil
+}
+
+// stop abandons a generator that hasn't finished, letting its goroutine exit
+func (g *LoxGenerator) stop() {
+	if g.stopped {
+		return
+	}
+	g.stopped = true
+	if !g.started {
+		return
+	}
+	g.resume <- false
+	for range g.values {
+	}
+}
+
+func (g *LoxGenerator) String() string {
+	return "<generator " + g.fn.name.lexeme + ">"
+}
+
+// VisitYieldStmt hands a value to the generator's consumer and waits until the next one is needed
+func (in *Interpreter) VisitYieldStmt(y *YieldStmt) error {
+	if in.generator == nil {
+		return RuntimeError{tkn: y.keyword, msg: "Can't yield outside a generator."}
+	}
+	val, err := in.evaluate(y.val)
+	if err != nil {
+		return err
+	}
+	in.generator.values <- val
+	if !<-in.generator.resume {
+		return generatorStopped{}
+	}
+	return nil
+}
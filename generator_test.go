@@ -0,0 +1,34 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestGeneratorPanic checks that a panic in a generator's body reaches the loop iterating over it
+func TestGeneratorPanic(t *testing.T) {
+	in, _ := bufferedInterpreter()
+	in.globals.Define("boom", &NativeFunction{name: "boom", fn: func(in *Interpreter, args []interface{}) interface{} {
+		panic("boom")
+	}})
+	stmts := parse("fun* gen() { yield 1; boom(); } for (var x in gen()) {}", in.reporter)
+	defer func() {
+		p, ok := recover().(goroutinePanic)
+		if !ok || p.val != "boom" || len(p.stack) == 0 {
+			t.Errorf("The generator's panic wasn't raised by the loop, got %#v\n", p)
+		}
+	}()
+	in.Interpret(stmts)
+}
+
+// TestGeneratorNotStarted checks that generators nobody iterates over don't keep goroutines
+func TestGeneratorNotStarted(t *testing.T) {
+	in, out := bufferedInterpreter()
+	before := runtime.NumGoroutine()
+	in.Interpret(parse("fun* gen() { yield 1; } for (var i = 0; i < 50; i = i + 1) gen();", in.reporter))
+	time.Sleep(10 * time.Millisecond)
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines left behind\n%v", n-before, out.String())
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Glob returns the paths matching a pattern, sorted. Each '/' separated segment of the pattern matches like
+// filepath.Match, and a '**' segment matches any number of directories, none included. Hidden files and
+// directories are only matched by a segment starting with '.'. A pattern without wildcards is returned as is
+// if the file exists. Globbing doesn't depend on the shell, so quoted patterns work the same everywhere.
+func Glob(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, seg := range segments {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %v", pattern)
+		}
+	}
+	// the segments before the first wildcard are a directory to start from
+	root := "."
+	first := 0
+	for first < len(segments)-1 && !hasWildcard(segments[first]) {
+		first++
+	}
+	if first > 0 {
+		root = strings.Join(segments[:first], "/")
+		if root == "" {
+			root = "/"
+		}
+	}
+	seen := make(map[string]bool)
+	matches := make([]string, 0)
+	globSegments(filepath.FromSlash(root), segments[first:], func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			matches = append(matches, path)
+		}
+	})
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// hasWildcard reports whether a pattern segment needs matching
+func hasWildcard(seg string) bool {
+	return strings.ContainsAny(seg, "*?[")
+}
+
+// globSegments calls match for every path below dir that the remaining segments match
+func globSegments(dir string, segments []string, match func(string)) {
+	if len(segments) == 0 {
+		match(dir)
+		return
+	}
+	seg := segments[0]
+	if !hasWildcard(seg) {
+		path := filepath.Join(dir, seg)
+		if _, err := os.Stat(path); err == nil {
+			globSegments(path, segments[1:], match)
+		}
+		return
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	if seg == "**" {
+		globSegments(dir, segments[1:], match)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(seg, ".") {
+			continue
+		}
+		if seg == "**" {
+			if entry.IsDir() {
+				globSegments(filepath.Join(dir, name), segments, match)
+			}
+		} else if ok, _ := filepath.Match(seg, name); ok {
+			globSegments(filepath.Join(dir, name), segments[1:], match)
+		}
+	}
+}
+
+// expandArgs turns the file arguments of a command into paths: 'dir/...' stands for every script below dir
+// and arguments with wildcards are globbed. Paths are in the order of the arguments, each pattern's sorted,
+// and every path is only listed once.
+func expandArgs(args []string) ([]string, error) {
+	seen := make(map[string]bool)
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		var expanded []string
+		switch {
+		case isWorkspacePattern(arg):
+			expanded = workspaceFiles(patternDir(arg))
+		case hasWildcard(arg):
+			var err error
+			if expanded, err = Glob(arg); err != nil {
+				return nil, err
+			}
+			if len(expanded) == 0 {
+				return nil, fmt.Errorf("no files match %v", arg)
+			}
+		default:
+			expanded = []string{arg}
+		}
+		for _, path := range expanded {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// patternDir returns the directory a pattern starts in, the part before its first wildcard or '...'
+func patternDir(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	dir := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if seg == "..." || hasWildcard(seg) {
+			break
+		}
+		dir = append(dir, seg)
+	}
+	if len(dir) == len(segments) {
+		// a plain path, it is in its own directory
+		dir = dir[:len(dir)-1]
+	}
+	if len(dir) == 0 {
+		return "."
+	}
+	if joined := strings.Join(dir, "/"); joined != "" {
+		return filepath.FromSlash(joined)
+	}
+	return "/"
+}
+
+// isPattern reports whether an argument names several files: 'dir/...' or a glob
+func isPattern(arg string) bool {
+	return isWorkspacePattern(arg) || hasWildcard(arg)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestGlob checks '**' matching any depth, the sorted order and that hidden files are left out
+func TestGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glox-glob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"b.lox", "a.lox", "notes.txt", "lib/x/deep.lox", "lib/c.lox", ".git/hook.lox", "lib/.hidden.lox"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Glob(filepath.ToSlash(dir) + "/**/*.lox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.lox", "b.lox", "lib/c.lox", "lib/x/deep.lox"}
+	for i := range want {
+		want[i] = filepath.Join(dir, filepath.FromSlash(want[i]))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, wanted %v\n", got, want)
+	}
+	if _, err := Glob(dir + "/[.lox"); err == nil {
+		t.Errorf("A malformed pattern was accepted\n")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// keywordCategory groups a keyword for highlighting, keywords added later fall into "other"
+// until they are given a category here
+func keywordCategory(t TokenType) string {
+	switch t {
+	case IfTok, Else, ForTok, WhileTok, ReturnTok, YieldTok, ImportTok, InTok:
+		return "control"
+	case VarTok, ConstTok, Fun, Class:
+		return "declaration"
+	case And, OrTok, IsTok:
+		return "operator"
+	case TrueTok, FalseTok, NilTok:
+		return "constant"
+	case ThisTok, Super:
+		return "variable"
+	}
+	return "other"
+}
+
+// keywordsByCategory returns the sorted keywords of each category
+func keywordsByCategory() map[string][]string {
+	groups := make(map[string][]string)
+	for word, t := range reservedWords {
+		cat := keywordCategory(t)
+		groups[cat] = append(groups[cat], word)
+	}
+	for _, words := range groups {
+		sort.Strings(words)
+	}
+	return groups
+}
+
+// sortedCategories returns the category names in a stable order
+func sortedCategories(groups map[string][]string) []string {
+	cats := make([]string, 0, len(groups))
+	for cat := range groups {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+	return cats
+}
+
+// tmScopes maps keyword categories to TextMate scope names
+var tmScopes = map[string]string{
+	"control":     "keyword.control.lox",
+	"declaration": "storage.type.lox",
+	"operator":    "keyword.operator.word.lox",
+	"constant":    "constant.language.lox",
+	"variable":    "variable.language.lox",
+	"other":       "keyword.other.lox",
+}
+
+// tmPattern is a rule of a TextMate grammar
+type tmPattern struct {
+	Name     string               `json:"name,omitempty"`
+	Match    string               `json:"match,omitempty"`
+	Begin    string               `json:"begin,omitempty"`
+	End      string               `json:"end,omitempty"`
+	Captures map[string]tmCapture `json:"captures,omitempty"`
+}
+
+type tmCapture struct {
+	Name string `json:"name"`
+}
+
+// tmGrammar is a TextMate grammar in the JSON form editors like VS Code load
+type tmGrammar struct {
+	Name      string      `json:"name"`
+	ScopeName string      `json:"scopeName"`
+	FileTypes []string    `json:"fileTypes"`
+	Patterns  []tmPattern `json:"patterns"`
+}
+
+// TextMateGrammar builds a TextMate grammar for Lox from the lexer's keyword and operator tables
+func TextMateGrammar() tmGrammar {
+	patterns := []tmPattern{
+		{Name: "comment.line.double-slash.lox", Match: `//.*$`},
+		{Name: "string.quoted.triple.lox", Begin: `"""`, End: `"""`},
+		{Name: "string.quoted.double.lox", Begin: `"`, End: `"`},
+		{Name: "string.quoted.other.raw.lox", Begin: "`", End: "`"},
+		{Name: "constant.numeric.lox", Match: `\b(0[xX][0-9A-Fa-f_]+|[0-9][0-9_]*(\.[0-9][0-9_]*)?)\b`},
+		{Match: `\b(fun)\s*(\*)?\s*([A-Za-z_][A-Za-z0-9_]*)`, Captures: map[string]tmCapture{
+			"1": {tmScopes["declaration"]}, "2": {"keyword.operator.lox"}, "3": {"entity.name.function.lox"},
+		}},
+	}
+	groups := keywordsByCategory()
+	for _, cat := range sortedCategories(groups) {
+		patterns = append(patterns, tmPattern{Name: tmScopes[cat], Match: `\b(` + strings.Join(groups[cat], "|") + `)\b`})
+	}
+	ops := make([]string, len(operators))
+	for i, op := range operators {
+		ops[i] = regexp.QuoteMeta(op)
+	}
+	patterns = append(patterns, tmPattern{Name: "keyword.operator.lox", Match: strings.Join(ops, "|")})
+	return tmGrammar{Name: "Lox", ScopeName: "source.lox", FileTypes: []string{"lox"}, Patterns: patterns}
+}
+
+// treeSitterCaptures maps keyword categories to the capture names of tree-sitter highlight queries
+var treeSitterCaptures = map[string]string{
+	"control":     "@keyword.control",
+	"declaration": "@keyword.storage",
+	"operator":    "@keyword.operator",
+	"constant":    "@constant.builtin",
+	"variable":    "@variable.builtin",
+	"other":       "@keyword",
+}
+
+// writeTreeSitterQueries writes a highlights.scm query file. Keywords and operators are matched by
+// their text, the named nodes assume a tree-sitter grammar for Lox using these node names.
+func writeTreeSitterQueries(w io.Writer) {
+	fmt.Fprintln(w, "; highlights.scm for Lox, generated by 'glx grammar'")
+	fmt.Fprintln(w, "(comment) @comment")
+	fmt.Fprintln(w, "(string) @string")
+	fmt.Fprintln(w, "(number) @number")
+	fmt.Fprintln(w, "(function_declaration name: (identifier) @function)")
+	fmt.Fprintln(w, "(call callee: (identifier) @function.call)")
+	groups := keywordsByCategory()
+	for _, cat := range sortedCategories(groups) {
+		fmt.Fprintf(w, "[%v] %v\n", quoteAll(groups[cat]), treeSitterCaptures[cat])
+	}
+	fmt.Fprintf(w, "[%v] @operator\n", quoteAll(operators))
+}
+
+// quoteAll formats words as a space separated list of query strings
+func quoteAll(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = `"` + w + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// runGrammar implements the 'grammar' subcommand
+func runGrammar(args []string) {
+	flags := flag.NewFlagSet("grammar", flag.ExitOnError)
+	format := flags.String("format", "tmLanguage", "syntax definition to generate: tmLanguage or tree-sitter-queries")
+	flags.Parse(args)
+	switch *format {
+	case "tmLanguage":
+		printJSON(TextMateGrammar())
+	case "tree-sitter-queries":
+		writeTreeSitterQueries(os.Stdout)
+	default:
+		fmt.Printf("Unknown format %q, expected tmLanguage or tree-sitter-queries.\n", *format)
+		os.Exit(64)
+	}
+}
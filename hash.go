@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+)
+
+// The hashing natives take bytes or a string (hashed as UTF-8) and return the digest as bytes,
+// hexEncode() or base64Encode() turn it into the usual printable form.
+
+// dataArg returns the bytes to hash from a bytes or string argument
+func dataArg(v interface{}) ([]byte, bool) {
+	switch d := v.(type) {
+	case *LoxBytes:
+		return d.b, true
+	case string:
+		return []byte(d), true
+	}
+	return nil, false
+}
+
+// sha256(data) returns the SHA-256 digest of bytes or a string
+func nativeSha256(in *Interpreter, args []interface{}) interface{} {
+	data, ok := dataArg(args[0])
+	if !ok {
+		return RuntimeError{msg: "sha256() expects bytes or a string."}
+	}
+	sum := sha256.Sum256(data)
+	return &LoxBytes{b: sum[:]}
+}
+
+// md5(data) returns the MD5 digest of bytes or a string, only for checksums and deduplication: it isn't secure
+func nativeMd5(in *Interpreter, args []interface{}) interface{} {
+	data, ok := dataArg(args[0])
+	if !ok {
+		return RuntimeError{msg: "md5() expects bytes or a string."}
+	}
+	sum := md5.Sum(data)
+	return &LoxBytes{b: sum[:]}
+}
+
+// hmac(key, data) returns the HMAC-SHA256 of data signed with key, both bytes or strings
+func nativeHmac(in *Interpreter, args []interface{}) interface{} {
+	key, kok := dataArg(args[0])
+	data, dok := dataArg(args[1])
+	if !kok || !dok {
+		return RuntimeError{msg: "hmac() expects a key and data, each bytes or a string."}
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return &LoxBytes{b: mac.Sum(nil)}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Rough per-value size estimates used by heap snapshots, these are meant for comparing values with each
+// other and finding what grows, not for predicting the memory use of the Go process
+const (
+	valueHeaderSize = 16
+	astNodeSize     = 48
+)
+
+// HeapKind summarizes all values of one kind reachable from the interpreter's environments
+type HeapKind struct {
+	Kind  string
+	Count int
+	Bytes int
+}
+
+// RetainingPath is a binding that keeps a value alive, e.g. "global cache" or "local i (scope 1)"
+type RetainingPath struct {
+	Path  string
+	Kind  string
+	Bytes int
+}
+
+// HeapSnapshot is a summary of every value reachable from the current scope chain
+type HeapSnapshot struct {
+	Kinds []HeapKind
+	Total int
+	Top   []RetainingPath
+}
+
+// maxRetainingPaths is how many of the biggest bindings a snapshot lists
+const maxRetainingPaths = 10
+
+// TakeHeapSnapshot walks the environments from the innermost scope up to the globals. Values reachable
+// through several bindings (e.g. a function stored in two variables) are only counted once.
+func TakeHeapSnapshot(in *Interpreter) *HeapSnapshot {
+	snap := &HeapSnapshot{}
+	kinds := make(map[string]*HeapKind)
+	seen := make(map[interface{}]bool)
+	depth := 0
+	for env := in.env; env != nil; env = env.enclosing {
+		for name, val := range env.bindings {
+			kind, size := heapKindOf(val)
+			path := "global " + name
+			if env != in.globals {
+				path = fmt.Sprintf("local %v (scope %d)", name, depth)
+			}
+			snap.Top = append(snap.Top, RetainingPath{Path: path, Kind: kind, Bytes: size})
+			if isHeapShared(val) {
+				if seen[val] {
+					continue
+				}
+				seen[val] = true
+			}
+			k, ok := kinds[kind]
+			if !ok {
+				k = &HeapKind{Kind: kind}
+				kinds[kind] = k
+			}
+			k.Count++
+			k.Bytes += size
+			snap.Total += size
+		}
+		depth++
+	}
+	for _, k := range kinds {
+		snap.Kinds = append(snap.Kinds, *k)
+	}
+	sort.Slice(snap.Kinds, func(i, j int) bool { return snap.Kinds[i].Bytes > snap.Kinds[j].Bytes })
+	sort.SliceStable(snap.Top, func(i, j int) bool {
+		if snap.Top[i].Bytes != snap.Top[j].Bytes {
+			return snap.Top[i].Bytes > snap.Top[j].Bytes
+		}
+		return snap.Top[i].Path < snap.Top[j].Path
+	})
+	if len(snap.Top) > maxRetainingPaths {
+		snap.Top = snap.Top[:maxRetainingPaths]
+	}
+	return snap
+}
+
+// isHeapShared reports whether a value is a reference that several bindings can share
+func isHeapShared(val interface{}) bool {
+	switch val.(type) {
+	case *LoxFunction, *NativeFunction, *Generator, *LoxSet, *LoxBytes, *LoxGenerator, *LoxCoroutine:
+		return true
+	}
+	return false
+}
+
+// heapKindOf names the kind of a value and estimates its size in bytes
+func heapKindOf(val interface{}) (string, int) {
+	switch v := val.(type) {
+	case nil:
+		return "nil", 0
+	case bool:
+		return "boolean", 1
+	case int64, float64:
+		return "number", 8
+	case string:
+		return "string", valueHeaderSize + len(v)
+	case *LoxFunction:
+		// a function keeps its whole syntax tree alive
+		nodes := 0
+		Inspect(v.body, func(node interface{}) bool {
+			nodes++
+			return true
+		})
+		return "function", valueHeaderSize + nodes*astNodeSize
+	case Tuple:
+		size := valueHeaderSize
+		for _, elem := range v {
+			_, elemSize := heapKindOf(elem)
+			size += elemSize
+		}
+		return "tuple", size
+	case LoxTime:
+		return "time", valueHeaderSize + 8
+	case *LoxSet:
+		size := valueHeaderSize
+		for _, elem := range v.elems {
+			_, elemSize := heapKindOf(elem)
+			size += elemSize
+		}
+		return "set", size
+	case *LoxBytes:
+		return "bytes", valueHeaderSize + len(v.b)
+	case *NativeFunction:
+		return "native", valueHeaderSize
+	case *LoxGenerator:
+		return "generator", valueHeaderSize
+	case *Generator:
+		return "generator", valueHeaderSize
+	case *LoxCoroutine:
+		return "coroutine", valueHeaderSize
+	}
+	return fmt.Sprintf("%T", val), valueHeaderSize
+}
+
+// Print writes the snapshot as a table followed by the biggest bindings
+func (s *HeapSnapshot) Print(w io.Writer) {
+	fmt.Fprintf(w, "%-12v %8v %10v\n", "kind", "count", "bytes")
+	count := 0
+	for _, k := range s.Kinds {
+		fmt.Fprintf(w, "%-12v %8d %10d\n", k.Kind, k.Count, k.Bytes)
+		count += k.Count
+	}
+	fmt.Fprintf(w, "%-12v %8d %10d\n", "total", count, s.Total)
+	if len(s.Top) > 0 {
+		fmt.Fprintln(w, "largest bindings:")
+		for _, p := range s.Top {
+			fmt.Fprintf(w, "  %-32v %-10v %8d\n", p.Path, p.Kind, p.Bytes)
+		}
+	}
+}
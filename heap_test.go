@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+// TestHeapSnapshotShared checks that a set bound to two variables is only counted once
+func TestHeapSnapshotShared(t *testing.T) {
+	in := NewInterpreter()
+	in.Interpret(parse("var a = set{1, 2, 3}; var b = a; var c = bytes(4); var d = c;", in.reporter))
+	for _, k := range TakeHeapSnapshot(in).Kinds {
+		if (k.Kind == "set" || k.Kind == "bytes") && k.Count != 1 {
+			t.Errorf("Aliased %v counted %d times\n", k.Kind, k.Count)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Hover describes the symbol at a position: what it is, where it is declared and what is known about its
+// value without running the script. The exported fields double as the JSON printed by 'glox hover -json'.
+type Hover struct {
+	Name string `json:"name"`
+	// Kind is one of variable, constant, function or parameter, native for the REPL's built-in functions
+	Kind string `json:"kind"`
+	Line int    `json:"line,omitempty"`
+	Col  int    `json:"col,omitempty"`
+	// Type is the annotated type, or else the built-in type name of the initial value if it is known
+	Type      string `json:"type,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Doc       string `json:"doc,omitempty"`
+	// Value is the folded value of a constant, written as a Lox literal
+	Value string `json:"value,omitempty"`
+}
+
+// String formats the hover for the terminal
+func (h *Hover) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %v", h.Kind, h.Name)
+	if h.Line > 0 {
+		fmt.Fprintf(&b, " [line %d, col %d]", h.Line, h.Col)
+	}
+	for _, field := range [][2]string{{"signature", h.Signature}, {"type", h.Type}, {"value", h.Value}} {
+		if field[1] != "" {
+			fmt.Fprintf(&b, "\n%v: %v", field[0], field[1])
+		}
+	}
+	if h.Doc != "" {
+		b.WriteString("\n" + h.Doc)
+	}
+	return b.String()
+}
+
+// HoverAt returns the hover of the symbol declared or used at line:col, nil if there is none.
+// The source doesn't have to parse.
+func HoverAt(source string, line, col int) *Hover {
+	table := NewSymbolTable(parseTolerant(source, &ErrorReporter{out: ioutil.Discard}))
+	sym := table.SymbolAt(line, col)
+	if sym == nil {
+		return nil
+	}
+	h := &Hover{Name: sym.name.lexeme, Line: sym.name.line, Col: sym.name.col}
+	folder := newConstantFolder(table)
+	switch decl := sym.decl.(type) {
+	case *FunctionStmt:
+		h.Kind, h.Type, h.Doc = "function", "Function", decl.doc
+		h.Signature = signatureOf(decl)
+	case *VarStmt:
+		h.Kind = "variable"
+		if decl.constant {
+			h.Kind = "constant"
+		}
+		if val, ok := folder.fold(decl.init); ok {
+			h.Type = typeOf(val)
+			if decl.typ.lexeme != "" {
+				h.Type = decl.typ.lexeme
+			}
+			if decl.constant {
+				h.Value = loxLiteral(folder.in, val)
+			}
+		} else if decl.typ.lexeme != "" {
+			h.Type = decl.typ.lexeme
+		} else if decl.init == nil {
+			h.Type = "Nil"
+		}
+	case *DestructureStmt:
+		h.Kind = "variable"
+		if decl.constant {
+			h.Kind = "constant"
+		}
+	default:
+		if sym.kind == ParamSymbol {
+			h.Kind = "parameter"
+			if fn, ok := sym.fun.decl.(*FunctionStmt); ok {
+				for i, param := range fn.params {
+					if param == sym.name {
+						h.Type = fn.paramTypes[i].lexeme
+					}
+				}
+			}
+		} else {
+			h.Kind = "variable"
+		}
+	}
+	return h
+}
+
+// infoOf is the hover of a global of a running interpreter, shown by the REPL's :info command
+func infoOf(in *Interpreter, name string, val interface{}) *Hover {
+	h := &Hover{Name: name, Kind: "variable", Type: typeOf(val), Value: loxLiteral(in, val)}
+	switch fn := val.(type) {
+	case *LoxFunction:
+		h.Kind, h.Signature, h.Doc, h.Value = "function", signatureOf(fn.FunctionStmt), fn.doc, ""
+		if fn.internal {
+			h.Kind = "native"
+		}
+	case LoxCaller:
+		h.Kind, h.Value = "native", ""
+	}
+	return h
+}
+
+// signatureOf writes a function's declaration line with its annotations: 'fun area(width: Int, height): Int'
+func signatureOf(f *FunctionStmt) string {
+	star := ""
+	if f.generator {
+		star = "*"
+	}
+	sig := "fun" + star + " " + f.name.lexeme + "(" + strings.Join(paramLabels(f), ", ") + ")"
+	if f.returnType.lexeme != "" {
+		sig += ": " + f.returnType.lexeme
+	}
+	return sig
+}
+
+// loxLiteral writes a value the way it would be written in a script, strings quoted
+func loxLiteral(in *Interpreter, val interface{}) string {
+	if str, ok := val.(string); ok {
+		return strconv.Quote(str)
+	}
+	return in.stringify(val)
+}
+
+// runHover implements the 'hover' subcommand: given file.lox:line:col it describes the symbol there
+func runHover(args []string) {
+	flags := flag.NewFlagSet("hover", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the hover as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: glox.exe hover [-json] [script]:[line]:[col]")
+		os.Exit(64)
+	}
+	path, line, col, err := splitPosition(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	h := HoverAt(string(contents), line, col)
+	if h == nil {
+		fmt.Printf("No symbol at %v:%d:%d.\n", path, line, col)
+		os.Exit(1)
+	}
+	if *asJSON {
+		printJSON(h)
+		return
+	}
+	fmt.Println(h)
+}
@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+// TestHoverAt checks that constants are folded through references to other constants
+func TestHoverAt(t *testing.T) {
+	source := "const base = 10;\nconst limit = base * 2 + 1;\nvar now = clock();\nprint limit;\n"
+	h := HoverAt(source, 4, 7)
+	if h == nil || h.Kind != "constant" || h.Line != 2 || h.Type != "Int" || h.Value != "21" {
+		t.Fatalf("Wrong hover of limit: %+v\n", h)
+	}
+	if h := HoverAt(source, 3, 5); h == nil || h.Kind != "variable" || h.Type != "" || h.Value != "" {
+		t.Errorf("Wrong hover of now: %+v\n", h)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+// Strings (by character), bytes and tuples can be indexed and sliced like Python sequences:
+// a negative index counts from the end, slice bounds are clamped to the sequence and a slice
+// whose start is past its end is empty. Only an index outside the sequence is an error.
+
+// sequence gives the length of an indexable value and a way to take an element or a slice of it
+type sequence struct {
+	length int
+	elem   func(i int) interface{}
+	slice  func(start, end int) interface{}
+}
+
+// asSequence returns the sequence view of v, ok is false if v can't be indexed
+func asSequence(v interface{}) (seq sequence, ok bool) {
+	switch val := v.(type) {
+	case string:
+		chars := []rune(val)
+		return sequence{
+			length: len(chars),
+			elem:   func(i int) interface{} { return string(chars[i]) },
+			slice:  func(start, end int) interface{} { return string(chars[start:end]) },
+		}, true
+	case *LoxBytes:
+		return sequence{
+			length: len(val.b),
+			elem:   func(i int) interface{} { return int64(val.b[i]) },
+			slice:  func(start, end int) interface{} { return &LoxBytes{b: append([]byte(nil), val.b[start:end]...)} },
+		}, true
+	case Tuple:
+		return sequence{
+			length: len(val),
+			elem:   func(i int) interface{} { return val[i] },
+			slice:  func(start, end int) interface{} { return append(Tuple(nil), val[start:end]...) },
+		}, true
+	}
+	return sequence{}, false
+}
+
+// VisitIndex evaluates 'value[i]' and 'value[start:end]'
+func (in *Interpreter) VisitIndex(i *IndexExpr) (interface{}, error) {
+	object, err := in.evaluate(i.object)
+	if err != nil {
+		return nil, err
+	}
+	seq, ok := asSequence(object)
+	if !ok {
+		return nil, RuntimeError{tkn: i.bracket, msg: "Can only index strings, bytes and tuples."}
+	}
+	bounds := make([]int, 0, 2)
+	for n, bound := range []Expr{i.start, i.end} {
+		if bound == nil {
+			// an omitted bound is the start or the end of the sequence
+			bounds = append(bounds, []int{0, seq.length}[n])
+			continue
+		}
+		val, err := in.evaluate(bound)
+		if err != nil {
+			return nil, err
+		}
+		idx, ok := val.(int64)
+		if !ok {
+			return nil, RuntimeError{tkn: i.bracket, msg: "Indices must be integers."}
+		}
+		if idx < 0 {
+			idx += int64(seq.length)
+		}
+		if !i.slice && (idx < 0 || idx >= int64(seq.length)) {
+			return nil, RuntimeError{tkn: i.bracket, msg: "Index out of range."}
+		}
+		bounds = append(bounds, clampIndex(idx, seq.length, i.slice))
+	}
+	if !i.slice {
+		return seq.elem(bounds[0]), nil
+	}
+	start, end := bounds[0], bounds[1]
+	if start > end {
+		start = end
+	}
+	return seq.slice(start, end), nil
+}
+
+// clampIndex limits a slice bound to 0..length, plain indices are checked by the caller instead
+func clampIndex(idx int64, length int, slice bool) int {
+	switch {
+	case !slice:
+		return int(idx)
+	case idx < 0:
+		return 0
+	case idx > int64(length):
+		return length
+	}
+	return int(idx)
+}
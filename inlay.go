@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// InlayHint is a label an editor shows inline in the code without it being part of the source.
+// The exported fields double as the JSON printed by 'glox inlay -json'.
+type InlayHint struct {
+	// Line and Col are where the label goes, it is shown before the character there
+	Line int `json:"line"`
+	Col  int `json:"col"`
+	// Kind is one of parameter (the name of the parameter an argument is passed for), type (the inferred type
+	// of a declared variable) or desugar (the 'while' a for loop is turned into)
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// InlayHints returns the hints for a script, ordered by position. The source doesn't have to parse.
+// Only arguments of functions declared in the script or the prelude are named, natives don't name their
+// parameters. Types of variables without an annotation are inferred from initializers that can be folded.
+func InlayHints(source string) []InlayHint {
+	stmts := parseTolerant(source, &ErrorReporter{out: ioutil.Discard})
+	table := NewSymbolTable(stmts)
+	folder := newConstantFolder(table)
+	preludeFuns := make(map[string]*FunctionStmt)
+	for _, stmt := range prelude() {
+		if fn, ok := stmt.(*FunctionStmt); ok {
+			preludeFuns[fn.name.lexeme] = fn
+		}
+	}
+	hints := make([]InlayHint, 0)
+	Inspect(stmts, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *CallExpr:
+			callee, ok := n.callee.(*Variable)
+			if !ok {
+				break
+			}
+			fn := preludeFuns[callee.name.lexeme]
+			if sym := folder.refs[callee.name]; sym != nil {
+				fn, _ = sym.decl.(*FunctionStmt)
+			}
+			if fn == nil {
+				break
+			}
+			for i, arg := range n.arguments {
+				start := exprStart(arg)
+				if i >= len(fn.params) || start.line == 0 {
+					break
+				}
+				// 'area(width, height)' says it already
+				if v, ok := arg.(*Variable); ok && v.name.lexeme == fn.params[i].lexeme {
+					continue
+				}
+				hints = append(hints, InlayHint{Line: start.line, Col: start.col, Kind: "parameter", Label: fn.params[i].lexeme + ":"})
+			}
+		case *VarStmt:
+			if val, ok := folder.fold(n.init); ok && n.keyword.lexeme != "" && n.typ.lexeme == "" {
+				hints = append(hints, InlayHint{
+					Line: n.name.line, Col: n.name.col + len(n.name.lexeme), Kind: "type", Label: ": " + typeOf(val),
+				})
+			}
+		case *WhileStmt:
+			if n.forTkn.lexeme != "" {
+				hints = append(hints, InlayHint{Line: n.forTkn.line, Col: n.forTkn.col, Kind: "desugar", Label: "while"})
+			}
+		}
+		return true
+	})
+	sort.SliceStable(hints, func(i, j int) bool {
+		if hints[i].Line != hints[j].Line {
+			return hints[i].Line < hints[j].Line
+		}
+		return hints[i].Col < hints[j].Col
+	})
+	return hints
+}
+
+// runInlay implements the 'inlay' subcommand, which prints the inlay hints of a script
+func runInlay(args []string) {
+	flags := flag.NewFlagSet("inlay", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the hints as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: glox.exe inlay [-json] [script]")
+		os.Exit(64)
+	}
+	contents, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", flags.Arg(0))
+		os.Exit(66)
+	}
+	hints := InlayHints(string(contents))
+	if *asJSON {
+		printJSON(hints)
+		return
+	}
+	for _, h := range hints {
+		fmt.Printf("%d:%d\t%v\t%v\n", h.Line, h.Col, h.Kind, h.Label)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+// TestInlayHints checks the three kinds of hints, arguments named like their parameter aren't labelled
+func TestInlayHints(t *testing.T) {
+	source := "fun area(w, h) { return w * h; }\nvar w = 2;\nfor (;;) print area(w, 3);\n"
+	want := []InlayHint{{2, 6, "type", ": Int"}, {3, 1, "desugar", "while"}, {3, 24, "parameter", "h:"}}
+	got := InlayHints(source)
+	if len(got) != len(want) {
+		t.Fatalf("Wanted %v, got %v\n", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Hint %d is %v, wanted %v\n", i, got[i], want[i])
+		}
+	}
+}
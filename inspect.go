@@ -0,0 +1,224 @@
+package main
+
+// Inspector is an implementation of both visitor interfaces that walks every node of a syntax tree
+// in depth-first order and hands each node to a callback. Static analysis passes that only care about
+// a handful of node types can use Inspect() instead of implementing every Visit method themselves.
+type Inspector struct {
+	fn func(node interface{}) bool
+}
+
+// Inspect walks the given statements, calling fn for each node before its children.
+// If fn returns false the children of that node are skipped.
+func Inspect(stmts []Stmt, fn func(node interface{}) bool) {
+	i := &Inspector{fn: fn}
+	i.stmts(stmts)
+}
+
+// stmts walks a list of statements, skipping the nil holes left behind by parse errors
+func (i *Inspector) stmts(stmts []Stmt) {
+	for _, s := range stmts {
+		i.stmt(s)
+	}
+}
+
+func (i *Inspector) stmt(s Stmt) {
+	if s != nil {
+		s.accept(i)
+	}
+}
+
+func (i *Inspector) expr(e Expr) {
+	if e != nil {
+		e.accept(i)
+	}
+}
+
+func (i *Inspector) VisitPrintStmt(c *PrintStmt) error {
+	if i.fn(c) {
+		i.expr(c.exp)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitExprStmt(c *ExprStmt) error {
+	if i.fn(c) {
+		i.expr(c.exp)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitVarStmt(c *VarStmt) error {
+	if i.fn(c) {
+		i.expr(c.init)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitDestructureStmt(d *DestructureStmt) error {
+	if i.fn(d) {
+		i.expr(d.init)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitBlockStmt(b *BlockStmt) error {
+	if i.fn(b) {
+		i.stmts(b.statements)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitIfStmt(s *IfStmt) error {
+	if i.fn(s) {
+		i.expr(s.exp)
+		i.stmt(s.thenPart)
+		i.stmt(s.elsePart)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitWhileStmt(w *WhileStmt) error {
+	if i.fn(w) {
+		i.expr(w.condition)
+		i.stmt(w.statement)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitForInStmt(f *ForInStmt) error {
+	if i.fn(f) {
+		i.expr(f.collection)
+		i.stmt(f.body)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitFunctionStmt(f *FunctionStmt) error {
+	if i.fn(f) {
+		for _, d := range f.decorators {
+			i.expr(d.expr)
+		}
+		i.stmts(f.body)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitReturnStmt(r *ReturnStmt) error {
+	if i.fn(r) {
+		i.expr(r.val)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitYieldStmt(y *YieldStmt) error {
+	if i.fn(y) {
+		i.expr(y.val)
+	}
+	return nil
+}
+
+func (i *Inspector) VisitImportStmt(s *ImportStmt) error {
+	i.fn(s)
+	return nil
+}
+
+func (i *Inspector) VisitBadStmt(s *BadStmt) error {
+	i.fn(s)
+	return nil
+}
+
+func (i *Inspector) VisitBinaryExpr(c *BinaryExpr) (interface{}, error) {
+	if i.fn(c) {
+		i.expr(c.left)
+		i.expr(c.right)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitGrouping(c *Grouping) (interface{}, error) {
+	if i.fn(c) {
+		i.expr(c.exp)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitLiteral(c *Literal) (interface{}, error) {
+	i.fn(c)
+	return nil, nil
+}
+
+func (i *Inspector) VisitBadExpr(b *BadExpr) (interface{}, error) {
+	i.fn(b)
+	return nil, nil
+}
+
+func (i *Inspector) VisitUnary(c *Unary) (interface{}, error) {
+	if i.fn(c) {
+		i.expr(c.right)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitVariable(c *Variable) (interface{}, error) {
+	i.fn(c)
+	return nil, nil
+}
+
+func (i *Inspector) VisitAssign(a *AssignExpr) (interface{}, error) {
+	if i.fn(a) {
+		i.expr(a.val)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitLogical(l *LogicalExpr) (interface{}, error) {
+	if i.fn(l) {
+		i.expr(l.left)
+		i.expr(l.right)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitCall(c *CallExpr) (interface{}, error) {
+	if i.fn(c) {
+		i.expr(c.callee)
+		for _, arg := range c.arguments {
+			i.expr(arg)
+		}
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitIndex(e *IndexExpr) (interface{}, error) {
+	if i.fn(e) {
+		i.expr(e.object)
+		i.expr(e.start)
+		i.expr(e.end)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitIs(e *IsExpr) (interface{}, error) {
+	if i.fn(e) {
+		i.expr(e.val)
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitSet(s *SetExpr) (interface{}, error) {
+	if i.fn(s) {
+		for _, elem := range s.elems {
+			i.expr(elem)
+		}
+	}
+	return nil, nil
+}
+
+func (i *Inspector) VisitTuple(t *TupleExpr) (interface{}, error) {
+	if i.fn(t) {
+		for _, val := range t.values {
+			i.expr(val)
+		}
+	}
+	return nil, nil
+}
@@ -2,22 +2,69 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
 // Interpreter is an implementation of the Visitor interface to recursively
 // walk the syntax tree generated by the parser. Tree-walk interpreter.
 type Interpreter struct {
-	// Lox return values are represented with an empty interface
-	resultVal    interface{}
 	globals, env *Environment
+	clock        Clock
+	// out receives everything printed by the script
+	out      io.Writer
+	reporter *ErrorReporter
+	// checkedInts makes integer overflow a runtime error instead of wrapping around
+	checkedInts bool
+	// optimize folds the constant expressions of every script before it runs, see foldConstants
+	optimize bool
+	// strict is set by -strict, see strict.go
+	strict bool
+	// steps counts executed statements, if maxSteps is positive the script is stopped once it is exceeded
+	steps, maxSteps int
+	// maxString, if positive, is the longest string '+' can build, see template.go
+	maxString int
+	// stepper is the execution started with Start this interpreter is running, execute() spends its budget
+	stepper *Execution
+	// dir is the directory of the running script, imports are resolved relative to it
+	dir string
+	// searchPath lists the library directories searched by imports
+	searchPath []string
+	// modules holds every imported file, true once it finished running and false while it is still being imported
+	modules map[string]bool
+	// importing is the chain of files currently being imported, used to report circular imports
+	importing []string
+	// dialect selects jlox or clox behaviour where glox differs from them
+	dialect Dialect
+	// generator is the generator whose body this interpreter is running, yield is an error outside of one
+	generator *LoxGenerator
+	// coroutine is the coroutine whose function this interpreter is running, suspend() is an error outside of one
+	coroutine *LoxCoroutine
+	// locals maps variable references to the distance of their local declaration, filled by the Resolver.
+	// References that aren't in it are globals.
+	locals map[Expr]int
+	// plugins are the registered plugins whose hooks this interpreter calls, see plugin.go
+	plugins []Plugin
+	// frames are the calls of the script's functions in progress, the innermost last, see stacktrace.go
+	frames []callFrame
+	// preludeLine is the line of the script a call of the prelude in progress was made on, 0 while the
+	// script's own code runs. Calls the prelude makes are listed on it in stack traces.
+	preludeLine int
 }
 
 // RuntimeError is a wrapper around the "offending" token and its associated error message
 type RuntimeError struct {
 	tkn Token
 	msg string
+	// user is set once the error has left a function of the script, tkn is in the script's code then
+	user bool
+	// trace holds the calls in progress when the error left the innermost of them, nil for none yet
+	trace []callFrame
 }
 
 func (r RuntimeError) Error() string {
@@ -38,36 +85,115 @@ func (r ReturnError) Error() string {
 func NewInterpreter() *Interpreter {
 	newEnv := NewEnvironment(nil)
 	newInt := &Interpreter{
-		globals: newEnv,
-		env:     newEnv,
+		globals:    newEnv,
+		env:        newEnv,
+		clock:      systemClock{},
+		out:        os.Stdout,
+		reporter:   reporter,
+		searchPath: filepath.SplitList(os.Getenv(importPathEnv)),
+		modules:    make(map[string]bool),
+		locals:     make(map[Expr]int),
 	}
 	// define native functions in the new interpreter's global environment
-	newInt.globals.Define("clock", GlobalFunctionClock("<native clock fn>"))
+	for _, native := range natives {
+		newInt.globals.Define(native.name, native)
+	}
+	if !*noPrelude {
+		newInt.loadPrelude()
+	}
+	newInt.initPlugins()
 	return newInt
 }
 
+// Globals returns the global environment, embedding hosts use it to define values and install a write interceptor
+func (in *Interpreter) Globals() *Environment {
+	return in.globals
+}
+
 // Interpret is the Interpreter type's public API that allows values to be interpreted
 func (in *Interpreter) Interpret(stmtList []Stmt) {
+	if !in.resolve(stmtList) {
+		return
+	}
 	for _, stmt := range stmtList {
 		err := in.execute(stmt)
 		if err != nil {
 			// catch error type
 			switch errtyp := err.(type) {
 			case RuntimeError:
-				runtimeError(errtyp)
+				// print the output so far first, so the error appears after it
+				in.flush()
+				in.reporter.runtimeError(errtyp)
 				return
 			}
 		}
 	}
+	in.flush()
+}
+
+// flusher is implemented by buffered writers like bufio.Writer
+type flusher interface {
+	Flush() error
+}
+
+// flush writes out anything print left in the interpreter's output buffer, if it has one
+func (in *Interpreter) flush() {
+	if f, ok := in.out.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// runSnippet parses and runs a piece of a longer session (a REPL line, a notebook cell) in the current
+// environment, so it sees everything earlier snippets declared. Errors go to the interpreter's reporter.
+func (in *Interpreter) runSnippet(script string) {
+	stmts := parse(script, in.reporter)
+	if in.reporter.hadError {
+		return
+	}
+	in.Interpret(stmts)
+}
+
+// evalSnippet is runSnippet for the REPL's verbose mode: if the snippet ends in an expression statement,
+// the value of that expression is returned as well. ok is false if there is no value to show.
+func (in *Interpreter) evalSnippet(script string) (val interface{}, ok bool) {
+	stmts := parse(script, in.reporter)
+	if in.reporter.hadError || len(stmts) == 0 {
+		return nil, false
+	}
+	if !in.resolve(stmts) {
+		return nil, false
+	}
+	last, isExpr := stmts[len(stmts)-1].(*ExprStmt)
+	if !isExpr {
+		in.Interpret(stmts)
+		return nil, false
+	}
+	in.Interpret(stmts[:len(stmts)-1])
+	if in.reporter.hadRuntimeError {
+		return nil, false
+	}
+	val, err := in.evaluate(last.exp)
+	in.flush()
+	if rerr, isRuntime := err.(RuntimeError); isRuntime {
+		in.reporter.runtimeError(rerr)
+		return nil, false
+	}
+	return val, err == nil
 }
 
 // execute() is the equivalent of evaluate() for statements
 func (in *Interpreter) execute(s Stmt) error {
-	s.accept(in)
-	if err, ok := in.resultVal.(error); ok {
-		return err
+	in.steps++
+	if in.maxSteps > 0 && in.steps > in.maxSteps {
+		return RuntimeError{msg: "Step limit exceeded."}
 	}
-	return nil
+	if in.stepper != nil && !in.stepper.spend() {
+		return RuntimeError{msg: "Execution was stopped."}
+	}
+	for _, p := range in.plugins {
+		p.OnStatement(in, s)
+	}
+	return s.accept(in)
 }
 
 // convert an evaluated Lox value into a string
@@ -75,13 +201,21 @@ func (in *Interpreter) stringify(val interface{}) string {
 	if val == nil {
 		return "nil"
 	}
+	if num, ok := val.(int64); ok {
+		return strconv.FormatInt(num, 10)
+	}
 	if num, ok := val.(float64); ok {
-		str := fmt.Sprintf("%.1f", num)
-		// strip decimal from int floats
-		if strings.HasSuffix(str, ".0") {
-			str = str[:len(str)-2]
+		return in.dialect.formatFloat(num)
+	}
+	if tuple, ok := val.(Tuple); ok {
+		strs := make([]string, len(tuple))
+		for i, v := range tuple {
+			strs[i] = in.stringify(v)
 		}
-		return str
+		return "(" + strings.Join(strs, ", ") + ")"
+	}
+	if set, ok := val.(*LoxSet); ok {
+		return in.stringifySet(set)
 	}
 	return fmt.Sprintf("%v", val)
 }
@@ -89,308 +223,474 @@ func (in *Interpreter) stringify(val interface{}) string {
 // allow a given expression to call the correct Visit method for its type
 func (in *Interpreter) evaluate(e Expr) (interface{}, error) {
 	// each expression "accepts" the interpreter struct (which implements the Visitor interface)
-	e.accept(in)
-	// catch any runtime errors
-	if err, ok := in.resultVal.(error); ok {
-		// DO SOMETHING WITH THE ERROR
-		return nil, err
-	}
-	return in.resultVal, nil
+	return e.accept(in)
 }
 
-func (in *Interpreter) VisitReturnStmt(r *ReturnStmt) {
+func (in *Interpreter) VisitReturnStmt(r *ReturnStmt) error {
 	var val interface{}
 	var err error
 	if r.val != nil {
 		val, err = in.evaluate(r.val)
 		if err != nil {
-			in.resultVal = err
-			return
+			return err
 		}
 	}
-	in.resultVal = &ReturnError{val}
+	return &ReturnError{val}
 }
 
 // VisitCall executes a call structure in the input AST
-func (in *Interpreter) VisitCall(c *CallExpr) {
+func (in *Interpreter) VisitCall(c *CallExpr) (interface{}, error) {
 	callee, err := in.evaluate(c.callee)
 	if err != nil {
-		in.resultVal = err
-		return
+		return nil, err
 	}
 	// eval args
 	evalArgs := make([]interface{}, 0)
 	for _, arg := range c.arguments {
 		evalArg, err := in.evaluate(arg)
 		if err != nil {
-			in.resultVal = err
-			return
+			return nil, err
 		}
 		evalArgs = append(evalArgs, evalArg)
 	}
 	// callee MUST BE callable
-	function, ok := callee.(LoxFunction)
+	function, ok := callee.(LoxCaller)
 	if !ok {
 		// throw a RuntimeError
-		in.resultVal = &RuntimeError{
+		return nil, RuntimeError{
 			tkn: c.paren,
 			msg: "Can only call functions and classes.",
 		}
-		return
 	}
 	// correct number of arguments MUST BE given
 	if len(evalArgs) != function.arity() {
-		in.resultVal = &RuntimeError{
+		return nil, RuntimeError{
 			tkn: c.paren,
 			msg: fmt.Sprintf("Expected %d arguments but got %d.", function.arity(), len(evalArgs)),
 		}
-		return
 	}
-	// call the given function without
-	in.resultVal = function.call(in, evalArgs)
+	// calls of the script's functions are listed in stack traces, natives and the prelude's functions report
+	// errors at the call site instead
+	fn, isFn := function.(*LoxFunction)
+	traced := isFn && !fn.internal
+	preludeLine := in.preludeLine
+	switch {
+	case traced:
+		line := c.paren.line
+		if preludeLine > 0 {
+			line = preludeLine
+		}
+		in.frames = append(in.frames, callFrame{name: fn.name.lexeme, line: line})
+		in.preludeLine = 0
+	case isFn && preludeLine == 0:
+		in.preludeLine = c.paren.line
+	}
+	result := function.call(in, evalArgs)
+	in.preludeLine = preludeLine
+	if traced {
+		if res, ok := result.(RuntimeError); ok && res.trace == nil {
+			res.trace = in.stackTrace()
+			result = res
+		}
+		in.frames = in.frames[:len(in.frames)-1]
+	}
+	if g, ok := result.(*LoxGenerator); ok && isFn && fn.internal {
+		// the prelude's generators run their bodies later, remember where the script asked for them
+		g.site = c.paren
+	}
+	switch res := result.(type) {
+	case RuntimeError:
+		switch {
+		case isFn && !fn.internal:
+			res.user = true
+		case isFn && !res.user, res.tkn.lexeme == "":
+			// natives don't know where they were called from and errors raised by the prelude would point
+			// into its source, blame the call site
+			res.tkn = c.paren
+		}
+		return nil, res
+	case error:
+		// the function of a closed coroutine unwinding
+		return nil, res
+	}
+	return result, nil
 }
 
 // VisitFunctionStmt creates a binding in the interpreter's current environment between the function's name
 // and its corresponding LoxFunction values when a variable declaration is encountered. This creates a "callable"
 // interface (LoxFunction) for the given FunctionStmt node that can be invoked using the call() method later in the tree-walk.
-func (in *Interpreter) VisitFunctionStmt(f *FunctionStmt) {
-	function := LoxFunction(*f)
-	in.env.Define(f.name.lexeme, function)
+func (in *Interpreter) VisitFunctionStmt(f *FunctionStmt) error {
+	function := &LoxFunction{FunctionStmt: f, closure: in.env}
+	val, err := in.decorate(function, f.decorators)
+	if err != nil {
+		return err
+	}
+	if err := in.env.Define(f.name.lexeme, val); err != nil {
+		return RuntimeError{tkn: f.name, msg: err.Error()}
+	}
+	return nil
 }
 
 // VisitVariable evaluates a variable expression to its corresponding value in the symbol table
-func (in *Interpreter) VisitAssign(a *AssignExpr) {
+func (in *Interpreter) VisitAssign(a *AssignExpr) (interface{}, error) {
 	val, err := in.evaluate(a.val)
 	if err != nil {
-		in.resultVal = err
-		return
+		return nil, err
 	}
-	err = in.env.Assign(a.name, val)
-	if err != nil {
-		in.resultVal = err
+	if distance, ok := in.locals[a]; ok {
+		err = in.env.AssignAt(distance, a.name, val)
 	} else {
-		in.resultVal = val
+		err = in.globals.Assign(a.name, val)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return val, nil
 }
 
 // VisitWhileStmt executes a while statement in the input syntax tree
 // this is a thin wrapper around Go's for loop
-func (in *Interpreter) VisitWhileStmt(w *WhileStmt) {
+func (in *Interpreter) VisitWhileStmt(w *WhileStmt) error {
 	condition, err := in.evaluate(w.condition)
 	if err != nil {
-		in.resultVal = err
-		return
+		return err
 	}
 	for in.isTruthy(condition) {
 		err = in.execute(w.statement)
 		if err != nil {
-			in.resultVal = err
-			return
+			return err
 		}
 		// check condition again
 		condition, err = in.evaluate(w.condition)
 		if err != nil {
-			in.resultVal = err
-			return
+			return err
 		}
 	}
-	in.resultVal = nil
+	return nil
+}
+
+// VisitForInStmt runs the loop body once for every element of a collection,
+// each iteration gets a fresh environment holding the loop variable
+func (in *Interpreter) VisitForInStmt(f *ForInStmt) error {
+	collection, err := in.evaluate(f.collection)
+	if err != nil {
+		return err
+	}
+	next, ok := in.iterator(collection)
+	if !ok {
+		return RuntimeError{
+			tkn: f.name,
+			msg: "Can only iterate over strings, sets and generators.",
+		}
+	}
+	if g, ok := collection.(*LoxGenerator); ok {
+		// let the generator's goroutine finish if the loop ends early
+		defer g.stop()
+	}
+	for {
+		elem, more, err := next()
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+		env := NewEnvironment(in.env)
+		env.Define(f.name.lexeme, elem)
+		if err := in.executeBlock([]Stmt{f.body}, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator returns a function producing the elements of a collection one at a time (or the error that
+// stopped it), ok is false if the value can't be iterated over
+func (in *Interpreter) iterator(collection interface{}) (next func() (interface{}, bool, error), ok bool) {
+	switch c := collection.(type) {
+	case string:
+		// strings are iterated character by character
+		chars := []rune(c)
+		i := 0
+		return func() (interface{}, bool, error) {
+			if i >= len(chars) {
+				return nil, false, nil
+			}
+			i++
+			return string(chars[i-1]), true, nil
+		}, true
+	case *LoxGenerator:
+		return c.next, true
+	case *LoxSet:
+		// iterate over a snapshot, so the loop body can add and remove elements
+		elems := append([]interface{}(nil), c.elems...)
+		i := 0
+		return func() (interface{}, bool, error) {
+			if i >= len(elems) {
+				return nil, false, nil
+			}
+			i++
+			return elems[i-1], true, nil
+		}, true
+	}
+	return nil, false
 }
 
 // VisitVariable evaluates a variable expression to its corresponding value in the symbol table
-func (in *Interpreter) VisitVariable(v *Variable) {
-	val, err := in.env.Get(v.name)
+func (in *Interpreter) VisitVariable(v *Variable) (interface{}, error) {
+	var val interface{}
+	var err error
+	if distance, ok := in.locals[v]; ok {
+		val, err = in.env.GetAt(distance, v.name)
+	} else {
+		val, err = in.globals.Get(v.name)
+	}
 	if err != nil {
-		in.resultVal = err
-		return
+		return nil, err
 	}
-	in.resultVal = val
+	if _, ok := val.(uninitialized); ok {
+		return nil, RuntimeError{tkn: v.name, msg: "Variable '" + v.name.lexeme + "' is read before it is initialized."}
+	}
+	return val, nil
 }
 
 // VisitIfStmt interprets an if statement
-func (in *Interpreter) VisitIfStmt(i *IfStmt) {
+func (in *Interpreter) VisitIfStmt(i *IfStmt) error {
 	condition, err := in.evaluate(i.exp)
 	if err != nil {
-		in.resultVal = err
-		return
+		return err
 	}
 	if in.isTruthy(condition) {
 		if err = in.execute(i.thenPart); err != nil {
-			in.resultVal = err
-			return
+			return err
 		}
 	} else if i.elsePart != nil {
 		// execute the else statement if it exists
 		if err = in.execute(i.elsePart); err != nil {
-			in.resultVal = err
-			return
+			return err
 		}
 	}
-	in.resultVal = nil
+	return nil
 }
 
 // VisitLogical() interprets the expressions given as
 // arguments to a logical expression (short-circuiting if necessary)
 // a value with appropriate "truthy-ness" will be returned
-func (in *Interpreter) VisitLogical(l *LogicalExpr) {
+func (in *Interpreter) VisitLogical(l *LogicalExpr) (interface{}, error) {
 	left, err := in.evaluate(l.left)
 	if err != nil {
-		in.resultVal = err
-		return
+		return nil, err
 	}
 	// the following conditional block allows logical operators to "short circuit"
-	if l.op.toktype == OrTok {
+	if l.op.toktype == QuestionQuestion {
+		// ?? only looks at the right side if the left one is nil
+		if left != nil {
+			return left, nil
+		}
+	} else if l.op.toktype == OrTok {
 		// OR token with true left expr
 		if in.isTruthy(left) {
-			in.resultVal = left
-			return
+			return left, nil
 		}
 	} else {
 		// AND token with false left expr
 		if !in.isTruthy(left) {
-			in.resultVal = left
-			return
+			return left, nil
 		}
 	}
 	right, err := in.evaluate(l.right)
 	if err != nil {
-		in.resultVal = err
-		return
+		return nil, err
 	}
-	in.resultVal = right
+	return right, nil
 }
 
 // VisitBlockStmt evaluates the statements inside of a lexical block
-func (in *Interpreter) VisitBlockStmt(b *BlockStmt) {
+func (in *Interpreter) VisitBlockStmt(b *BlockStmt) error {
 	// execute block statements in a new environment
-	in.executeBlock(b.statements, NewEnvironment(in.env))
+	return in.executeBlock(b.statements, NewEnvironment(in.env))
 }
 
-// execute a given list of statements in the given environment
-func (in *Interpreter) executeBlock(stmts []Stmt, newEnv *Environment) {
-	// "push" the given environment onto the top of the scope chain
-	newEnv.enclosing = in.env
+// execute a given list of statements in the given environment, which encloses the current one for a
+// block and the function's closure for a call
+func (in *Interpreter) executeBlock(stmts []Stmt, newEnv *Environment) error {
+	prev := in.env
 	in.env = newEnv
+	// restore the previous environment however the block is left
+	defer func() { in.env = prev }()
 	for _, statement := range stmts {
-		err := in.execute(statement)
+		if err := in.execute(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tuple holds the values of a 'return a, b;' until they are destructured
+type Tuple []interface{}
+
+// VisitTuple evaluates the values of a multiple return
+func (in *Interpreter) VisitTuple(t *TupleExpr) (interface{}, error) {
+	tuple := make(Tuple, 0, len(t.values))
+	for _, e := range t.values {
+		val, err := in.evaluate(e)
+		if err != nil {
+			return nil, err
+		}
+		tuple = append(tuple, val)
+	}
+	return tuple, nil
+}
+
+// VisitBadStmt refuses to run what a tolerant parser skipped, the tree of a script with syntax errors
+// is only meant for analysis
+func (in *Interpreter) VisitBadStmt(b *BadStmt) error {
+	return RuntimeError{tkn: b.from, msg: "Can't run code with syntax errors."}
+}
+
+// VisitBadExpr refuses to evaluate an expression a tolerant parser couldn't parse
+func (in *Interpreter) VisitBadExpr(b *BadExpr) (interface{}, error) {
+	return nil, RuntimeError{tkn: b.tkn, msg: "Can't run code with syntax errors."}
+}
+
+// VisitDestructureStmt unpacks a tuple into new variables (or existing ones for an assignment),
+// the number of names must match the number of values
+func (in *Interpreter) VisitDestructureStmt(d *DestructureStmt) error {
+	val, err := in.evaluate(d.init)
+	if err != nil {
+		return err
+	}
+	tuple, ok := val.(Tuple)
+	if !ok {
+		tuple = Tuple{val}
+	}
+	if len(tuple) != len(d.names) {
+		return RuntimeError{
+			tkn: d.names[0],
+			msg: fmt.Sprintf("Expected %d values to destructure, got %d.", len(d.names), len(tuple)),
+		}
+	}
+	for i, name := range d.names {
+		switch {
+		case d.constant:
+			err = in.env.DefineConst(name, tuple[i])
+		case d.declare:
+			err = in.env.Define(name.lexeme, tuple[i])
+		default:
+			err = in.env.Assign(name, tuple[i])
+		}
 		if err != nil {
-			in.resultVal = err
-			in.env = in.env.enclosing
-			return
+			if _, ok := err.(RuntimeError); !ok {
+				err = RuntimeError{tkn: name, msg: err.Error()}
+			}
+			return err
 		}
 	}
-	// pop the innermost scope off of the "scope chain"
-	in.env = in.env.enclosing
+	return nil
 }
 
 // VisitVarStmt inserts a variable binding into the current environment
-func (in *Interpreter) VisitVarStmt(v *VarStmt) {
+func (in *Interpreter) VisitVarStmt(v *VarStmt) error {
 	var val interface{}
 	var err error
 	if v.init != nil {
 		val, err = in.evaluate(v.init)
 		if err != nil {
-			in.resultVal = RuntimeError{
-				tkn: *v.name,
-				msg: "Can't evaluate variable init expression.",
-			}
-			return
+			return err
 		}
+	} else if in.strict {
+		val = uninitialized{}
 	}
 	// add new binding to current environment
-	in.env.Define(v.name.lexeme, val)
+	if v.constant {
+		err = in.env.DefineConst(*v.name, val)
+	} else {
+		err = in.env.Define(v.name.lexeme, val)
+	}
+	if err != nil {
+		return RuntimeError{tkn: *v.name, msg: err.Error()}
+	}
+	return nil
 }
 
 // VisitBinaryExpr interprets any given binary expression
-func (in *Interpreter) VisitBinaryExpr(b *BinaryExpr) {
+func (in *Interpreter) VisitBinaryExpr(b *BinaryExpr) (interface{}, error) {
 	// evaluate left and right operand expressions, passing errors up the call stack as needed
 	left, lerr := in.evaluate(b.left)
 	if lerr != nil {
-		in.resultVal = lerr
-		return
+		return nil, lerr
 	}
 	right, rerr := in.evaluate(b.right)
 	if rerr != nil {
-		in.resultVal = rerr
-		return
+		return nil, rerr
 	}
 	switch b.op.toktype {
-	case Greater:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd > rightd
-	case GreaterEqual:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd >= rightd
-	case Less:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd < rightd
-	case LessEqual:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd <= rightd
-	case Minus:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd - rightd
-	case Slash:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd / rightd
-	case Star:
-		in.checkNumberOperands(b.op, left, right)
-		if _, ok := in.resultVal.(error); ok {
-			return
-		}
-		leftd := left.(float64)
-		rightd := right.(float64)
-		in.resultVal = leftd * rightd
+	case Comma:
+		// both operands have been evaluated in order, the comma operator yields the last one
+		return right, nil
+	case InTok:
+		return valueOrError(in.membership(b.op, left, right))
+	case EqualEqual:
+		return in.isEqual(left, right), nil
+	case BangEqual:
+		return !in.isEqual(left, right), nil
+	case Greater, GreaterEqual, Less, LessEqual:
+		var cmp int
+		lStr, lStrOk := left.(string)
+		rStr, rStrOk := right.(string)
+		if lStrOk && rStrOk {
+			// strings are ordered lexicographically by their bytes (so by code point for UTF-8)
+			cmp = strings.Compare(lStr, rStr)
+		} else {
+			if err := in.checkNumberOperands(b.op, left, right); err != nil {
+				return nil, err
+			}
+			cmp = compareNumbers(left, right)
+		}
+		switch b.op.toktype {
+		case Greater:
+			return cmp == 1, nil
+		case GreaterEqual:
+			return cmp == 1 || cmp == 0, nil
+		case Less:
+			return cmp == -1, nil
+		}
+		return cmp == -1 || cmp == 0, nil
+	case Minus, Slash, Star, Percent:
+		if err := in.checkNumberOperands(b.op, left, right); err != nil {
+			return nil, err
+		}
+		return valueOrError(arithmetic(b.op, left, right, in.checkedInts))
 	case Plus:
-		// plus can be applied to both numbers (doubles) and strings
-		// this solution only looks at the type of the expression's left operand
-		leftd, lOk := left.(float64)
-		rightd, rOk := right.(float64)
-		leftstr, lStrOk := left.(string)
-		rightstr, rStrOk := right.(string)
+		// plus can be applied to both numbers and strings
+		// if only one side is a string the other side is stringified the same way print does it
+		_, lStrOk := left.(string)
+		_, rStrOk := right.(string)
 		switch {
-		case lOk && rOk:
-			in.resultVal = leftd + rightd
-		case lStrOk && rStrOk:
-			in.resultVal = leftstr + rightstr
-		default:
-			in.resultVal = RuntimeError{
-				tkn: b.op,
-				msg: "Addition operands must both be numbers or strings",
+		case isNumber(left) && isNumber(right):
+			return valueOrError(arithmetic(b.op, left, right, in.checkedInts))
+		case lStrOk && rStrOk, (lStrOk || rStrOk) && in.dialect == GloxDialect:
+			str := in.stringify(left) + in.stringify(right)
+			if in.maxString > 0 && len(str) > in.maxString {
+				return nil, RuntimeError{tkn: b.op, msg: fmt.Sprintf("Strings are limited to %d bytes.", in.maxString)}
 			}
+			return str, nil
+		}
+		return nil, RuntimeError{
+			tkn: b.op,
+			msg: in.dialect.message("Addition operands must be two numbers or include a string"),
 		}
 	}
 	// TODO: implement more binary operations
+	return nil, nil
+}
+
+// valueOrError splits what helpers like arithmetic() return, either a value or the RuntimeError computing it failed with
+func valueOrError(val interface{}) (interface{}, error) {
+	if err, ok := val.(RuntimeError); ok {
+		return nil, err
+	}
+	return val, nil
 }
 
 // isEqual checks whether two given values are equal.
@@ -402,46 +702,57 @@ func (in *Interpreter) isEqual(a, b interface{}) bool {
 	if a == nil {
 		return false
 	}
-	// same as Go's == for strings, booleans, and doubles (float64)
+	// integers and doubles with the same value are equal
+	if isNumber(a) && isNumber(b) {
+		return compareNumbers(a, b) == 0
+	}
+	// times are equal if they are the same instant, even in different timezones
+	if at, ok := a.(LoxTime); ok {
+		bt, ok := b.(LoxTime)
+		return ok && at.t.Equal(bt.t)
+	}
+	// sets are equal if they hold the same elements
+	if as, ok := a.(*LoxSet); ok {
+		bs, ok := b.(*LoxSet)
+		return ok && as.equal(bs)
+	}
+	// bytes are equal if they hold the same data
+	if ab, ok := a.(*LoxBytes); ok {
+		bb, ok := b.(*LoxBytes)
+		return ok && string(ab.b) == string(bb.b)
+	}
+	// same as Go's == for strings and booleans
 	return reflect.DeepEqual(a, b)
 }
 
 // VisitGrouping interprets any given Grouping expression
-func (in *Interpreter) VisitGrouping(g *Grouping) {
-	exp, err := in.evaluate(g.exp)
-	if err != nil {
-		in.resultVal = err
-		return
-	}
-	in.resultVal = exp
+func (in *Interpreter) VisitGrouping(g *Grouping) (interface{}, error) {
+	return in.evaluate(g.exp)
 }
 
 // VisitLiteral interprets any given Literal expression
-func (in *Interpreter) VisitLiteral(l *Literal) {
-	in.resultVal = l.val
+func (in *Interpreter) VisitLiteral(l *Literal) (interface{}, error) {
+	// the other implementations only have doubles
+	if i, ok := l.val.(int64); ok && in.dialect != GloxDialect {
+		return float64(i), nil
+	}
+	return l.val, nil
 }
 
 // VisitExprStmt interprets an expression-statement
-func (in *Interpreter) VisitExprStmt(estmt *ExprStmt) {
-	val, err := in.evaluate(estmt.exp)
-	if err != nil {
-		in.resultVal = RuntimeError{
-			msg: "Can't eval expr statement",
-		}
-	}
-	in.resultVal = val
+func (in *Interpreter) VisitExprStmt(estmt *ExprStmt) error {
+	_, err := in.evaluate(estmt.exp)
+	return err
 }
 
 // VisitPrintStmt interprets an print statement
-func (in *Interpreter) VisitPrintStmt(pstmt *PrintStmt) {
+func (in *Interpreter) VisitPrintStmt(pstmt *PrintStmt) error {
 	val, err := in.evaluate(pstmt.exp)
 	if err != nil {
-		in.resultVal = RuntimeError{
-			msg: "Can't eval print statement",
-		}
-		return
+		return err
 	}
-	fmt.Println(in.stringify(val))
+	fmt.Fprintln(in.out, in.stringify(val))
+	return nil
 }
 
 // isTruthy determines whether a given value will evaluate to true
@@ -458,45 +769,44 @@ func (in *Interpreter) isTruthy(val interface{}) bool {
 }
 
 // VisitUnary interprets any given Unary expression
-func (in *Interpreter) VisitUnary(u *Unary) {
+func (in *Interpreter) VisitUnary(u *Unary) (interface{}, error) {
 	right, err := in.evaluate(u.right)
 	if err != nil {
-		in.resultVal = err
-		return
+		return nil, err
+	}
+	if u.op.toktype == Bang {
+		return !in.isTruthy(right), nil
 	}
-	switch u.op.toktype {
-	case Minus:
-		in.checkNumberOperand(u.op, right)
-		if _, ok := in.resultVal.(error); ok {
-			// if result value if an error val, unwind
-			return
+	if err := in.checkNumberOperand(u.op, right); err != nil {
+		return nil, err
+	}
+	if i, ok := right.(int64); ok {
+		if in.checkedInts && i == math.MinInt64 {
+			return nil, RuntimeError{tkn: u.op, msg: fmt.Sprintf("Integer overflow in -(%d).", i)}
 		}
-		in.resultVal = -right.(float64)
-	case Bang:
-		in.resultVal = !in.isTruthy(right)
+		return -i, nil
 	}
+	return -right.(float64), nil
 }
 
-// checkNumberOperand sets the result value of the current expression when operand is NaN, otherwise NOP
-func (in *Interpreter) checkNumberOperand(op Token, operand interface{}) {
-	if _, ok := operand.(float64); ok {
-		return
+// checkNumberOperand returns the error of an operator applied to an operand that isn't a number, otherwise nil
+func (in *Interpreter) checkNumberOperand(op Token, operand interface{}) error {
+	if isNumber(operand) {
+		return nil
 	}
-	in.resultVal = RuntimeError{
+	return RuntimeError{
 		tkn: op,
-		msg: "operand must be a number",
+		msg: in.dialect.message("operand must be a number"),
 	}
 }
 
-// checkNumberOperands sets the result value of the current expression to an error value if either operand is NaN, otherwise NOP
-func (in *Interpreter) checkNumberOperands(op Token, left, right interface{}) {
-	_, lok := left.(float64)
-	_, rok := right.(float64)
-	if lok && rok {
-		return
+// checkNumberOperands returns the error of an operator applied to operands that aren't both numbers, otherwise nil
+func (in *Interpreter) checkNumberOperands(op Token, left, right interface{}) error {
+	if isNumber(left) && isNumber(right) {
+		return nil
 	}
-	in.resultVal = RuntimeError{
+	return RuntimeError{
 		tkn: op,
-		msg: "both operands must be numbers",
+		msg: in.dialect.message("both operands must be numbers"),
 	}
 }
@@ -1,8 +1,9 @@
 package main
 
 import (
-	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
 // A Lexer is an interface that can be scanned into a slice of tokens
@@ -17,6 +18,7 @@ type LexScanner struct {
 	source               string
 	start, current, line int
 	tokens               []*Token
+	reporter             *ErrorReporter
 }
 
 // ScanTokens gets a list of tokens from a Lex object
@@ -26,33 +28,40 @@ func (l *LexScanner) ScanTokens() []*Token {
 		l.scanToken()
 	}
 	// add EOF token
+	l.start = l.current
 	l.addToken(EOF, nil)
 	return l.tokens
 }
 
+// reservedWords maps the keywords to their token types, the grammar command generates syntax definitions from it
+var reservedWords = map[string]TokenType{
+	"and":    And,
+	"class":  Class,
+	"const":  ConstTok,
+	"else":   Else,
+	"false":  FalseTok,
+	"for":    ForTok,
+	"fun":    Fun,
+	"if":     IfTok,
+	"import": ImportTok,
+	"in":     InTok,
+	"is":     IsTok,
+	"nil":    NilTok,
+	"or":     OrTok,
+	"print":  PrintTok,
+	"return": ReturnTok,
+	"super":  Super,
+	"this":   ThisTok,
+	"true":   TrueTok,
+	"var":    VarTok,
+	"while":  WhileTok,
+	"yield":  YieldTok,
+}
+
 // NewLexScanner is a simple factory function that
 // creates LexScanner objects and returns pointers to them
 func NewLexScanner(inputStr string) *LexScanner {
-	// initialize the reserved word map
-	m := map[string]TokenType{
-		"and":    And,
-		"class":  Class,
-		"else":   Else,
-		"false":  FalseTok,
-		"for":    ForTok,
-		"fun":    Fun,
-		"if":     IfTok,
-		"nil":    NilTok,
-		"or":     OrTok,
-		"print":  PrintTok,
-		"return": ReturnTok,
-		"super":  Super,
-		"this":   ThisTok,
-		"true":   TrueTok,
-		"var":    VarTok,
-		"while":  WhileTok,
-	}
-	return &LexScanner{line: 1, source: inputStr, reserved: m}
+	return &LexScanner{line: 1, source: inputStr, reserved: reservedWords, reporter: reporter}
 }
 
 // Has our scanner class reached the end of source string ?
@@ -73,10 +82,16 @@ func (l *LexScanner) addToken(tok TokenType, lit interface{}) {
 	if tok == EOF {
 		text = "END OF FILE"
 	}
-	newtok := &Token{toktype: tok, literal: lit, lexeme: text, line: l.line}
+	// columns count from the last newline before the start of the lexeme
+	col := l.start - strings.LastIndexByte(l.source[:l.start], '\n')
+	newtok := &Token{toktype: tok, literal: lit, lexeme: text, line: l.line, col: col}
 	l.tokens = append(l.tokens, newtok)
 }
 
+// operators lists the lexemes of the operator tokens scanToken() produces, longest first.
+// Keep it in sync with the switch below, the grammar command highlights these.
+var operators = []string{"==", "!=", "<=", ">=", "??", "|>", "+", "-", "*", "/", "%", "!", "=", "<", ">", "@"}
+
 // the "big switch" scans individual tokens. the string
 // contained at source[start:current] is the current token
 func (l *LexScanner) scanToken() {
@@ -103,6 +118,14 @@ func (l *LexScanner) scanToken() {
 		l.addToken(Semicolon, nil)
 	case '*':
 		l.addToken(Star, nil)
+	case '%':
+		l.addToken(Percent, nil)
+	case '[':
+		l.addToken(LeftBracket, nil)
+	case ']':
+		l.addToken(RightBracket, nil)
+	case ':':
+		l.addToken(Colon, nil)
 	case '!':
 		tmp := Bang
 		// lookahead by one character
@@ -137,8 +160,29 @@ func (l *LexScanner) scanToken() {
 		} else {
 			l.addToken(Slash, nil)
 		}
+	case '?':
+		if l.match('?') {
+			l.addToken(QuestionQuestion, nil)
+		} else {
+			l.reporter.report(l.line, "", "Unexpected character.")
+		}
+	case '@':
+		l.addToken(At, nil)
+	case '|':
+		if l.match('>') {
+			l.addToken(PipeGreater, nil)
+		} else {
+			l.reporter.report(l.line, "", "Unexpected character.")
+		}
 	case '"':
-		l.string()
+		if l.peek() == '"' && l.peekNext() == '"' {
+			l.current += 2
+			l.tripleString()
+		} else {
+			l.string()
+		}
+	case '`':
+		l.rawString()
 	case '\n':
 		l.line++
 	case ' ':
@@ -150,7 +194,7 @@ func (l *LexScanner) scanToken() {
 		} else if isAlphaNumeric(c) {
 			l.identifier()
 		} else {
-			fmt.Println(l.line, "Unexpected character.")
+			l.reporter.report(l.line, "", "Unexpected character.")
 		}
 	}
 }
@@ -179,22 +223,75 @@ func isAlphaNumeric(c byte) bool {
 	return isAlpha(c) || isADigit(c)
 }
 
-// number() scans a number from the input stream
+// number() scans a number from the input stream, literals without a decimal point are integers (int64).
+// Digits may be grouped with underscores ('1_000_000', '0xFF_FF'), they are dropped before parsing.
 func (l *LexScanner) number() {
-	for isADigit(l.peek()) {
+	if l.source[l.start] == '0' && (l.peek() == 'x' || l.peek() == 'X') {
+		l.hexNumber()
+		return
+	}
+	for isADigit(l.peek()) || l.peek() == '_' {
 		l.advance()
 	}
 	if l.peek() == '.' && isADigit(l.peekNext()) {
 		l.advance()
-		for isADigit(l.peek()) {
+		for isADigit(l.peek()) || l.peek() == '_' {
 			l.advance()
 		}
+		text, ok := l.digits(isADigit)
+		f, err := strconv.ParseFloat(text, 64)
+		if ok && err != nil {
+			l.reporter.report(l.line, "", "Error reading floating point value.")
+		}
+		l.addToken(Number, f)
+		return
+	}
+	text, ok := l.digits(isADigit)
+	i, err := strconv.ParseInt(text, 10, 64)
+	if ok && err != nil {
+		l.reporter.report(l.line, "", "Integer literal out of range.")
+	}
+	l.addToken(Number, i)
+}
+
+// hexNumber() scans an integer written in hexadecimal, the '0' of its '0x' prefix has been consumed
+func (l *LexScanner) hexNumber() {
+	l.advance()
+	for isHexDigit(l.peek()) || l.peek() == '_' {
+		l.advance()
+	}
+	text, ok := l.digits(isHexDigit)
+	if ok && len(text) == 2 {
+		l.reporter.report(l.line, "", "Expect hexadecimal digits after '0x'.")
+		ok = false
+	}
+	var i int64
+	if ok {
+		u, err := strconv.ParseUint(text[2:], 16, 64)
+		if err != nil || u > math.MaxInt64 {
+			l.reporter.report(l.line, "", "Integer literal out of range.")
+		}
+		i = int64(u)
 	}
-	f, err := strconv.ParseFloat(l.source[l.start:l.current], 64)
-	if err != nil {
-		fmt.Println(l.line, "Error reading floating point value")
+	l.addToken(Number, i)
+}
+
+// digits returns the number literal being scanned without its separators. ok is false if a separator
+// isn't between two digits, which has been reported.
+func (l *LexScanner) digits(isDigit func(byte) bool) (string, bool) {
+	text := l.source[l.start:l.current]
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && (!isDigit(text[i-1]) || i+1 == len(text) || !isDigit(text[i+1])) {
+			l.reporter.report(l.line, "", "Numeric separator '_' must be between two digits.")
+			return "", false
+		}
 	}
-	l.addToken(Number, f)
+	return strings.Replace(text, "_", "", -1), true
+}
+
+// isHexDigit
+func isHexDigit(c byte) bool {
+	return isADigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
 
 // isADigit
@@ -213,7 +310,8 @@ func (l *LexScanner) string() {
 		l.advance()
 	}
 	if l.isAtEnd() {
-		fmt.Println(l.line, "Unterminated String.")
+		l.reporter.report(l.line, "", "Unterminated string.")
+		return
 	}
 	l.advance()
 	// trim quotes + create token
@@ -221,6 +319,72 @@ func (l *LexScanner) string() {
 	l.addToken(StringTok, val)
 }
 
+// rawString() scans a backtick-delimited string. Everything up to the closing backtick is taken
+// literally, including quotes, backslashes and newlines, so there's no way to embed a backtick.
+func (l *LexScanner) rawString() {
+	for l.peek() != '`' && !l.isAtEnd() {
+		if l.peek() == '\n' {
+			l.line++
+		}
+		l.advance()
+	}
+	if l.isAtEnd() {
+		l.reporter.report(l.line, "", "Unterminated raw string.")
+		return
+	}
+	l.advance()
+	l.addToken(StringTok, l.source[l.start+1:l.current-1])
+}
+
+// tripleString() scans a """-delimited string, which may contain newlines and single quotes.
+// If the text starts on the line after the opening quotes it is a block: that first newline, the
+// line holding the closing quotes and the indentation common to all lines are removed.
+func (l *LexScanner) tripleString() {
+	end := strings.Index(l.source[l.current:], `"""`)
+	if end < 0 {
+		l.line += strings.Count(l.source[l.current:], "\n")
+		l.current = len(l.source)
+		l.reporter.report(l.line, "", "Unterminated string.")
+		return
+	}
+	text := l.source[l.current : l.current+end]
+	l.current += end + 3
+	lines := strings.Count(text, "\n")
+	if strings.HasPrefix(strings.TrimLeft(text, " \t\r"), "\n") {
+		text = dedent(text)
+	}
+	// the token belongs to the line it starts on
+	l.addToken(StringTok, text)
+	l.line += lines
+}
+
+// dedent turns the text of a block string into its value, see tripleString()
+func dedent(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	lines = lines[1:]
+	if last := lines[len(lines)-1]; strings.TrimSpace(last) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		} else if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // match is a simple lookahead method that consumes
 // the next character iff it's the character we're expecting
 // thanks to Bob Nystrom for the code !
@@ -29,7 +29,7 @@ func compareTokenSlices(a, b []*Token) bool {
 
 // Test the ouput of an empty lexer
 func TestEmptyScanToken(t *testing.T) {
-	expected := []*Token{&Token{toktype: EOF, line: 1, lexeme: "END OF FILE"}}
+	expected := []*Token{&Token{toktype: EOF, line: 1, col: 1, lexeme: "END OF FILE"}}
 	emptyLex := NewLexScanner("")
 	emptyLex.ScanTokens()
 	if !compareTokenSlices(emptyLex.tokens, expected) {
@@ -40,15 +40,46 @@ func TestEmptyScanToken(t *testing.T) {
 // Test the ouput of an empty lexer
 func TestArithScanToken(t *testing.T) {
 	expected := []*Token{
-		// NUMBER tokens literals are *always* floating point values
-		&Token{toktype: Number, line: 1, lexeme: "2", literal: 2.0},
-		&Token{toktype: Plus, line: 1, lexeme: "+"},
-		&Token{toktype: Number, line: 1, lexeme: "4", literal: 4.0},
-		&Token{toktype: EOF, line: 1, lexeme: "END OF FILE"},
+		// NUMBER token literals are integers unless they have a decimal point
+		&Token{toktype: Number, line: 1, col: 1, lexeme: "2", literal: int64(2)},
+		&Token{toktype: Plus, line: 1, col: 3, lexeme: "+"},
+		&Token{toktype: Number, line: 1, col: 5, lexeme: "4.5", literal: 4.5},
+		&Token{toktype: EOF, line: 1, col: 8, lexeme: "END OF FILE"},
 	}
-	arithLex := NewLexScanner("2 + 4")
+	arithLex := NewLexScanner("2 + 4.5")
 	arithLex.ScanTokens()
 	if !compareTokenSlices(arithLex.tokens, expected) {
 		t.Errorf("Arithmetic lexer scanned incorrect tokens.\nWanted: %v\nGot: %v\n", expected, arithLex.tokens)
 	}
 }
+
+// Test that columns restart after every newline
+func TestColumnScanToken(t *testing.T) {
+	lex := NewLexScanner("var a;\n  a = 1;")
+	lex.ScanTokens()
+	tok := lex.tokens[3]
+	if tok.lexeme != "a" || tok.line != 2 || tok.col != 3 {
+		t.Errorf("Wrong position for token %v. Wanted: line 2, col 3\n", tok)
+	}
+}
+
+// Test that raw strings keep quotes and backslashes as they are
+func TestRawStringScanToken(t *testing.T) {
+	lex := NewLexScanner("`C:\\dir \"q\"`")
+	lex.ScanTokens()
+	tok := lex.tokens[0]
+	if tok.toktype != StringTok || tok.literal != `C:\dir "q"` {
+		t.Errorf("Raw string scanned incorrectly: %v\n", tok)
+	}
+}
+
+// Test that digit separators are dropped from number literals and hex literals are integers
+func TestNumberSeparatorScanToken(t *testing.T) {
+	lex := NewLexScanner("1_000_000 0xFF_FF 2_0.5")
+	lex.ScanTokens()
+	for i, want := range []interface{}{int64(1000000), int64(65535), 20.5} {
+		if tok := lex.tokens[i]; tok.toktype != Number || tok.literal != want {
+			t.Errorf("Number scanned incorrectly: %v, wanted %v\n", tok, want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warning is a non-fatal diagnostic produced by one of the static analysis passes run by 'glox vet'
+type Warning struct {
+	tkn Token
+	msg string
+	// fix resolves the warning automatically with 'glox vet -fix', nil if there is no safe fix
+	fix *Fix
+}
+
+// String formats a warning the same way report() formats errors
+func (w Warning) String() string {
+	return w.format("")
+}
+
+// format is String() with the message in the given language
+func (w Warning) format(lang string) string {
+	return w.formatAs("Warning", lang)
+}
+
+// formatAs formats the warning reported as the given kind, 'glox check' can report warnings as errors.
+// Warnings outside the catalog are shown without an id.
+func (w Warning) formatAs(kind, lang string) string {
+	id, msg := localize(w.msg, lang)
+	where := "at '" + w.tkn.lexeme + "'"
+	if w.tkn.toktype == EOF {
+		where = "at end"
+	}
+	if id != "" {
+		kind += " [" + id + "]"
+	}
+	return fmt.Sprintf("[line %d] %v %v: %v", w.tkn.line, kind, where, msg)
+}
+
+// lintFloatEquality flags '==' and '!=' comparisons between two computed numbers.
+// Lox numbers are doubles, so `0.1 + 0.2 == x` style checks are a classic source of flaky scripts.
+func lintFloatEquality(stmts []Stmt) []Warning {
+	numeric := numericVariables(stmts)
+	warnings := make([]Warning, 0)
+	Inspect(stmts, func(node interface{}) bool {
+		b, ok := node.(*BinaryExpr)
+		if !ok || (b.op.toktype != EqualEqual && b.op.toktype != BangEqual) {
+			return true
+		}
+		if isLiteralExpr(b.left) || isLiteralExpr(b.right) {
+			return true
+		}
+		if isNumericExpr(b.left, numeric) && isNumericExpr(b.right, numeric) {
+			warnings = append(warnings, Warning{
+				tkn: b.op,
+				msg: "Exact comparison of computed numbers; consider approxEqual(a, b, eps).",
+			})
+		}
+		return true
+	})
+	return warnings
+}
+
+// numericVariables guesses which variable names only ever hold numbers by looking at every
+// initializer and assignment to them. The guess ignores scoping, which is good enough for a lint.
+func numericVariables(stmts []Stmt) map[string]bool {
+	numeric := make(map[string]bool)
+	// start optimistic: every variable is a number until one of its definitions says otherwise
+	Inspect(stmts, func(node interface{}) bool {
+		if v, ok := node.(*VarStmt); ok {
+			numeric[v.name.lexeme] = true
+		}
+		return true
+	})
+	// keep demoting until nothing changes, a variable may be initialized from one demoted later on
+	for changed := true; changed; {
+		changed = false
+		demote := func(name string, val Expr) {
+			if numeric[name] && (val == nil || !isNumericExpr(val, numeric)) {
+				numeric[name] = false
+				changed = true
+			}
+		}
+		Inspect(stmts, func(node interface{}) bool {
+			switch n := node.(type) {
+			case *VarStmt:
+				demote(n.name.lexeme, n.init)
+			case *AssignExpr:
+				demote(n.name.lexeme, n.val)
+			case *DestructureStmt:
+				// the values of a tuple aren't tracked
+				for _, name := range n.names {
+					demote(name.lexeme, nil)
+				}
+			case *FunctionStmt:
+				demote(n.name.lexeme, nil)
+				for _, param := range n.params {
+					demote(param.lexeme, nil)
+				}
+			}
+			return true
+		})
+	}
+	return numeric
+}
+
+// isLiteralExpr reports whether e is a (possibly parenthesized) literal value
+func isLiteralExpr(e Expr) bool {
+	switch exp := e.(type) {
+	case *Literal:
+		return true
+	case *Grouping:
+		return isLiteralExpr(exp.exp)
+	}
+	return false
+}
+
+// isNumericExpr reports whether e looks like it can only ever evaluate to a number
+func isNumericExpr(e Expr, numeric map[string]bool) bool {
+	switch exp := e.(type) {
+	case *Literal:
+		return isNumber(exp.val)
+	case *Variable:
+		return numeric[exp.name.lexeme]
+	case *Grouping:
+		return isNumericExpr(exp.exp, numeric)
+	case *Unary:
+		return exp.op.toktype == Minus
+	case *BinaryExpr:
+		switch exp.op.toktype {
+		case Minus, Star, Slash:
+			return true
+		case Comma:
+			return isNumericExpr(exp.right, numeric)
+		case Plus:
+			// '+' is also string concatenation
+			return isNumericExpr(exp.left, numeric) && isNumericExpr(exp.right, numeric)
+		}
+	}
+	return false
+}
+
+// lintDeadCode reports functions and global variables that are never used by code reachable from the
+// top level of the script. Functions only called by other dead functions are dead too.
+func lintDeadCode(table *SymbolTable) []Warning {
+	// a nil function stands for the top-level code, which always runs
+	live := map[*Symbol]bool{nil: true}
+	read := make(map[*Symbol]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, ref := range table.refs {
+			if ref.write || !live[ref.from] || read[ref.target] {
+				continue
+			}
+			read[ref.target] = true
+			if ref.target.kind == FunSymbol {
+				live[ref.target] = true
+			}
+			changed = true
+		}
+	}
+	warnings := make([]Warning, 0)
+	for _, sym := range table.symbols {
+		switch {
+		case sym.kind == FunSymbol && !live[sym] && live[sym.fun]:
+			// functions nested inside a dead function aren't worth reporting separately
+			warnings = append(warnings, Warning{
+				tkn: sym.name,
+				msg: "Function '" + sym.name.lexeme + "' is never used.",
+			})
+		case sym.kind == VarSymbol && sym.global && !read[sym]:
+			warnings = append(warnings, Warning{
+				tkn: sym.name,
+				msg: "Global variable '" + sym.name.lexeme + "' is never read.",
+				fix: removeUnusedVar(sym),
+			})
+		}
+	}
+	return warnings
+}
+
+// lintUnused reports local variables and parameters that are never read and local functions that are never
+// used, wherever they are. Parameters whose name starts with '_' are meant to be unused and are skipped, so are
+// the variables of for-in loops and decorated functions.
+func lintUnused(table *SymbolTable) []Warning {
+	warnings := make([]Warning, 0)
+	for _, sym := range table.symbols {
+		if sym.global {
+			continue
+		}
+		read := false
+		for _, ref := range sym.refs {
+			read = read || !ref.write
+		}
+		if read {
+			continue
+		}
+		switch sym.kind {
+		case VarSymbol:
+			if _, loopVar := sym.decl.(*ForInStmt); !loopVar {
+				warnings = append(warnings, Warning{
+					tkn: sym.name,
+					msg: "Local variable '" + sym.name.lexeme + "' is never read.",
+					fix: removeUnusedVar(sym),
+				})
+			}
+		case ParamSymbol:
+			if !strings.HasPrefix(sym.name.lexeme, "_") {
+				warnings = append(warnings, Warning{tkn: sym.name, msg: "Parameter '" + sym.name.lexeme + "' is never read."})
+			}
+		case FunSymbol:
+			// a decorator can keep the function somewhere else, e.g. register it as a handler
+			if fn, ok := sym.decl.(*FunctionStmt); !ok || len(fn.decorators) == 0 {
+				warnings = append(warnings, Warning{tkn: sym.name, msg: "Function '" + sym.name.lexeme + "' is never used."})
+			}
+		}
+	}
+	return warnings
+}
+
+// lintShadowing reports local declarations that hide a variable, parameter or function of the same name
+// declared in an enclosing scope, a closure then silently captures the inner one
+func lintShadowing(table *SymbolTable) []Warning {
+	warnings := make([]Warning, 0)
+	for _, sym := range table.symbols {
+		if sym.shadows != nil {
+			warnings = append(warnings, Warning{
+				tkn: sym.name,
+				msg: fmt.Sprintf("Declaration of '%v' shadows the one on line %d.", sym.name.lexeme, sym.shadows.name.line),
+			})
+		}
+	}
+	return warnings
+}
+
+// lintArity reports calls of functions declared in the script with the wrong number of arguments, they
+// would fail when they run. Only functions that are never reassigned or decorated are checked.
+func lintArity(table *SymbolTable) []Warning {
+	warnings := make([]Warning, 0)
+	for _, site := range table.calls {
+		if params, ok := knownArity(table, site, nil); ok && params != site.args {
+			warnings = append(warnings, Warning{
+				tkn: site.callee.tkn,
+				msg: fmt.Sprintf("Call of '%v' with the wrong number of arguments: %d given, %d expected.", site.callee.tkn.lexeme, site.args, params),
+			})
+		}
+	}
+	return warnings
+}
+
+// uniqueWarnings drops the repeated warnings of passes that overlap, e.g. -dead-code and -unused both
+// report local functions that are never called
+func uniqueWarnings(warnings []Warning) []Warning {
+	seen := make(map[string]bool)
+	unique := make([]Warning, 0, len(warnings))
+	for _, w := range warnings {
+		if key := w.String(); !seen[key] {
+			seen[key] = true
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
+
+// removeUnusedVar returns a fix deleting the declaration of a variable that is never mentioned again.
+// Variables that are still assigned to, or whose initializer might have side effects, are left alone.
+func removeUnusedVar(sym *Symbol) *Fix {
+	v, ok := sym.decl.(*VarStmt)
+	if !ok || len(sym.refs) > 0 || v.keyword.lexeme == "" || hasSideEffects(v.init) {
+		return nil
+	}
+	return &Fix{
+		description: "remove unused variable '" + sym.name.lexeme + "'",
+		edits: []TextEdit{{
+			start: Position{v.keyword.line, v.keyword.col},
+			end:   Position{v.end.line, v.end.col + len(v.end.lexeme)},
+		}},
+	}
+}
+
+// hasSideEffects reports whether evaluating e might do more than compute a value, i.e. it calls or assigns
+func hasSideEffects(e Expr) bool {
+	effects := false
+	Inspect([]Stmt{&ExprStmt{exp: e}}, func(node interface{}) bool {
+		switch node.(type) {
+		case *CallExpr, *AssignExpr:
+			effects = true
+		}
+		return !effects
+	})
+	return effects
+}
+
+// lintAssignCondition flags an assignment used directly as an if or while condition, which is almost
+// always a typo for '=='. Wrapping the assignment in an extra pair of parentheses silences the warning.
+func lintAssignCondition(stmts []Stmt) []Warning {
+	warnings := make([]Warning, 0)
+	check := func(cond Expr) {
+		a, ok := cond.(*AssignExpr)
+		if !ok || a.op.lexeme == "" {
+			return
+		}
+		pos := Position{a.op.line, a.op.col}
+		warnings = append(warnings, Warning{
+			tkn: a.op,
+			msg: "Assignment used as a condition; did you mean '=='?",
+			fix: &Fix{
+				description: "replace '=' with '=='",
+				edits:       []TextEdit{{start: pos, end: Position{pos.line, pos.col + 1}, text: "=="}},
+			},
+		})
+	}
+	Inspect(stmts, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *IfStmt:
+			check(n.exp)
+		case *WhileStmt:
+			check(n.condition)
+		}
+		return true
+	})
+	return warnings
+}
@@ -2,20 +2,37 @@ package main
 
 // LoxFunction is a wrapper around a FunctionStmt AST node that implements the LoxCaller interface.
 // In other words, LoxFunction keeps the logic related to binding arguments and parameters out of the parser.
-type LoxFunction FunctionStmt
+type LoxFunction struct {
+	*FunctionStmt
+	// closure is the environment the function was declared in, its body can use the variables in scope there
+	closure *Environment
+}
 
 // the call method allows a FunctionStmt body to be executed in a correctly configured environment.
 func (l *LoxFunction) call(in *Interpreter, args []interface{}) interface{} {
-	// create new environment from interpreter's global environment
-	env := NewEnvironment(in.globals)
+	if l.generator {
+		return newGenerator(in, l, args)
+	}
+	// a yield inside this function doesn't belong to a generator that called it
+	generator := in.generator
+	in.generator = nil
+	defer func() { in.generator = generator }()
+	// create new environment enclosed by the one the function was declared in
+	env := NewEnvironment(l.closure)
 	// create mapping between parameters and arguments to function
 	for i, param := range l.params {
 		env.Define(param.lexeme, args[i])
 	}
 	// execute function body inside newly-created environment
-	in.executeBlock(l.body, env)
-	if returnVal, ok := in.resultVal.(*ReturnError); ok {
-		return returnVal.val
+	switch res := in.executeBlock(l.body, env).(type) {
+	case *ReturnError:
+		return res.val
+	case RuntimeError:
+		// hand runtime errors back to the caller so they aren't swallowed
+		return res
+	case coroutineClosed:
+		// keep unwinding the function of a closed coroutine
+		return res
 	}
 	// no return statement was encountered while executing function body, return val is assumed nil
 	return nil
@@ -28,5 +45,8 @@ func (l *LoxFunction) arity() int {
 
 // simple String() representation
 func (l *LoxFunction) String() string {
+	if l.generator {
+		return "<fn* " + l.name.lexeme + ">"
+	}
 	return "<fn " + l.name.lexeme + ">"
 }
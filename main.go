@@ -7,55 +7,96 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
-)
-
-const (
-	version = "v0.0.1"
+	"path/filepath"
+	"strings"
 )
 
 // global var definitions
 var (
-	hasError, hasRuntimeError bool
-	interpreter               *Interpreter
+	interpreter *Interpreter
+	// verbose makes the REPL show the value of expression statements, toggled by ':verbose'
+	verbose     bool
+	checkedInts = flag.Bool("checked-int", false, "make integer overflow a runtime error instead of wrapping around")
+	showVersion = flag.Bool("version", false, "print version, language features and build information, then exit")
+	unbuffered  = flag.Bool("unbuffered", false, "write the output of print immediately instead of buffering it")
+	dialectName = flag.String("dialect", "glox", "behave like another Lox implementation where they differ: glox, jlox or clox")
+	noPrelude   = flag.Bool("no-prelude", false, "don't load the prelude, the helper functions written in Lox")
+	langName    = flag.String("lang", "en", "language of error messages and warnings: en, de or es")
+	optimize    = flag.Bool("O", false, "fold constant expressions like 1 + 2 * 3 before running")
+	typeCheck   = flag.Bool("check", false, "type check the annotated code before running, a mismatch keeps the script from running")
+	strict      = flag.Bool("strict", false, "forbid assigning undeclared names, reading uninitialized variables and calling known functions with the wrong number of arguments")
+	warnUnused  = flag.Bool("warn-unused", false, "report unused locals, parameters and local functions on stderr before running")
+	crashReport = flag.String("crash-report", "", "on an internal interpreter error, write a crash report bundle into this directory")
 )
 
+// commands maps subcommand names to their entry points, each receives the remaining command line args
+var commands = map[string]func(args []string){
+	"vet":       runVet,
+	"refs":      runRefs,
+	"outline":   runOutline,
+	"callgraph": runCallGraph,
+	"metrics":   runMetrics,
+	"test":      runTest,
+	"mutate":    runMutate,
+	"fmt":       runFmt,
+	"schedule":  runSchedule,
+	"notebook":  runNotebook,
+	"grammar":   runGrammar,
+	"explain":   runExplain,
+	"bundle":    runBundle,
+	"pack":      runPack,
+	"check":     runCheck,
+	"complete":  runComplete,
+	"signature": runSignature,
+	"hover":     runHover,
+	"inlay":     runInlay,
+}
+
+// newMainInterpreter creates the interpreter used for script files and the REPL, configured by the command line flags
+// and the project's configuration file
+func newMainInterpreter() *Interpreter {
+	in := NewInterpreter()
+	in.checkedInts = *checkedInts
+	in.optimize = *optimize
+	in.strict = *strict
+	if !flagSet("checked-int") {
+		in.checkedInts = project.bool("language", "checked-int")
+	}
+	name := *dialectName
+	if !flagSet("dialect") {
+		name = project.string("language", "dialect", name)
+	}
+	// the flag was validated by main() and the configuration by FindProject()
+	in.dialect, _ = ParseDialect(name)
+	in.reporter.dialect = in.dialect
+	in.searchPath = project.searchPath()
+	project.sandbox(in)
+	if !*unbuffered {
+		// Interpret() and flush() write the buffer out, saving a syscall for every print
+		in.out = bufio.NewWriterSize(os.Stdout, 64*1024)
+	}
+	return in
+}
+
 // Run a given string of code input could be entire script or a single line
 func run(script string) {
-	lexer := NewLexScanner(script)
-	parser := NewParser(lexer)
 	// Optional pretty printing class. printer := &ASTPrinter{}
 	// start the interpreter (with a clean environment) if not running already
 	if interpreter == nil {
-		interpreter = NewInterpreter()
+		interpreter = newMainInterpreter()
 	}
-	if hasError {
+	if verbose {
+		if val, ok := interpreter.evalSnippet(script); ok {
+			fmt.Println(inspectValue(val, defaultInspectDepth, make(map[interface{}]bool)))
+		}
 		return
 	}
-	interpreter.Interpret(parser.Parse())
-}
-
-// errorTok prints out the contents and location of the token that caused the parser to panic
-func errorTok(tok Token, msg string) {
-	if tok.toktype == EOF {
-		report(tok.line, "at end", msg)
-	} else {
-		report(tok.line, "at '"+tok.lexeme+"'", msg)
-	}
-}
-
-// runtimeError reports an err that occurs at runtime
-func runtimeError(e RuntimeError) {
-	fmt.Printf("%s [line %d]\n", e.msg, e.tkn.line)
-	hasRuntimeError = true
-}
-
-// Report an error at a given line number
-func report(line int, where, msg string) {
-	fmt.Printf("[line %d] Error %v: %v\n", line, where, msg)
-	hasError = true
+	interpreter.runSnippet(script)
 }
 
 // Read a given lox file at 'path' into a string and execute it
@@ -64,26 +105,63 @@ func runFile(path string) {
 	if err != nil {
 		fmt.Printf("Can't open file at [%v].\n", path)
 	}
-	fstring := string(contents)
+	runSource(path, string(contents))
+}
+
+// runSource executes a whole script read from path, exiting with an error status if it fails
+func runSource(path, fstring string) {
+	if *crashReport != "" {
+		defer recoverCrash(*crashReport, path, fstring)
+	}
+	interpreter = newMainInterpreter()
+	interpreter.dir = filepath.Dir(path)
+	if *warnUnused {
+		warnUnusedIn(path, fstring)
+	}
+	warnArityIn(path, fstring)
+	if *typeCheck {
+		typeCheckSource(fstring)
+	}
+	// don't lose buffered output if the interpreter panics
+	defer interpreter.flush()
 	// execute the resulting string
 	run(fstring)
 	// did we find an error along the way
-	if hasError {
+	if reporter.hadError {
 		os.Exit(65)
 	}
-	if hasRuntimeError {
+	if reporter.hadRuntimeError {
 		os.Exit(70)
 	}
 }
 
-// Trim the last 'num' character from 'str'
-func trimSuffix(str string, num int) string {
-	return str[:len(str)-num]
+// parseFile reads, parses and resolves the lox file at 'path' without running it.
+// Used by the subcommands that only analyse a script, exits if the script can't be parsed.
+func parseFile(path string) []Stmt {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	stmts := parse(string(contents), reporter)
+	// static errors, like reading a variable in its own initializer, keep a script from running as well
+	if reporter.hadError || !resolve(stmts, make(map[Expr]int), reporter) {
+		os.Exit(65)
+	}
+	return stmts
+}
+
+// printJSON pretty-prints v as JSON for the subcommands that offer machine-readable output
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
 }
 
 // simple REPL implementation, input is executed line-by-line
 func runPrompt() {
-	fmt.Println("Hey. Lox Interpreter", version, "(type 'exit' to leave)")
+	fmt.Println("Hey. Lox Interpreter", Version, "(type 'exit' to leave)")
 	r := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Print("> ")
@@ -91,25 +169,148 @@ func runPrompt() {
 		if err != nil {
 			fmt.Println("Error reading line.")
 		}
-		// remove newline '\r\n' (windows) from input
-		line = trimSuffix(line, 2)
+		// remove newline '\r\n' (windows) or '\n' from input
+		line = strings.TrimRight(line, "\r\n")
 		if line == "exit" {
 			fmt.Println("Bye bye.")
 			break
 		}
+		if line == ":verbose" {
+			verbose = !verbose
+			fmt.Println("verbose mode", map[bool]string{true: "on", false: "off"}[verbose])
+			continue
+		}
+		if strings.HasPrefix(line, ":browse ") {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			name := strings.TrimSpace(strings.TrimPrefix(line, ":browse "))
+			val, err := interpreter.Globals().Get(Token{lexeme: name})
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			browseValue(r, os.Stdout, name, val)
+			continue
+		}
+		if strings.HasPrefix(line, ":doc ") {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			name := strings.TrimSpace(strings.TrimPrefix(line, ":doc "))
+			val, err := interpreter.Globals().Get(Token{lexeme: name})
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			if doc, ok := docOf(val); ok {
+				fmt.Println(doc)
+			} else {
+				fmt.Println(name, "has no docstring")
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":complete ") {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			// candidates for the end of the line, the REPL's globals included
+			code := strings.TrimPrefix(line, ":complete ")
+			for _, c := range Complete(code, len(code), interpreter.Globals()) {
+				fmt.Printf("%v\t%v\n", c.Label, c.Kind)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":signature ") {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			// a hint for the call that is open at the end of the line
+			code := strings.TrimPrefix(line, ":signature ")
+			if sig := SignatureAt(code, len(code), interpreter.Globals()); sig != nil {
+				fmt.Println(sig)
+			} else {
+				fmt.Println("not in a call of a known function")
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":info ") {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			name := strings.TrimSpace(strings.TrimPrefix(line, ":info "))
+			val, err := interpreter.Globals().Get(Token{lexeme: name})
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(infoOf(interpreter, name, val))
+			continue
+		}
+		if strings.HasPrefix(line, ":reload ") {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			names, err := interpreter.Reload(strings.TrimSpace(strings.TrimPrefix(line, ":reload ")))
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println("reloaded", strings.Join(names, ", "))
+			continue
+		}
+		if line == ":heap" {
+			if interpreter == nil {
+				interpreter = newMainInterpreter()
+			}
+			TakeHeapSnapshot(interpreter).Print(os.Stdout)
+			continue
+		}
 		if line != "" {
 			run(line)
-			hasError = false // reset error flag in interactive mode
+			reporter.reset() // reset error flags in interactive mode
 		}
 	}
 }
 
 // Application entry point
 func main() {
-	// accept an input script
-	args := os.Args[1:]
+	// an executable made by 'glox pack' runs the script it carries instead of acting like glox
+	if exe, script, ok := packedScript(); ok {
+		runSource(exe, script)
+		return
+	}
+	// accept an input script, flags before it (or the subcommand) configure the interpreter
+	flag.Parse()
+	if *showVersion {
+		printVersion(os.Stdout)
+		return
+	}
+	if _, err := ParseDialect(*dialectName); err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	lang, err := ParseLanguage(*langName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	reporter.lang = lang
+	args := flag.Args()
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			cmd(args[1:])
+			return
+		}
+	}
+	// the REPL is configured by the project of the working directory
+	script := "."
+	if len(args) == 1 {
+		script = args[0]
+	}
+	project = loadProject(script)
 	if len(args) > 1 {
-		fmt.Println("usage: glox.exe [script]")
+		fmt.Println("usage: glox.exe [-version] [-checked-int] [-unbuffered] [-dialect name] [-lang code] [-no-prelude] [-crash-report dir] [script] | glox.exe [command] [args]")
 	} else if len(args) == 1 {
 		runFile(args[0])
 	} else {
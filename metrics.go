@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// FunctionMetrics holds the size and complexity numbers for a single function (or the top-level script)
+type FunctionMetrics struct {
+	Name       string `json:"name"`
+	Line       int    `json:"line"`
+	Params     int    `json:"params"`
+	Statements int    `json:"statements"`
+	MaxDepth   int    `json:"maxDepth"`
+	Complexity int    `json:"complexity"`
+}
+
+// Metrics computes the metrics of the top-level code of a script followed by every function in it.
+// Nested functions are measured on their own and don't count towards their enclosing function.
+func Metrics(stmts []Stmt) []*FunctionMetrics {
+	all := []*FunctionMetrics{measure(scriptNodeID, 1, 0, stmts)}
+	Inspect(stmts, func(node interface{}) bool {
+		if f, ok := node.(*FunctionStmt); ok {
+			all = append(all, measure(f.name.lexeme, f.name.line, len(f.params), f.body))
+		}
+		return true
+	})
+	return all
+}
+
+// measure computes the metrics of a function body
+func measure(name string, line, params int, body []Stmt) *FunctionMetrics {
+	m := &FunctionMetrics{Name: name, Line: line, Params: params, Complexity: 1}
+	Inspect(body, func(node interface{}) bool {
+		switch node.(type) {
+		case *FunctionStmt:
+			// the declaration is a statement of this function, its body is not
+			m.Statements++
+			return false
+		case *BlockStmt:
+			// blocks only group statements
+		case *IfStmt, *WhileStmt, *ForInStmt:
+			m.Statements++
+			m.Complexity++
+		case *LogicalExpr:
+			// 'and'/'or' short circuit, which adds a path through the function
+			m.Complexity++
+		case Stmt:
+			m.Statements++
+		}
+		return true
+	})
+	m.MaxDepth = nestingDepth(body)
+	return m
+}
+
+// nestingDepth returns how deeply branches and loops are nested inside the given statements.
+// Blocks don't count on their own, otherwise desugared for loops would look three levels deep.
+func nestingDepth(stmts []Stmt) int {
+	max := 0
+	for _, stmt := range stmts {
+		depth := 0
+		switch s := stmt.(type) {
+		case *BlockStmt:
+			depth = nestingDepth(s.statements)
+		case *IfStmt:
+			depth = 1 + nestingDepth([]Stmt{s.thenPart, s.elsePart})
+		case *WhileStmt:
+			depth = 1 + nestingDepth([]Stmt{s.statement})
+		case *ForInStmt:
+			depth = 1 + nestingDepth([]Stmt{s.body})
+		}
+		if depth > max {
+			max = depth
+		}
+	}
+	return max
+}
+
+// runMetrics implements the 'metrics' subcommand
+func runMetrics(args []string) {
+	flags := flag.NewFlagSet("metrics", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the metrics as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: glox.exe metrics [-json] [script]")
+		os.Exit(64)
+	}
+	metrics := Metrics(parseFile(flags.Arg(0)))
+	if *asJSON {
+		printJSON(metrics)
+		return
+	}
+	fmt.Printf("%-20s %6s %7s %11s %6s %11s\n", "function", "line", "params", "statements", "depth", "complexity")
+	for _, m := range metrics {
+		fmt.Printf("%-20s %6d %7d %11d %6d %11d\n", m.Name, m.Line, m.Params, m.Statements, m.MaxDepth, m.Complexity)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importPathEnv names the environment variable holding the library search path for imports
+const importPathEnv = "GLOX_PATH"
+
+// VisitImportStmt runs the imported file in the global environment, so its top-level declarations become
+// visible to the importer. Every file is only run once, importing it again (from anywhere) does nothing.
+func (in *Interpreter) VisitImportStmt(i *ImportStmt) error {
+	path, ok := resolveImport(i.path.literal.(string), in.dir, in.searchPath)
+	if !ok {
+		return RuntimeError{tkn: i.path, msg: "Can't find module " + i.path.lexeme + "."}
+	}
+	if done, seen := in.modules[path]; seen {
+		if !done {
+			chain := make([]string, 0, len(in.importing)+1)
+			for _, p := range append(in.importing, path) {
+				chain = append(chain, displayPath(p))
+			}
+			return RuntimeError{tkn: i.path, msg: "Circular import: " + strings.Join(chain, " -> ") + "."}
+		}
+		return nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RuntimeError{tkn: i.path, msg: "Can't read module " + i.path.lexeme + "."}
+	}
+	// syntax errors in the module are reported as usual, the import itself fails with a runtime error
+	r := &ErrorReporter{out: in.reporter.out}
+	stmts := parse(string(contents), r)
+	if r.hadError || !in.prepare(stmts, r) {
+		return RuntimeError{tkn: i.path, msg: "Module " + i.path.lexeme + " has syntax errors."}
+	}
+	in.modules[path] = false
+	in.importing = append(in.importing, path)
+	prevEnv, prevDir := in.env, in.dir
+	in.env, in.dir = in.globals, filepath.Dir(path)
+	defer func() {
+		in.env, in.dir = prevEnv, prevDir
+		in.importing = in.importing[:len(in.importing)-1]
+		// a module that failed isn't being imported anymore, importing it again runs it again
+		if !in.modules[path] {
+			delete(in.modules, path)
+		}
+	}()
+	for _, stmt := range stmts {
+		if err := in.execute(stmt); err != nil {
+			return err
+		}
+	}
+	in.modules[path] = true
+	return nil
+}
+
+// resolveImport finds the file an import in a script in 'dir' refers to. Paths starting with "./" or "../" are
+// relative to the importing script, any other path is looked up next to the importing script first and then
+// in every directory of the search path. The result is an absolute path, so every file has a single cache entry.
+func resolveImport(name, dir string, searchPath []string) (string, bool) {
+	for _, path := range importCandidates(name, dir, searchPath) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// importCandidates returns the absolute paths resolveImport tries, in order
+func importCandidates(name, dir string, searchPath []string) []string {
+	dirs := []string{dir}
+	if !isRelativeImport(name) {
+		dirs = append(dirs, searchPath...)
+	}
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+	paths := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if path, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// isRelativeImport reports whether an import path is explicitly relative to the importing script
+func isRelativeImport(name string) bool {
+	return strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../")
+}
+
+// displayPath shortens an absolute module path to one relative to the working directory where possible
+func displayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
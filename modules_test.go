@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFailedImport checks that a module that failed at runtime can be imported again instead of being
+// reported as a circular import
+func TestFailedImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glox-modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.lox"), []byte("print \"bad\"; print 1 / 0;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	in := NewInterpreter()
+	in.out = &out
+	in.reporter = &ErrorReporter{out: &out}
+	in.dir = dir
+	for i := 0; i < 2; i++ {
+		in.Interpret(parse(`import "./bad.lox";`, in.reporter))
+	}
+	if strings.Contains(out.String(), "Circular import") || strings.Count(out.String(), "Integer division by zero") != 2 {
+		t.Errorf("The second import didn't run the module again: %q\n", out.String())
+	}
+}
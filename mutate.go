@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Mutant is a single small change to a script's syntax tree that a good test should notice.
+// Mutants change the tree in place, revert must be called before the next mutant is applied.
+type Mutant struct {
+	line        int
+	description string
+	apply       func()
+	revert      func()
+}
+
+// flippedOperators maps each comparison operator to the one a mutant replaces it with
+var flippedOperators = map[TokenType]TokenType{
+	Less:         LessEqual,
+	LessEqual:    Less,
+	Greater:      GreaterEqual,
+	GreaterEqual: Greater,
+	EqualEqual:   BangEqual,
+	BangEqual:    EqualEqual,
+}
+
+// operatorLexemes is used to keep mutated operator tokens printable
+var operatorLexemes = map[TokenType]string{
+	Less:         "<",
+	LessEqual:    "<=",
+	Greater:      ">",
+	GreaterEqual: ">=",
+	EqualEqual:   "==",
+	BangEqual:    "!=",
+}
+
+// Mutants lists every mutation that can be applied to the given statements: flipped comparison
+// operators, changed constants and negated if/while conditions. Code synthesized by the parser is left alone.
+func Mutants(stmts []Stmt) []*Mutant {
+	mutants := make([]*Mutant, 0)
+	Inspect(stmts, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *BinaryExpr:
+			if to, ok := flippedOperators[n.op.toktype]; ok {
+				mutants = append(mutants, flipOperator(n, to))
+			}
+		case *Literal:
+			if m := changeConstant(n); m != nil {
+				mutants = append(mutants, m)
+			}
+		case *IfStmt:
+			if line := exprLine(n.exp); line > 0 {
+				mutants = append(mutants, negateCondition(&n.exp, line))
+			}
+		case *WhileStmt:
+			if line := exprLine(n.condition); line > 0 {
+				mutants = append(mutants, negateCondition(&n.condition, line))
+			}
+		}
+		return true
+	})
+	return mutants
+}
+
+func flipOperator(b *BinaryExpr, to TokenType) *Mutant {
+	orig := b.op
+	return &Mutant{
+		line:        orig.line,
+		description: fmt.Sprintf("changed '%v' to '%v'", orig.lexeme, operatorLexemes[to]),
+		apply: func() {
+			b.op.toktype = to
+			b.op.lexeme = operatorLexemes[to]
+		},
+		revert: func() {
+			b.op = orig
+		},
+	}
+}
+
+// changeConstant returns a mutant for a number, boolean or string literal, or nil if there is nothing to change
+func changeConstant(l *Literal) *Mutant {
+	if l.tkn.lexeme == "" {
+		return nil
+	}
+	orig := l.val
+	var changed interface{}
+	switch v := l.val.(type) {
+	case int64:
+		changed = v + 1
+	case float64:
+		changed = v + 1
+	case bool:
+		changed = !v
+	case string:
+		if v == "" {
+			return nil
+		}
+		changed = ""
+	default:
+		return nil
+	}
+	return &Mutant{
+		line:        l.tkn.line,
+		description: fmt.Sprintf("changed constant %v to %v", l.tkn.lexeme, describeConstant(changed)),
+		apply: func() {
+			l.val = changed
+		},
+		revert: func() {
+			l.val = orig
+		},
+	}
+}
+
+func describeConstant(val interface{}) string {
+	if str, ok := val.(string); ok {
+		return fmt.Sprintf("%q", str)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// negateCondition returns a mutant that wraps the condition stored at 'cond' in a '!'
+func negateCondition(cond *Expr, line int) *Mutant {
+	orig := *cond
+	return &Mutant{
+		line:        line,
+		description: "negated condition",
+		apply: func() {
+			*cond = &Unary{op: Token{toktype: Bang, lexeme: "!", line: line}, right: &Grouping{exp: orig}}
+		},
+		revert: func() {
+			*cond = orig
+		},
+	}
+}
+
+// exprLine returns the line an expression starts on, 0 if it was synthesized by the parser
+func exprLine(e Expr) int {
+	return exprStart(e).line
+}
+
+// exprStart returns the first token of an expression, or the closest one to it the AST keeps (a grouping
+// starts at its contents). The token is empty if the expression was synthesized by the parser.
+func exprStart(e Expr) Token {
+	switch exp := e.(type) {
+	case *BinaryExpr:
+		return exprStart(exp.left)
+	case *LogicalExpr:
+		return exprStart(exp.left)
+	case *Grouping:
+		return exprStart(exp.exp)
+	case *Literal:
+		return exp.tkn
+	case *Unary:
+		return exp.op
+	case *Variable:
+		return exp.name
+	case *AssignExpr:
+		return exp.name
+	case *CallExpr:
+		return exprStart(exp.callee)
+	case *TupleExpr:
+		return exprStart(exp.values[0])
+	case *IsExpr:
+		return exprStart(exp.val)
+	case *IndexExpr:
+		return exprStart(exp.object)
+	case *SetExpr:
+		return exp.keyword
+	case *BadExpr:
+		return exp.tkn
+	}
+	return Token{}
+}
+
+// MutationResult is the outcome of running every mutant of a single test file
+type MutationResult struct {
+	test     *LoxTest
+	total    int
+	survived []*Mutant
+	// skipped explains why the file wasn't mutated (e.g. it fails without any mutation)
+	skipped string
+}
+
+// Mutate runs a test file once unchanged and then once per mutant. A mutant is killed if the test fails,
+// including by running far longer than the unchanged file did (a mutated loop may never finish).
+func (t *LoxTest) Mutate() *MutationResult {
+	res := &MutationResult{test: t}
+	if t.snapshot {
+		if _, err := os.Stat(t.snapshotPath()); err != nil {
+			res.skipped = "no snapshot stored yet, run 'glox test' first"
+			return res
+		}
+	}
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out}
+	stmts := parse(t.source, r)
+	baseline := t.runParsed(stmts, r, &out, 0, false)
+	if len(baseline.failures) > 0 {
+		res.skipped = "test fails without mutations"
+		return res
+	}
+	budget := baseline.steps*10 + 1000
+	mutants := Mutants(stmts)
+	res.total = len(mutants)
+	for _, m := range mutants {
+		m.apply()
+		var mout bytes.Buffer
+		result := t.runParsed(stmts, &ErrorReporter{out: &mout}, &mout, budget, false)
+		m.revert()
+		if len(result.failures) == 0 {
+			res.survived = append(res.survived, m)
+		}
+	}
+	return res
+}
+
+// runMutate implements the 'mutate' subcommand
+func runMutate(args []string) {
+	flags := flag.NewFlagSet("mutate", flag.ExitOnError)
+	filter := flags.String("run", "", "only mutate test files whose path matches this regular expression")
+	flags.Parse(args)
+	runRe, err := regexp.Compile(*filter)
+	if err != nil {
+		fmt.Printf("Invalid -run pattern: %v\n", err)
+		os.Exit(64)
+	}
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	paths, err := FindTests(roots)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(66)
+	}
+	total, killed := 0, 0
+	for _, path := range paths {
+		if !runRe.MatchString(filepath.ToSlash(path)) {
+			continue
+		}
+		t, err := LoadTest(path)
+		if err != nil {
+			fmt.Printf("Can't open file at [%v].\n", path)
+			os.Exit(66)
+		}
+		if t.skip {
+			continue
+		}
+		res := t.Mutate()
+		if res.skipped != "" {
+			fmt.Printf("%v: skipped, %v\n", path, res.skipped)
+			continue
+		}
+		total += res.total
+		killed += res.total - len(res.survived)
+		fmt.Printf("%v: %d mutants, %d killed, %d survived\n", path, res.total, res.total-len(res.survived), len(res.survived))
+		for _, m := range res.survived {
+			fmt.Printf("    line %d: %v\n", m.line, m.description)
+		}
+	}
+	if total > 0 {
+		fmt.Printf("mutation score: %.1f%% (%d/%d killed)\n", 100*float64(killed)/float64(total), killed, total)
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 /*
 Native functions should be defined as types that implement that LoxCaller interface
@@ -9,20 +12,122 @@ Native functions should be defined as types that implement that LoxCaller interf
 // LoxCaller encompasses any type that supported being called with arguments
 type LoxCaller interface {
 	arity() int
-	call(in Interpreter, args []interface{}) interface{}
+	call(in *Interpreter, args []interface{}) interface{}
 }
 
-// GlobalFunctionClock is a native function wrapper that exposes clock() which returns a Unix time
-type GlobalFunctionClock string
+// NativeFunction wraps a Go function so it can be called from Lox like any other LoxCaller.
+// Natives report failures by returning a RuntimeError, the interpreter fills in the call site.
+type NativeFunction struct {
+	name  string
+	nargs int
+	fn    func(in *Interpreter, args []interface{}) interface{}
+}
+
+func (n *NativeFunction) arity() int {
+	return n.nargs
+}
+
+func (n *NativeFunction) call(in *Interpreter, args []interface{}) interface{} {
+	for _, p := range in.plugins {
+		p.OnNativeCall(in, n.name, args)
+	}
+	return n.fn(in, args)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn " + n.name + ">"
+}
+
+// natives lists every native function defined in a new interpreter's global environment
+var natives = []*NativeFunction{
+	{"clock", 0, nativeClock},
+	{"sleep", 1, nativeSleep},
+	{"flush", 0, nativeFlush},
+	{"approxEqual", 3, nativeApproxEqual},
+	{"forall", 3, nativeForall},
+	{"genInt", 2, nativeGenInt},
+	{"genString", 1, nativeGenString},
+	{"genBool", 0, nativeGenBool},
+	{"addChecked", 2, checkedIntNative("addChecked", Plus)},
+	{"subChecked", 2, checkedIntNative("subChecked", Minus)},
+	{"mulChecked", 2, checkedIntNative("mulChecked", Star)},
+	{"now", 0, nativeNow},
+	{"toZone", 2, nativeToZone},
+	{"parseTime", 1, nativeParseTime},
+	{"formatTime", 1, nativeFormatTime},
+	{"addDuration", 2, nativeAddDuration},
+	{"timeDiff", 2, nativeTimeDiff},
+	{"parseDuration", 1, nativeParseDuration},
+	{"bytes", 1, nativeBytes},
+	{"toBytes", 1, nativeToBytes},
+	{"byteLength", 1, nativeByteLength},
+	{"byteAt", 2, nativeByteAt},
+	{"setByte", 3, nativeSetByte},
+	{"slice", 3, nativeSlice},
+	{"toString", 2, nativeToString},
+	{"readFileBytes", 1, nativeReadFileBytes},
+	{"writeFileBytes", 2, nativeWriteFileBytes},
+	{"hexEncode", 1, nativeHexEncode},
+	{"hexDecode", 1, nativeHexDecode},
+	{"base64Encode", 1, nativeBase64Encode},
+	{"base64Decode", 1, nativeBase64Decode},
+	{"sha256", 1, nativeSha256},
+	{"md5", 1, nativeMd5},
+	{"hmac", 2, nativeHmac},
+	{"uuid", 0, nativeUUID},
+	{"randomHex", 1, nativeRandomHex},
+	{"set", 0, nativeSet},
+	{"add", 2, nativeAdd},
+	{"has", 2, nativeHas},
+	{"remove", 2, nativeRemove},
+	{"union", 2, nativeUnion},
+	{"intersect", 2, nativeIntersect},
+	{"difference", 2, nativeDifference},
+	{"clone", 1, nativeClone},
+	{"freeze", 1, nativeFreeze},
+	{"isFrozen", 1, nativeIsFrozen},
+	{"inspect", 2, nativeInspect},
+	{"diff", 2, nativeDiff},
+	{"assertEqual", 2, nativeAssertEqual},
+	{"fail", 1, nativeFail},
+	{"memoize", 1, nativeMemoize},
+	{"doc", 1, nativeDoc},
+	{"coroutine", 1, nativeCoroutine},
+	{"resume", 2, nativeResume},
+	{"suspend", 1, nativeSuspend},
+	{"coroutineStatus", 1, nativeCoroutineStatus},
+	{"close", 1, nativeClose},
+}
+
+// clock() returns the current Unix time in seconds
+func nativeClock(in *Interpreter, args []interface{}) interface{} {
+	return float64(in.clock.Now().UnixNano()) / float64(time.Second)
+}
 
-func (g *GlobalFunctionClock) arity() int {
-	return 0
+// sleep(ms) pauses the script for the given number of milliseconds
+func nativeSleep(in *Interpreter, args []interface{}) interface{} {
+	ms, ok := toFloat(args[0])
+	if !ok || ms < 0 {
+		return RuntimeError{msg: "sleep() expects a non-negative number of milliseconds."}
+	}
+	in.clock.Sleep(millis(ms))
+	return nil
 }
 
-func (g *GlobalFunctionClock) String() string {
-	return g.String()
+// flush() writes out the buffered output of print right away instead of at the end of the script
+func nativeFlush(in *Interpreter, args []interface{}) interface{} {
+	in.flush()
+	return nil
 }
 
-func (g *GlobalFunctionClock) call(in *Interpreter, args []interface{}) interface{} {
-	return time.Now().Unix()
+// approxEqual(a, b, eps) reports whether two numbers are within eps of each other.
+// This is the safe alternative to '==' on computed floats.
+func nativeApproxEqual(in *Interpreter, args []interface{}) interface{} {
+	a, aok := toFloat(args[0])
+	b, bok := toFloat(args[1])
+	eps, eok := toFloat(args[2])
+	if !aok || !bok || !eok {
+		return RuntimeError{msg: "approxEqual() arguments must be numbers."}
+	}
+	return math.Abs(a-b) <= eps
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+A notebook (.loxnb) is a plain text file split into cells by marker lines:
+
+	%% md      starts a markdown cell
+	%% lox     starts a code cell (a bare "%%" does too)
+
+Text before the first marker is a code cell. Code cells run in order in a single interpreter, like lines
+typed into the REPL, so later cells see what earlier ones declared.
+*/
+
+// NotebookCell is a single cell of a notebook and, once run, its captured output
+type NotebookCell struct {
+	markdown bool
+	source   string
+	output   string
+	failed   bool
+}
+
+// ParseNotebook splits a notebook into its cells, empty cells are dropped
+func ParseNotebook(source string) []*NotebookCell {
+	cells := make([]*NotebookCell, 0)
+	cur := &NotebookCell{}
+	var lines []string
+	flush := func() {
+		cur.source = strings.Trim(strings.Join(lines, "\n"), "\n")
+		if strings.TrimSpace(cur.source) != "" {
+			cells = append(cells, cur)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasPrefix(line, "%%") {
+			lines = append(lines, line)
+			continue
+		}
+		flush()
+		kind := strings.TrimSpace(line[2:])
+		cur = &NotebookCell{markdown: kind == "md" || kind == "markdown"}
+	}
+	flush()
+	return cells
+}
+
+// RunNotebook runs every code cell in the interpreter, capturing the output and errors of each cell.
+// A failing cell doesn't stop the notebook, the number of failed cells is returned.
+func RunNotebook(in *Interpreter, cells []*NotebookCell) int {
+	failed := 0
+	for _, cell := range cells {
+		if cell.markdown {
+			continue
+		}
+		var out bytes.Buffer
+		r := &ErrorReporter{out: &out}
+		in.out, in.reporter = &out, r
+		in.runSnippet(cell.source)
+		cell.output = out.String()
+		cell.failed = r.hadError || r.hadRuntimeError
+		if cell.failed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// writeNotebookMarkdown renders a run notebook as markdown, code cells are followed by their output
+func writeNotebookMarkdown(w io.Writer, cells []*NotebookCell) {
+	for i, cell := range cells {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if cell.markdown {
+			fmt.Fprintln(w, cell.source)
+			continue
+		}
+		fmt.Fprintf(w, "```lox\n%v\n```\n", cell.source)
+		if cell.failed {
+			fmt.Fprintln(w, "\n**Error:**")
+		}
+		if cell.output != "" {
+			fmt.Fprintf(w, "\n```\n%v```\n", cell.output)
+		}
+	}
+}
+
+// writeNotebookHTML renders a run notebook as a standalone HTML page. Markdown cells only get their
+// headings and paragraphs converted, everything else is shown as text.
+func writeNotebookHTML(w io.Writer, title string, cells []*NotebookCell) {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%v</title>\n", html.EscapeString(title))
+	fmt.Fprintln(w, "<style>pre { background: #f4f4f4; padding: 8px; } pre.output { background: #fff; border-left: 3px solid #ccc; } pre.error { border-left-color: #c00; }</style>")
+	fmt.Fprintln(w, "</head>\n<body>")
+	for _, cell := range cells {
+		if !cell.markdown {
+			fmt.Fprintf(w, "<pre class=\"code\">%v</pre>\n", html.EscapeString(cell.source))
+			if cell.output != "" || cell.failed {
+				class := "output"
+				if cell.failed {
+					class += " error"
+				}
+				fmt.Fprintf(w, "<pre class=\"%v\">%v</pre>\n", class, html.EscapeString(cell.output))
+			}
+			continue
+		}
+		for _, para := range strings.Split(cell.source, "\n\n") {
+			para = strings.TrimSpace(para)
+			level := len(para) - len(strings.TrimLeft(para, "#"))
+			if level >= 1 && level <= 6 && strings.HasPrefix(para[level:], " ") {
+				fmt.Fprintf(w, "<h%d>%v</h%d>\n", level, html.EscapeString(strings.TrimSpace(para[level:])), level)
+			} else if para != "" {
+				fmt.Fprintf(w, "<p>%v</p>\n", html.EscapeString(para))
+			}
+		}
+	}
+	fmt.Fprintln(w, "</body>\n</html>")
+}
+
+// runNotebook implements the 'notebook' subcommand, 'notebook run' prints a report of the notebook with
+// the output of every cell. The exit status is 1 if any cell failed.
+func runNotebook(args []string) {
+	flags := flag.NewFlagSet("notebook", flag.ExitOnError)
+	format := flags.String("format", "md", "report format: md or html")
+	outPath := flags.String("o", "", "write the report to this file instead of stdout")
+	if len(args) > 0 && args[0] == "run" {
+		flags.Parse(args[1:])
+	}
+	if len(args) == 0 || args[0] != "run" || flags.NArg() != 1 || (*format != "md" && *format != "html") {
+		fmt.Println("usage: glox.exe notebook run [-format md|html] [-o report] [notebook.loxnb]")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	path := flags.Arg(0)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	cells := ParseNotebook(string(contents))
+	in := newMainInterpreter()
+	in.dir = filepath.Dir(path)
+	failed := RunNotebook(in, cells)
+	var report bytes.Buffer
+	if *format == "html" {
+		writeNotebookHTML(&report, filepath.Base(path), cells)
+	} else {
+		writeNotebookMarkdown(&report, cells)
+	}
+	if *outPath == "" {
+		os.Stdout.Write(report.Bytes())
+	} else if err := ioutil.WriteFile(*outPath, report.Bytes(), 0644); err != nil {
+		fmt.Printf("Can't write file at [%v].\n", *outPath)
+		os.Exit(74)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
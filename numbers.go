@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Lox has two kinds of numbers: integers (int64), written without a decimal point, and doubles (float64).
+// Arithmetic on two integers stays integral, as soon as a double is involved the integer is promoted.
+
+// toFloat converts any Lox number to a float64, ok is false if val isn't a number
+func toFloat(val interface{}) (float64, bool) {
+	switch n := val.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// isNumber reports whether val is an integer or a double
+func isNumber(val interface{}) bool {
+	_, ok := toFloat(val)
+	return ok
+}
+
+// arithmetic applies one of the numeric binary operators to two numbers. Integer division truncates
+// towards zero and the remainder takes the sign of the dividend; dividing an integer by zero is an error.
+// Integer overflow wraps around unless 'checked' is set, then it is an error too.
+func arithmetic(op Token, left, right interface{}, checked bool) interface{} {
+	li, lInt := left.(int64)
+	ri, rInt := right.(int64)
+	if lInt && rInt {
+		if checked && op.toktype != Percent {
+			if ri == 0 && op.toktype == Slash {
+				return RuntimeError{tkn: op, msg: "Integer division by zero."}
+			}
+			res, ok := checkedInt(op.toktype, li, ri)
+			if !ok {
+				return RuntimeError{tkn: op, msg: fmt.Sprintf("Integer overflow in %d %v %d.", li, op.lexeme, ri)}
+			}
+			return res
+		}
+		switch op.toktype {
+		case Plus:
+			return li + ri
+		case Minus:
+			return li - ri
+		case Star:
+			return li * ri
+		case Slash, Percent:
+			if ri == 0 {
+				return RuntimeError{tkn: op, msg: "Integer division by zero."}
+			}
+			if op.toktype == Slash {
+				return li / ri
+			}
+			return li % ri
+		}
+	}
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	switch op.toktype {
+	case Plus:
+		return lf + rf
+	case Minus:
+		return lf - rf
+	case Star:
+		return lf * rf
+	case Slash:
+		return lf / rf
+	case Percent:
+		return math.Mod(lf, rf)
+	}
+	return RuntimeError{tkn: op, msg: "Unknown arithmetic operator."}
+}
+
+// checkedInt applies +, -, * or / to two integers, ok is false if the result doesn't fit into an int64.
+// The divisor must not be zero.
+func checkedInt(op TokenType, a, b int64) (res int64, ok bool) {
+	switch op {
+	case Plus:
+		res = a + b
+		return res, (b >= 0) == (res >= a)
+	case Minus:
+		res = a - b
+		return res, (b >= 0) == (res <= a)
+	case Star:
+		res = a * b
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		// MinInt64 * -1 wraps to MinInt64, which the division check can't tell from a correct result
+		return res, res/b == a && !(b == -1 && a == math.MinInt64)
+	case Slash:
+		return a / b, !(a == math.MinInt64 && b == -1)
+	}
+	return 0, false
+}
+
+// checkedIntNative builds the addChecked()/subChecked()/mulChecked() natives, which raise an error on
+// integer overflow no matter whether the interpreter checks overflow everywhere
+func checkedIntNative(name string, op TokenType) func(in *Interpreter, args []interface{}) interface{} {
+	return func(in *Interpreter, args []interface{}) interface{} {
+		a, aok := args[0].(int64)
+		b, bok := args[1].(int64)
+		if !aok || !bok {
+			return RuntimeError{msg: name + "() arguments must be integers."}
+		}
+		res, ok := checkedInt(op, a, b)
+		if !ok {
+			return RuntimeError{msg: fmt.Sprintf("Integer overflow in %v(%d, %d).", name, a, b)}
+		}
+		return res
+	}
+}
+
+// compareNumbers returns -1, 0 or 1 depending on whether left is less than, equal to or greater than right.
+// Two integers are compared exactly. Comparisons involving NaN return 2 so every ordering test fails.
+func compareNumbers(left, right interface{}) int {
+	li, lInt := left.(int64)
+	ri, rInt := right.(int64)
+	if lInt && rInt {
+		switch {
+		case li < ri:
+			return -1
+		case li > ri:
+			return 1
+		}
+		return 0
+	}
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	switch {
+	case lf < rf:
+		return -1
+	case lf > rf:
+		return 1
+	case lf == rf:
+		return 0
+	}
+	return 2
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// OutlineItem is one declaration in the hierarchical outline of a script.
+// The exported fields double as the JSON format printed by 'glox outline -json'.
+type OutlineItem struct {
+	Name      string         `json:"name"`
+	Kind      string         `json:"kind"`
+	Params    []string       `json:"params,omitempty"`
+	StartLine int            `json:"startLine"`
+	EndLine   int            `json:"endLine"`
+	Children  []*OutlineItem `json:"children,omitempty"`
+}
+
+// Outline returns the top-level variables and functions of a script,
+// functions list the functions declared inside of them as children
+func Outline(stmts []Stmt) []*OutlineItem {
+	items := make([]*OutlineItem, 0)
+	for _, stmt := range stmts {
+		switch decl := stmt.(type) {
+		case *VarStmt:
+			items = append(items, &OutlineItem{
+				Name:      decl.name.lexeme,
+				Kind:      "variable",
+				StartLine: decl.name.line,
+				EndLine:   decl.name.line,
+			})
+		case *DestructureStmt:
+			if !decl.declare {
+				continue
+			}
+			for _, name := range decl.names {
+				items = append(items, &OutlineItem{
+					Name:      name.lexeme,
+					Kind:      "variable",
+					StartLine: name.line,
+					EndLine:   name.line,
+				})
+			}
+		case *FunctionStmt:
+			items = append(items, outlineFunction(decl))
+		}
+	}
+	return items
+}
+
+// outlineFunction builds the outline item of a function and of any functions nested inside of it
+func outlineFunction(f *FunctionStmt) *OutlineItem {
+	item := &OutlineItem{
+		Name:      f.name.lexeme,
+		Kind:      "function",
+		Params:    make([]string, 0, len(f.params)),
+		StartLine: f.name.line,
+		EndLine:   f.end.line,
+	}
+	for _, param := range f.params {
+		item.Params = append(item.Params, param.lexeme)
+	}
+	Inspect(f.body, func(node interface{}) bool {
+		if nested, ok := node.(*FunctionStmt); ok {
+			item.Children = append(item.Children, outlineFunction(nested))
+			return false
+		}
+		return true
+	})
+	return item
+}
+
+// runOutline implements the 'outline' subcommand
+func runOutline(args []string) {
+	flags := flag.NewFlagSet("outline", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the outline as JSON")
+	tolerant := flags.Bool("tolerant", false, "outline a script with syntax errors as far as possible, reporting them on stderr")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: glox.exe outline [-json] [-tolerant] [script]")
+		os.Exit(64)
+	}
+	var stmts []Stmt
+	if *tolerant {
+		contents, err := ioutil.ReadFile(flags.Arg(0))
+		if err != nil {
+			fmt.Printf("Can't open file at [%v].\n", flags.Arg(0))
+			os.Exit(66)
+		}
+		stmts = parseTolerant(string(contents), &ErrorReporter{out: os.Stderr, lang: reporter.lang})
+	} else {
+		stmts = parseFile(flags.Arg(0))
+	}
+	items := Outline(stmts)
+	if *asJSON {
+		printJSON(items)
+		return
+	}
+	printOutline(items, 0)
+}
+
+// printOutline prints the outline as an indented tree
+func printOutline(items []*OutlineItem, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, item := range items {
+		if item.Kind == "function" {
+			fmt.Printf("%vfun %v(%v) [lines %d-%d]\n", indent, item.Name, strings.Join(item.Params, ", "), item.StartLine, item.EndLine)
+		} else {
+			fmt.Printf("%vvar %v [line %d]\n", indent, item.Name, item.StartLine)
+		}
+		printOutline(item.Children, depth+1)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// packMagic ends an executable made by 'glox pack'. Such an executable is a copy of the glox binary followed
+// by the bundled script, the script's length as 8 little-endian bytes and this marker.
+const packMagic = "GLOXPACK"
+
+// packTrailerSize is the size of the length and the marker after the script
+const packTrailerSize = 8 + len(packMagic)
+
+// payloadStart returns the offset of the packed script in an executable of the given size, ok is false
+// if the executable doesn't carry a script
+func payloadStart(f io.ReaderAt, size int64) (start int64, ok bool) {
+	if size < int64(packTrailerSize) {
+		return 0, false
+	}
+	trailer := make([]byte, packTrailerSize)
+	if _, err := f.ReadAt(trailer, size-int64(packTrailerSize)); err != nil || string(trailer[8:]) != packMagic {
+		return 0, false
+	}
+	length := int64(binary.LittleEndian.Uint64(trailer[:8]))
+	start = size - int64(packTrailerSize) - length
+	return start, length >= 0 && start >= 0
+}
+
+// packedScript returns the path of the running executable and the script packed into it, if there is one
+func packedScript() (exe, script string, ok bool) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", "", false
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return "", "", false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", "", false
+	}
+	start, ok := payloadStart(f, info.Size())
+	if !ok {
+		return "", "", false
+	}
+	payload := make([]byte, info.Size()-int64(packTrailerSize)-start)
+	if _, err := f.ReadAt(payload, start); err != nil {
+		return "", "", false
+	}
+	return exe, string(payload), true
+}
+
+// Pack writes an executable to out that runs script: a copy of the glox executable at exe with the script
+// appended. If exe is itself a packed executable its script is replaced.
+func Pack(exe, script, out string) error {
+	glox, err := ioutil.ReadFile(exe)
+	if err != nil {
+		return err
+	}
+	if start, ok := payloadStart(strings.NewReader(string(glox)), int64(len(glox))); ok {
+		glox = glox[:start]
+	}
+	trailer := make([]byte, 8, packTrailerSize)
+	binary.LittleEndian.PutUint64(trailer, uint64(len(script)))
+	trailer = append(trailer, packMagic...)
+	return ioutil.WriteFile(out, append(append(glox, script...), trailer...), 0755)
+}
+
+// runPack implements the 'pack' subcommand: the script is bundled with its imports and packed into a
+// copy of the running glox executable. The flags may come before or after the script.
+func runPack(args []string) {
+	flags := flag.NewFlagSet("pack", flag.ExitOnError)
+	outPath := flags.String("o", "", "name of the executable, the script's name without .lox by default")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		path := flags.Arg(0)
+		flags.Parse(flags.Args()[1:])
+		args = append([]string{path}, flags.Args()...)
+	} else {
+		args = flags.Args()
+	}
+	if len(args) != 1 {
+		fmt.Println("usage: glox.exe pack [-o executable] [script]")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	bundled, err := Bundle(args[0], loadProject(args[0]).searchPath())
+	if err != nil {
+		fmt.Printf("Can't bundle %v: %v.\n", args[0], err)
+		os.Exit(65)
+	}
+	out := *outPath
+	if out == "" {
+		out = strings.TrimSuffix(args[0], filepath.Ext(args[0]))
+		if runtime.GOOS == "windows" {
+			out += ".exe"
+		}
+	}
+	exe, err := os.Executable()
+	if err == nil {
+		err = Pack(exe, bundled, out)
+	}
+	if err != nil {
+		fmt.Printf("Can't write executable at [%v]: %v\n", out, err)
+		os.Exit(74)
+	}
+}
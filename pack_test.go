@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPack checks that a packed script can be found again and that packing twice replaces the script
+func TestPack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glox-pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	exe, once, twice := filepath.Join(dir, "glox"), filepath.Join(dir, "once"), filepath.Join(dir, "twice")
+	if err := ioutil.WriteFile(exe, []byte("not really an executable"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pack(exe, "print 1;", once); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pack(once, "print 2;", twice); err != nil {
+		t.Fatal(err)
+	}
+	packed, _ := ioutil.ReadFile(twice)
+	f, _ := os.Open(twice)
+	defer f.Close()
+	start, ok := payloadStart(f, int64(len(packed)))
+	if !ok || string(packed[:start]) != "not really an executable" || string(packed[start:len(packed)-packTrailerSize]) != "print 2;" {
+		t.Errorf("Wrong packed executable: %q\n", packed)
+	}
+}
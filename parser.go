@@ -8,35 +8,46 @@ import (
 /*
 The simple statement grammar for Lox:
 program		   → declaration* EOF ;
-declaration	   → funcDecl | varDecl | statement ;
-varDecl		   → "var" IDENTIFIER ( "=" expression )? ";" ;
-funDecl		   → "fun" function ;
-function	   → IDENTIFIER "(" parameters? ")" block ;
-statement	   → exprStmt | returnStmt | printStmt | whilestmt | ifstmt | block;
+declaration	   → funcDecl | varDecl | importDecl | statement ;
+importDecl     → "import" STRING ";" ;
+varDecl		   → "var" IDENTIFIER annotation? ( "=" expression )? ";"
+			   | "const" IDENTIFIER annotation? "=" expression ";"
+			   | ( "var" | "const" ) IDENTIFIER ( "," IDENTIFIER )+ "=" exprList ";" ;
+funDecl		   → "fun" "*"? function ;
+function	   → IDENTIFIER "(" parameters? ")" annotation? block ;
+statement	   → exprStmt | returnStmt | yieldStmt | printStmt | whilestmt | ifstmt | block | destructure ;
+destructure    → IDENTIFIER ( "," IDENTIFIER )+ "=" exprList ";" ;
 block          → "{" declaration* "}" ;
 ifstmt         → "if" "(" expression ")" statement ("else" statement)? ;
 whilestmt	   → "while" "(" expression ")" statement ;
-forstmt        → "for" "(" (varDecl | exprStmt | ";") expression? ";" expression?)" statement;
-returnStmt     → "return" expression? ";" ;
-parameters     → IDENTIFIER ( "," IDENTIFIER )* ;
+forstmt        → "for" "(" (varDecl | exprStmt | ";") expression? ";" comma? ")" statement
+			   | "for" "(" "var" IDENTIFIER "in" expression ")" statement ;
+returnStmt     → "return" exprList? ";" ;
+yieldStmt      → "yield" expression ";" ;
+exprList       → expression ( "," expression )* ;
+parameters     → IDENTIFIER annotation? ( "," IDENTIFIER annotation? )* ;
+annotation     → ":" IDENTIFIER ;
 
 The simple expression grammar for Lox is as follows (left-factored & unambiguous):
+comma          → expression ( "," expression )* ;
 expression     → assignment ;
 assignment     → IDENTIFIER "=" assignment
-			   | logic_or;
+			   | coalesce;
+coalesce       → logic_or ( "??" logic_or )* ;
 logic_of	   → logic_and ("or" logic_and)* ;
 logic_and	   → equality ("and" equality)* ;
-equality       → comparison ( ( "!=" | "==" ) comparison )* ;
+equality       → comparison ( ( "!=" | "==" ) comparison | "is" IDENTIFIER )* ;
 comparison     → term ( ( ">" | ">=" | "<" | "<=" ) term )* ;
 term           → factor ( ( "-" | "+" ) factor )* ;
-factor         → unary ( ( "/" | "*" ) unary )* ;
+factor         → unary ( ( "/" | "*" | "%" ) unary )* ;
 unary          → ( "!" | "-" ) unary
                | call ;
-call           → primary ( "(" arguments? ")" )* ;
+call           → primary ( "(" arguments? ")" | "[" subscript "]" )* ;
+subscript      → expression | expression? ":" expression? ;
 arguments	   → expression ( "," expression )* ;
 primary        → NUMBER | STRING | "true" | "false" | "nil"
                | IDENTIFIER
-               | "(" expression ")" ;
+               | "(" comma ")" ;
 */
 
 // Parser is a recursive descent parser
@@ -44,11 +55,20 @@ primary        → NUMBER | STRING | "true" | "false" | "nil"
 type Parser struct {
 	inputTokens []*Token
 	current     int
+	reporter    *ErrorReporter
+	// fixes holds machine-applicable fixes for the syntax errors found so far (e.g. a missing ';')
+	fixes []*Fix
+	// tolerant parsers are for editors working on incomplete code: they carry on as if missing closing
+	// tokens were there, put BadExpr where an expression is missing and BadStmt over skipped tokens
+	tolerant bool
 }
 
+// closingTokens end a construct, a tolerant parser assumes the missing ones
+var closingTokens = map[TokenType]bool{Semicolon: true, RightParen: true, RightBrace: true, RightBracket: true}
+
 // NewParser is a factory function that creates a new Parser struct from a Lexer implementation
 func NewParser(l Lexer) Parser {
-	p := Parser{inputTokens: l.ScanTokens()}
+	p := Parser{inputTokens: l.ScanTokens(), reporter: reporter}
 	return p
 }
 
@@ -56,39 +76,97 @@ func NewParser(l Lexer) Parser {
 func (p *Parser) Parse() []Stmt {
 	stmtList := make([]Stmt, 0)
 	for !p.isAtEnd() {
-		stmt := p.declaration()
-		stmtList = append(stmtList, stmt)
+		start := p.current
+		stmtList = append(stmtList, p.progress(start, p.declaration()))
 	}
 	return stmtList
 }
 
+// progress returns the statement parsed from the token at start on. A tolerant parser may not get past
+// a stray token (like ')') without an error, it is skipped then.
+func (p *Parser) progress(start int, stmt Stmt) Stmt {
+	if p.current == start {
+		return p.skip(start)
+	}
+	return stmt
+}
+
+// skip discards tokens after a syntax error until the next statement begins. Tolerant parsers return a
+// BadStmt covering them, others nil.
+func (p *Parser) skip(start int) Stmt {
+	p.synchronize()
+	if !p.tolerant {
+		return nil
+	}
+	return &BadStmt{from: *p.inputTokens[start], to: *p.previous()}
+}
+
 // declaration parses a declaration from the token struct.
 // ParseErrors are caught and handled here.
 func (p *Parser) declaration() Stmt {
+	start := p.current
+	if p.check(At) {
+		stmt, err := p.decorated()
+		if err != nil {
+			return p.skip(start)
+		}
+		return stmt
+	}
 	if p.match(Fun) {
+		generator := p.match(Star)
 		fun, err := p.function("function")
 		if err != nil {
-			p.synchronize()
-			return nil
+			return p.skip(start)
 		}
+		fun.(*FunctionStmt).generator = generator
 		return fun
 	}
-	if p.match(VarTok) {
+	if p.match(VarTok, ConstTok) {
 		stmt, err := p.varDeclaration()
 		if err != nil {
-			p.synchronize()
-			return nil
+			return p.skip(start)
+		}
+		return stmt
+	}
+	if p.match(ImportTok) {
+		stmt, err := p.importDeclaration()
+		if err != nil {
+			return p.skip(start)
 		}
 		return stmt
 	}
 	stmt, err := p.statement()
 	if err != nil {
-		p.synchronize()
-		return nil
+		return p.skip(start)
 	}
 	return stmt
 }
 
+// decorated parses the decorators in front of a function declaration and the declaration itself
+func (p *Parser) decorated() (Stmt, error) {
+	decorators := make([]Decorator, 0)
+	for p.match(At) {
+		at := p.previous()
+		exp, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		decorators = append(decorators, Decorator{at: *at, expr: exp})
+	}
+	err := p.consume(Fun, "Expect function declaration after decorator.")
+	if err != nil {
+		return nil, err
+	}
+	generator := p.match(Star)
+	fun, err := p.function("function")
+	if err != nil {
+		return nil, err
+	}
+	fun.(*FunctionStmt).generator = generator
+	fun.(*FunctionStmt).decorators = decorators
+	return fun, nil
+}
+
 func (p *Parser) function(kind string) (Stmt, error) {
 	err := p.consume(Identifier, fmt.Sprintf("Expect %s name.", kind))
 	if err != nil {
@@ -99,22 +177,32 @@ func (p *Parser) function(kind string) (Stmt, error) {
 	err = p.consume(LeftParen, fmt.Sprintf("Expect '(' after %s name.", kind))
 	// consume parameters
 	params := make([]Token, 0)
+	paramTypes := make([]Token, 0)
 	if !p.check(RightParen) {
 		for ok := true; ok; ok = p.match(Comma) {
 			if len(params) >= 255 {
-				errorTok(*p.Peek(), "Can't have more than 255 parameters.")
+				p.reporter.errorTok(*p.Peek(), "Can't have more than 255 parameters.")
 			}
 			err = p.consume(Identifier, "Expect parameter name.")
 			if err != nil {
 				return nil, err
 			}
 			params = append(params, *p.previous())
+			typ, err := p.annotation()
+			if err != nil {
+				return nil, err
+			}
+			paramTypes = append(paramTypes, typ)
 		}
 	}
 	err = p.consume(RightParen, "Expect ')' after parameter list.")
 	if err != nil {
 		return nil, err
 	}
+	returnType, err := p.annotation()
+	if err != nil {
+		return nil, err
+	}
 	// parse body
 	err = p.consume(LeftBrace, fmt.Sprintf("Expect '{' before %s body.", kind))
 	if err != nil {
@@ -125,20 +213,126 @@ func (p *Parser) function(kind string) (Stmt, error) {
 		return nil, err
 	}
 	return &FunctionStmt{
-		name:   *name,
-		params: params,
-		body:   body,
+		name:       *name,
+		params:     params,
+		paramTypes: paramTypes,
+		returnType: returnType,
+		body:       body,
+		end:        *p.previous(),
+		doc:        docString(body),
+	}, nil
+}
+
+// annotation parses an optional ': TypeName', it returns an empty token if there is none.
+// Annotations are only read by the type checker, they don't change how a script runs.
+func (p *Parser) annotation() (Token, error) {
+	if !p.match(Colon) {
+		return Token{}, nil
+	}
+	err := p.consume(Identifier, "Expect type name after ':'.")
+	if err != nil {
+		return Token{}, err
+	}
+	return *p.previous(), nil
+}
+
+// importDeclaration parses an import of another script
+func (p *Parser) importDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	err := p.consume(StringTok, "Expect module path after 'import'.")
+	if err != nil {
+		return nil, err
+	}
+	path := p.previous()
+	err = p.consume(Semicolon, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+	return &ImportStmt{keyword: *keyword, path: *path, end: *p.previous()}, nil
+}
+
+// destructuring parses the rest of a 'var a, b = ...;' declaration (keyword is 'var' or 'const') or of an
+// 'a, b = ...;' assignment (keyword is empty) once the first name was consumed
+func (p *Parser) destructuring(keyword, first Token) (Stmt, error) {
+	names := []Token{first}
+	for p.match(Comma) {
+		err := p.consume(Identifier, "Expect variable name after ','.")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, *p.previous())
+	}
+	err := p.consume(Equal, "Expect '=' after the names to destructure into.")
+	if err != nil {
+		return nil, err
+	}
+	init, err := p.expressionList()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(Semicolon, "Expect semicolon after destructuring.")
+	if err != nil {
+		return nil, err
+	}
+	return &DestructureStmt{
+		keyword:  keyword,
+		names:    names,
+		init:     init,
+		declare:  keyword.lexeme != "",
+		constant: keyword.toktype == ConstTok,
 	}, nil
 }
 
-// varDeclaration parses a variable declaration with an optional initializer expression
+// expressionList parses one expression, or several separated by commas which become a tuple
+func (p *Parser) expressionList() (Expr, error) {
+	val, err := p.expression()
+	if err != nil || !p.check(Comma) {
+		return val, err
+	}
+	tuple := &TupleExpr{values: []Expr{val}}
+	for p.match(Comma) {
+		val, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+		tuple.values = append(tuple.values, val)
+	}
+	return tuple, nil
+}
+
+// isDestructuringAssignment looks ahead for 'IDENTIFIER ( "," IDENTIFIER )+ "="' without consuming anything
+func (p *Parser) isDestructuringAssignment() bool {
+	if !p.check(Identifier) || !p.checkAhead(1, Comma) {
+		return false
+	}
+	n := 0
+	for p.checkAhead(n, Identifier) && p.checkAhead(n+1, Comma) {
+		n += 2
+	}
+	return p.checkAhead(n, Identifier) && p.checkAhead(n+1, Equal)
+}
+
+// varDeclaration parses a variable declaration with an optional initializer expression,
+// or a constant declaration (after 'const') where the initializer is required
 func (p *Parser) varDeclaration() (Stmt, error) {
 	var init Expr = nil
+	keyword := p.previous()
+	constant := keyword.toktype == ConstTok
 	err := p.consume(Identifier, "Expect variable name.")
 	if err != nil {
 		return nil, err
 	}
 	name := p.previous()
+	if p.check(Comma) {
+		return p.destructuring(*keyword, *name)
+	}
+	typ, err := p.annotation()
+	if err != nil {
+		return nil, err
+	}
+	if constant && !p.check(Equal) {
+		return nil, p.getError(*p.Peek(), "Expect '=' after constant name, constants must be initialized.")
+	}
 	if p.match(Equal) {
 		init, err = p.expression()
 		if err != nil {
@@ -150,8 +344,12 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 		return nil, err
 	}
 	return &VarStmt{
-		name: name,
-		init: init,
+		name:     name,
+		typ:      typ,
+		init:     init,
+		keyword:  *keyword,
+		constant: constant,
+		end:      *p.previous(),
 	}, nil
 }
 
@@ -182,6 +380,12 @@ func (p *Parser) statement() (Stmt, error) {
 			return nil, err
 		}
 		return rStmt, nil
+	case p.match(YieldTok):
+		yStmt, err := p.yieldStatement()
+		if err != nil {
+			return nil, err
+		}
+		return yStmt, nil
 	case p.match(WhileTok):
 		wStmt, err := p.whileStatement()
 		if err != nil {
@@ -195,6 +399,10 @@ func (p *Parser) statement() (Stmt, error) {
 		}
 		return &BlockStmt{statements: block}, nil
 	}
+	if p.isDestructuringAssignment() {
+		p.advance()
+		return p.destructuring(Token{}, *p.previous())
+	}
 	// otherwise: look for an expression statement
 	estmt, expErr := p.exprStmt()
 	if expErr != nil {
@@ -211,7 +419,7 @@ func (p *Parser) returnStatement() (Stmt, error) {
 	var val Expr
 	var err error
 	if !p.check(Semicolon) {
-		val, err = p.expression()
+		val, err = p.expressionList()
 		if err != nil {
 			return nil, err
 		}
@@ -226,12 +434,31 @@ func (p *Parser) returnStatement() (Stmt, error) {
 	}, nil
 }
 
+// yieldStatement() parses a yield statement, whether it is inside a generator is checked when it runs
+func (p *Parser) yieldStatement() (Stmt, error) {
+	keyword := p.previous()
+	val, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(Semicolon, "Expect ';' after yield value.")
+	if err != nil {
+		return nil, err
+	}
+	return &YieldStmt{keyword: *keyword, val: val}, nil
+}
+
 // forStatement() parses any valid for statement from the input token stream
 func (p *Parser) forStatement() (Stmt, error) {
+	forTkn := *p.previous()
 	err := p.consume(LeftParen, "Expect '(' after 'for'.")
 	if err != nil {
 		return nil, err
 	}
+	// for (var x in collection) loops are their own statement
+	if p.check(VarTok) && p.checkAhead(2, InTok) {
+		return p.forInStatement()
+	}
 	// consume the initializer
 	var init Stmt
 	if p.match(Semicolon) {
@@ -260,7 +487,7 @@ func (p *Parser) forStatement() (Stmt, error) {
 	// consume the increment
 	var increment Expr
 	if !p.check(RightParen) {
-		increment, err = p.expression()
+		increment, err = p.comma()
 		if err != nil {
 			return nil, err
 		}
@@ -281,11 +508,12 @@ func (p *Parser) forStatement() (Stmt, error) {
 	}
 	// an omitted condition expression is assumed to be true
 	if condition == nil {
-		condition = &Literal{true}
+		condition = &Literal{val: true}
 	}
 	body = &WhileStmt{
 		condition: condition,
 		statement: body,
+		forTkn:    forTkn,
 	}
 	if init != nil {
 		// create a new block that contains the initializer statement followed by the loop body (with increment expression)
@@ -294,6 +522,34 @@ func (p *Parser) forStatement() (Stmt, error) {
 	return body, nil
 }
 
+// forInStatement() parses the rest of a for-in loop, the opening paren has already been consumed
+func (p *Parser) forInStatement() (Stmt, error) {
+	p.advance() // 'var'
+	err := p.consume(Identifier, "Expect loop variable name.")
+	if err != nil {
+		return nil, err
+	}
+	name := p.previous()
+	p.advance() // 'in'
+	collection, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(RightParen, "Expect ')' after for-in collection.")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return &ForInStmt{
+		name:       *name,
+		collection: collection,
+		body:       body,
+	}, nil
+}
+
 // whileStatement() parses a simple while loop structure from the token stream
 func (p *Parser) whileStatement() (Stmt, error) {
 	// check left paren
@@ -359,7 +615,8 @@ func (p *Parser) ifStatement() (Stmt, error) {
 func (p *Parser) block() ([]Stmt, error) {
 	statements := make([]Stmt, 0)
 	for !p.check(RightBrace) && !p.isAtEnd() {
-		statements = append(statements, p.declaration())
+		start := p.current
+		statements = append(statements, p.progress(start, p.declaration()))
 	}
 	err := p.consume(RightBrace, "Expect '}' after block")
 	if err != nil {
@@ -398,6 +655,25 @@ func (p *Parser) exprStmt() (Stmt, error) {
 	}, nil
 }
 
+// comma() parses the C-style comma operator, evaluating each operand in turn and yielding the last one.
+// A bare comma already separates arguments and the values of 'return a, b;', so this is only used inside
+// parentheses and in the increment clause of a for loop.
+func (p *Parser) comma() (Expr, error) {
+	exp, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(Comma) {
+		op := p.previous()
+		right, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		exp = &BinaryExpr{left: exp, op: *op, right: right}
+	}
+	return exp, nil
+}
+
 func (p *Parser) expression() (Expr, error) {
 	asg, err := p.assignment()
 	if err != nil {
@@ -409,7 +685,7 @@ func (p *Parser) expression() (Expr, error) {
 // assignment generates a Assign token for an assignment expr
 // the return value is the expression that represents the assignment target
 func (p *Parser) assignment() (Expr, error) {
-	orRes, err := p.or()
+	orRes, err := p.pipeline()
 	if err != nil {
 		return nil, err
 	}
@@ -424,14 +700,66 @@ func (p *Parser) assignment() (Expr, error) {
 			return &AssignExpr{
 				name: varTok.name,
 				val:  val,
+				op:   *eqtok,
 			}, nil
 		} else {
-			errorTok(*eqtok, "Invalid assignment target")
+			p.reporter.errorTok(*eqtok, "Invalid assignment target")
 		}
 	}
 	return orRes, nil
 }
 
+// pipeline parses 'value |> f |> g(2)', which is rewritten into the calls 'g(f(value), 2)': the value is
+// passed as the first argument of a call on the right, anything else on the right is called with the value
+func (p *Parser) pipeline() (Expr, error) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(PipeGreater) {
+		op := p.previous()
+		right, err := p.coalesce()
+		if err != nil {
+			return nil, err
+		}
+		if call, ok := right.(*CallExpr); ok {
+			expr = &CallExpr{
+				callee:    call.callee,
+				paren:     call.paren,
+				arguments: append([]Expr{expr}, call.arguments...),
+			}
+			continue
+		}
+		expr = &CallExpr{
+			callee:    right,
+			paren:     *op,
+			arguments: []Expr{expr},
+		}
+	}
+	return expr, nil
+}
+
+// coalesce parses 'a ?? b', which binds looser than 'or' so 'a or b ?? c' is '(a or b) ?? c'
+func (p *Parser) coalesce() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(QuestionQuestion) {
+		op := p.previous()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		expr = &LogicalExpr{
+			left:  expr,
+			right: right,
+			op:    *op,
+		}
+	}
+	return expr, nil
+}
+
 // or() parses any number of logical OR expressions
 func (p *Parser) or() (Expr, error) {
 	expr, err := p.and()
@@ -480,8 +808,16 @@ func (p *Parser) equality() (Expr, error) {
 		return nil, err
 	}
 	// left-associatively group equality expressions
-	for p.match(BangEqual, EqualEqual) {
+	for p.match(BangEqual, EqualEqual, IsTok) {
 		op := p.previous()
+		if op.toktype == IsTok {
+			err = p.consume(Identifier, "Expect type name after 'is'.")
+			if err != nil {
+				return nil, err
+			}
+			exp = &IsExpr{val: exp, keyword: *op, typeName: *p.previous()}
+			continue
+		}
 		right, err := p.comparison()
 		if err != nil {
 			return nil, err
@@ -501,7 +837,7 @@ func (p *Parser) comparison() (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	for p.match(Greater, GreaterEqual, Less, LessEqual) {
+	for p.match(Greater, GreaterEqual, Less, LessEqual, InTok) {
 		op := p.previous()
 		right, err := p.term()
 		if err != nil {
@@ -544,7 +880,7 @@ func (p *Parser) factor() (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	for p.match(Star, Slash) {
+	for p.match(Star, Slash, Percent) {
 		op := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -593,6 +929,11 @@ func (p *Parser) call() (Expr, error) {
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(LeftBracket) {
+			exp, err = p.finishIndex(exp)
+			if err != nil {
+				return nil, err
+			}
 		} else {
 			break
 		}
@@ -600,6 +941,32 @@ func (p *Parser) call() (Expr, error) {
 	return exp, nil
 }
 
+// finishIndex parses the subscript or slice following an opening bracket
+func (p *Parser) finishIndex(object Expr) (Expr, error) {
+	index := &IndexExpr{object: object, bracket: *p.previous()}
+	var err error
+	if !p.check(Colon) {
+		index.start, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.match(Colon) {
+		index.slice = true
+		if !p.check(RightBracket) {
+			index.end, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = p.consume(RightBracket, "Expect ']' after index.")
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
 // finishCall collects any arguments to a function call and returns the
 // appropriate CallExpr struct
 func (p *Parser) finishCall(callee Expr) (Expr, error) {
@@ -610,7 +977,7 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 		for ok := true; ok; ok = p.match(Comma) {
 			if len(args) >= 255 {
 				// report an error here ... BUT don't panic (no need to synchronize)
-				errorTok(*p.Peek(), "Can't have more than 255 arguments.")
+				p.reporter.errorTok(*p.Peek(), "Can't have more than 255 arguments.")
 			}
 			exp, err := p.expression()
 			if err != nil {
@@ -634,13 +1001,17 @@ func (p *Parser) primary() (Expr, error) {
 	// match a number of different types of literals
 	switch {
 	case p.match(FalseTok):
-		return &Literal{val: false}, nil
+		return &Literal{val: false, tkn: *p.previous()}, nil
 	case p.match(TrueTok):
-		return &Literal{val: true}, nil
+		return &Literal{val: true, tkn: *p.previous()}, nil
 	case p.match(NilTok):
-		return &Literal{val: nil}, nil
+		return &Literal{val: nil, tkn: *p.previous()}, nil
 	case p.match(Number, StringTok):
-		return &Literal{p.previous().literal}, nil
+		return &Literal{val: p.previous().literal, tkn: *p.previous()}, nil
+	}
+	// 'set' followed by a brace starts a set literal, otherwise it is the set() native
+	if p.check(Identifier) && p.Peek().lexeme == "set" && p.checkAhead(1, LeftBrace) {
+		return p.setLiteral()
 	}
 	// check for a variable usage
 	if p.match(Identifier) {
@@ -648,7 +1019,7 @@ func (p *Parser) primary() (Expr, error) {
 	}
 	// enforce matching parens
 	if p.match(LeftParen) {
-		exp, err := p.expression()
+		exp, err := p.comma()
 		if err != nil {
 			return nil, err
 		}
@@ -660,7 +1031,31 @@ func (p *Parser) primary() (Expr, error) {
 		return &Grouping{exp: exp}, nil
 	}
 	// current token can not be used to start an expression
-	return nil, getError(*p.Peek(), "Expected expression.")
+	err := p.getError(*p.Peek(), "Expected expression.")
+	if p.tolerant {
+		return &BadExpr{tkn: *p.Peek()}, nil
+	}
+	return nil, err
+}
+
+// setLiteral parses 'set{elem, ...}', the elements are optional
+func (p *Parser) setLiteral() (Expr, error) {
+	set := &SetExpr{keyword: *p.advance()}
+	p.advance() // '{'
+	if !p.check(RightBrace) {
+		for ok := true; ok; ok = p.match(Comma) {
+			exp, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			set.elems = append(set.elems, exp)
+		}
+	}
+	err := p.consume(RightBrace, "Expect '}' after set elements.")
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
 }
 
 // consume matches the given token type or panic
@@ -671,7 +1066,20 @@ func (p *Parser) consume(typ TokenType, fails string) error {
 		p.advance()
 		return nil
 	}
-	return getError(*p.Peek(), fails)
+	if typ == Semicolon && p.current > 0 {
+		// a forgotten ';' can be fixed by putting one right after the previous token
+		prev := p.previous()
+		pos := Position{line: prev.line, col: prev.col + len(prev.lexeme)}
+		p.fixes = append(p.fixes, &Fix{
+			description: "insert missing ';'",
+			edits:       []TextEdit{{start: pos, end: pos, text: ";"}},
+		})
+	}
+	err := p.getError(*p.Peek(), fails)
+	if p.tolerant && closingTokens[typ] {
+		return nil
+	}
+	return err
 }
 
 // synchronize discard tokens from the parsers' input token steam
@@ -688,7 +1096,7 @@ func (p *Parser) synchronize() {
 			return
 		case Fun:
 			return
-		case VarTok:
+		case VarTok, ConstTok, ImportTok:
 			return
 		case ForTok:
 			return
@@ -698,7 +1106,7 @@ func (p *Parser) synchronize() {
 			return
 		case PrintTok:
 			return
-		case ReturnTok:
+		case ReturnTok, YieldTok:
 			return
 		}
 		// otherwise, discard current token.
@@ -707,8 +1115,8 @@ func (p *Parser) synchronize() {
 }
 
 // getError generates an error
-func getError(tok Token, msg string) error {
-	errorTok(tok, msg) // record invalid token
+func (p *Parser) getError(tok Token, msg string) error {
+	p.reporter.errorTok(tok, msg) // record invalid token
 	return errors.New(msg)
 }
 
@@ -740,6 +1148,14 @@ func (p *Parser) check(typ TokenType) bool {
 	return p.Peek().toktype == typ
 }
 
+// checkAhead compares the token 'n' positions past the next one to a given token type
+func (p *Parser) checkAhead(n int, typ TokenType) bool {
+	if p.current+n >= len(p.inputTokens) {
+		return false
+	}
+	return p.inputTokens[p.current+n].toktype == typ
+}
+
 // isAtEnd returns true if the next token is EOF
 func (p *Parser) isAtEnd() bool {
 	return p.Peek().toktype == EOF
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestTolerantParse checks that incomplete code still yields the declarations around the error
+func TestTolerantParse(t *testing.T) {
+	var errs bytes.Buffer
+	r := &ErrorReporter{out: &errs}
+	stmts := parseTolerant("var a = ;\n) fun f(x) {\n  print x(1,\n", r)
+	if !r.hadError {
+		t.Fatalf("Syntax errors weren't reported\n")
+	}
+	if len(stmts) != 3 {
+		t.Fatalf("Wanted 3 statements, got %d: %v\n", len(stmts), stmts)
+	}
+	if v, ok := stmts[0].(*VarStmt); !ok || v.init == nil {
+		t.Errorf("Declaration without initializer wasn't kept: %#v\n", stmts[0])
+	} else if _, ok := v.init.(*BadExpr); !ok {
+		t.Errorf("Missing initializer isn't a BadExpr: %#v\n", v.init)
+	}
+	if bad, ok := stmts[1].(*BadStmt); !ok || bad.from.lexeme != ")" {
+		t.Errorf("Stray ')' wasn't skipped: %#v\n", stmts[1])
+	}
+	if f, ok := stmts[2].(*FunctionStmt); !ok || len(f.body) != 1 {
+		t.Errorf("Unterminated function lost its body: %#v\n", stmts[2])
+	}
+}
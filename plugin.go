@@ -0,0 +1,86 @@
+package main
+
+import "sort"
+
+// Plugin extends glox without changing its core files. Plugins are compiled in: a file of package main
+// registers one from its init() with RegisterPlugin, and every interpreter created afterwards calls its hooks.
+// Embed BasePlugin to only implement the hooks a plugin needs.
+type Plugin interface {
+	// Name identifies the plugin, 'glox -version' lists the registered ones
+	Name() string
+	// Init is called for every new interpreter once the natives and the prelude are defined, e.g. to define
+	// more natives in in.Globals() or to install a write interceptor
+	Init(in *Interpreter)
+	// OnStatement is called before the interpreter executes a statement
+	OnStatement(in *Interpreter, s Stmt)
+	// OnNativeCall is called before a native function is called, args are the evaluated arguments
+	OnNativeCall(in *Interpreter, name string, args []interface{})
+	// OnDiagnostic is called for every syntax, static or runtime error of the scripts glox runs. id is the
+	// diagnostic's id in the catalog ("" if it has none) and msg its English message.
+	OnDiagnostic(line int, id, msg string)
+}
+
+// Linter is implemented by plugins that add lints, their warnings are reported by 'glox check' and in the
+// workspace like the built-in ones
+type Linter interface {
+	Lint(stmts []Stmt) []Warning
+}
+
+// BasePlugin implements every hook of Plugin as a no-op
+type BasePlugin struct{}
+
+func (BasePlugin) Init(in *Interpreter)                                          {}
+func (BasePlugin) OnStatement(in *Interpreter, s Stmt)                           {}
+func (BasePlugin) OnNativeCall(in *Interpreter, name string, args []interface{}) {}
+func (BasePlugin) OnDiagnostic(line int, id, msg string)                         {}
+
+// plugins are the registered plugins, in the order they were registered
+var plugins []Plugin
+
+// RegisterPlugin adds a plugin to the registry. It is meant to be called from init(), interpreters that
+// already exist don't see it. Registering two plugins with the same name panics.
+func RegisterPlugin(p Plugin) {
+	for _, registered := range plugins {
+		if registered.Name() == p.Name() {
+			panic("glox: plugin " + p.Name() + " registered twice")
+		}
+	}
+	plugins = append(plugins, p)
+}
+
+// pluginNames returns the names of the registered plugins, sorted
+func pluginNames() []string {
+	names := make([]string, len(plugins))
+	for i, p := range plugins {
+		names[i] = p.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// initPlugins hands a new interpreter to every registered plugin, it keeps its own copy of the registry
+// so the hooks of the hot paths don't see later registrations half way through a run
+func (in *Interpreter) initPlugins() {
+	in.plugins = append([]Plugin(nil), plugins...)
+	for _, p := range in.plugins {
+		p.Init(in)
+	}
+}
+
+// lintPlugins runs the lints of the plugins that implement Linter
+func lintPlugins(stmts []Stmt) []Warning {
+	var warnings []Warning
+	for _, p := range plugins {
+		if l, ok := p.(Linter); ok {
+			warnings = append(warnings, l.Lint(stmts)...)
+		}
+	}
+	return warnings
+}
+
+// notifyDiagnostic tells every registered plugin about an error
+func notifyDiagnostic(line int, id, msg string) {
+	for _, p := range plugins {
+		p.OnDiagnostic(line, id, msg)
+	}
+}
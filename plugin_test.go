@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// tracePlugin counts the statements and native calls of the interpreters it is registered with
+type tracePlugin struct {
+	BasePlugin
+	statements int
+	calls      []string
+}
+
+func (p *tracePlugin) Name() string { return "trace" }
+
+func (p *tracePlugin) Init(in *Interpreter) {
+	in.Globals().Define("answer", &NativeFunction{"answer", 0, func(in *Interpreter, args []interface{}) interface{} {
+		return int64(42)
+	}})
+}
+
+func (p *tracePlugin) OnStatement(in *Interpreter, s Stmt) { p.statements++ }
+
+func (p *tracePlugin) OnNativeCall(in *Interpreter, name string, args []interface{}) {
+	p.calls = append(p.calls, name)
+}
+
+func (p *tracePlugin) Lint(stmts []Stmt) []Warning {
+	return []Warning{{tkn: Token{lexeme: "answer", line: 1}, msg: "Traced."}}
+}
+
+// TestPlugin checks that a registered plugin can define natives, sees statements and native calls and adds lints
+func TestPlugin(t *testing.T) {
+	p := &tracePlugin{}
+	defer func(registered []Plugin) { plugins = registered }(plugins)
+	RegisterPlugin(p)
+	in, out := bufferedInterpreter()
+	stmts := parse("var a = answer(); print a;", in.reporter)
+	in.Interpret(stmts)
+	if out.String() != "42\n" {
+		t.Errorf("Plugin native printed %q\n", out.String())
+	}
+	if p.statements != 2 || len(p.calls) != 1 || p.calls[0] != "answer" {
+		t.Errorf("Plugin saw %d statements and the calls %v\n", p.statements, p.calls)
+	}
+	if warnings := lintAll(stmts); len(warnings) != 1 || warnings[0].msg != "Traced." {
+		t.Errorf("Plugin lint wasn't run: %v\n", warnings)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	_ "embed"
+	"os"
+	"sync"
+)
+
+// PreludeVersion is the version of the prelude, it changes whenever a prelude function is added or changed
+const PreludeVersion = "1.1"
+
+// preludeSource holds helper functions written in Lox that every interpreter starts with
+//
+//go:embed prelude/prelude.lox
+var preludeSource string
+
+var (
+	preludeOnce  sync.Once
+	preludeStmts []Stmt
+)
+
+// prelude parses the prelude once, its functions are marked internal so errors inside them are
+// reported where the script called them
+func prelude() []Stmt {
+	preludeOnce.Do(func() {
+		preludeStmts = parse(preludeSource, &ErrorReporter{out: os.Stderr})
+		Inspect(preludeStmts, func(node interface{}) bool {
+			if fn, ok := node.(*FunctionStmt); ok {
+				fn.internal = true
+			}
+			return true
+		})
+	})
+	return preludeStmts
+}
+
+// loadPrelude defines the prelude's functions in the interpreter's global environment
+func (in *Interpreter) loadPrelude() {
+	in.resolve(prelude())
+	for _, stmt := range prelude() {
+		in.execute(stmt)
+	}
+	// the script's step limit doesn't include the prelude
+	in.steps = 0
+}
+
+// fail(message) stops the script with a runtime error, used by assert() and unwrap()
+func nativeFail(in *Interpreter, args []interface{}) interface{} {
+	return RuntimeError{msg: in.stringify(args[0])}
+}
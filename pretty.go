@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultInspectDepth is how deep the REPL's verbose mode looks into nested values
+const defaultInspectDepth = 3
+
+// inspectValue formats v for a developer rather than for print: every value is annotated with its
+// type, strings are quoted, collections show their size and are only expanded 'depth' levels deep
+// ('...' stands for the rest). A collection that contains itself is shown as '<cycle>'.
+func inspectValue(v interface{}, depth int, seen map[interface{}]bool) string {
+	switch val := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool " + strconv.FormatBool(val)
+	case int64:
+		return "int " + strconv.FormatInt(val, 10)
+	case float64:
+		return "float " + strconv.FormatFloat(val, 'g', -1, 64)
+	case string:
+		return "string " + strconv.Quote(val)
+	case LoxTime:
+		return "time " + val.String()
+	case *LoxBytes:
+		return fmt.Sprintf("%vbytes(%d) %x", frozenMark(val.frozen), len(val.b), val.b)
+	case *LoxFunction:
+		return "function " + val.name.lexeme + "/" + strconv.Itoa(len(val.params))
+	case *NativeFunction:
+		return "native " + val.name + "/" + strconv.Itoa(val.nargs)
+	case *LoxGenerator:
+		return "generator " + val.fn.name.lexeme
+	case *LoxCoroutine:
+		return "coroutine " + val.fn.name.lexeme + " " + val.status
+	case Tuple:
+		return "tuple(" + strconv.Itoa(len(val)) + ") " + inspectElems(val, "(", ")", depth, seen)
+	case *LoxSet:
+		if seen[val] {
+			return "<cycle>"
+		}
+		seen[val] = true
+		defer delete(seen, val)
+		return frozenMark(val.frozen) + "set(" + strconv.Itoa(len(val.elems)) + ") " + inspectElems(val.elems, "{", "}", depth, seen)
+	}
+	return fmt.Sprintf("%T %v", v, v)
+}
+
+// inspectElems formats the elements of a collection between open and close
+func inspectElems(elems []interface{}, open, close string, depth int, seen map[interface{}]bool) string {
+	if len(elems) == 0 {
+		return open + close
+	}
+	if depth <= 0 {
+		return open + "..." + close
+	}
+	strs := make([]string, len(elems))
+	for i, elem := range elems {
+		strs[i] = inspectValue(elem, depth-1, seen)
+	}
+	return open + strings.Join(strs, ", ") + close
+}
+
+// frozenMark prefixes frozen values
+func frozenMark(frozen bool) string {
+	if frozen {
+		return "frozen "
+	}
+	return ""
+}
+
+// inspect(v, depth) returns a description of v for debugging, see inspectValue
+func nativeInspect(in *Interpreter, args []interface{}) interface{} {
+	depth, ok := args[1].(int64)
+	if !ok || depth < 0 {
+		return RuntimeError{msg: "inspect() expects a value and a non-negative integer depth."}
+	}
+	return inspectValue(args[0], int(depth), make(map[interface{}]bool))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// maxShrinkSteps bounds how long forall() spends minimizing a failing input
+const maxShrinkSteps = 1000
+
+// Generator produces random inputs for forall() and knows how to make a failing input smaller
+type Generator struct {
+	kind     string
+	generate func(r *rand.Rand) interface{}
+	// shrink returns "smaller" candidates for v, simplest first
+	shrink func(v interface{}) []interface{}
+}
+
+func (g *Generator) String() string {
+	return "<generator " + g.kind + ">"
+}
+
+// forall(gen, property, iterations) calls property with 'iterations' random inputs from gen.
+// A property fails if it returns a falsey value or raises a runtime error; the failing input is shrunk
+// to a minimal counterexample and reported as a runtime error. Returns true if every input passed.
+func nativeForall(in *Interpreter, args []interface{}) interface{} {
+	gen, ok := args[0].(*Generator)
+	if !ok {
+		return RuntimeError{msg: "forall() expects a generator as its first argument."}
+	}
+	prop, ok := args[1].(LoxCaller)
+	if !ok || prop.arity() != 1 {
+		return RuntimeError{msg: "forall() expects a property function of one argument."}
+	}
+	iterations, ok := toFloat(args[2])
+	if !ok || iterations < 1 {
+		return RuntimeError{msg: "forall() iteration count must be a positive number."}
+	}
+	seed := time.Now().UnixNano()
+	r := rand.New(rand.NewSource(seed))
+	for i := 1; i <= int(iterations); i++ {
+		input := gen.generate(r)
+		if holds, _ := checkProperty(in, prop, input); holds {
+			continue
+		}
+		shrunk, reason := shrinkInput(in, gen, prop, input)
+		return RuntimeError{msg: fmt.Sprintf("Property failed after %d tests for input %v (shrunk from %v, seed %d): %v",
+			i, in.describe(shrunk), in.describe(input), seed, reason)}
+	}
+	return true
+}
+
+// checkProperty calls the property with a single input, the reason explains a failure
+func checkProperty(in *Interpreter, prop LoxCaller, input interface{}) (bool, string) {
+	result := prop.call(in, []interface{}{input})
+	if rerr, ok := result.(RuntimeError); ok {
+		return false, rerr.msg
+	}
+	if !in.isTruthy(result) {
+		return false, "property returned " + in.stringify(result)
+	}
+	return true, ""
+}
+
+// shrinkInput greedily replaces the failing input with the first smaller candidate that still fails
+func shrinkInput(in *Interpreter, gen *Generator, prop LoxCaller, input interface{}) (interface{}, string) {
+	_, reason := checkProperty(in, prop, input)
+	for step := 0; step < maxShrinkSteps; step++ {
+		smaller := false
+		for _, candidate := range gen.shrink(input) {
+			if in.isEqual(candidate, input) {
+				continue
+			}
+			if holds, why := checkProperty(in, prop, candidate); !holds {
+				input, reason, smaller = candidate, why, true
+				break
+			}
+		}
+		if !smaller {
+			break
+		}
+	}
+	return input, reason
+}
+
+// describe formats a value for error messages, strings are quoted so "" and " " are visible
+func (in *Interpreter) describe(val interface{}) string {
+	if str, ok := val.(string); ok {
+		return fmt.Sprintf("%q", str)
+	}
+	return in.stringify(val)
+}
+
+// genInt(lo, hi) generates integers between lo and hi (inclusive), shrinking towards 0
+func nativeGenInt(in *Interpreter, args []interface{}) interface{} {
+	flo, lok := toFloat(args[0])
+	fhi, hok := toFloat(args[1])
+	if !lok || !hok || math.Ceil(flo) > math.Floor(fhi) {
+		return RuntimeError{msg: "genInt() expects two numbers with lo <= hi."}
+	}
+	lo, hi := int64(math.Ceil(flo)), int64(math.Floor(fhi))
+	// the simplest value in range is the one closest to zero
+	target := lo
+	if lo < 0 {
+		target = 0
+		if hi < 0 {
+			target = hi
+		}
+	}
+	return &Generator{
+		kind: "int",
+		generate: func(r *rand.Rand) interface{} {
+			return lo + r.Int63n(hi-lo+1)
+		},
+		shrink: func(v interface{}) []interface{} {
+			n := v.(int64)
+			if n == target {
+				return nil
+			}
+			step := int64(1)
+			if n > target {
+				step = -1
+			}
+			candidates := []interface{}{target}
+			if half := n - (n-target)/2; half != target && half != n {
+				candidates = append(candidates, half)
+			}
+			if n+step != target {
+				candidates = append(candidates, n+step)
+			}
+			return candidates
+		},
+	}
+}
+
+// genString(maxLen) generates printable ASCII strings of up to maxLen characters, shrinking towards ""
+func nativeGenString(in *Interpreter, args []interface{}) interface{} {
+	maxLen, ok := toFloat(args[0])
+	if !ok || maxLen < 0 {
+		return RuntimeError{msg: "genString() expects a non-negative maximum length."}
+	}
+	return &Generator{
+		kind: "string",
+		generate: func(r *rand.Rand) interface{} {
+			buf := make([]byte, r.Intn(int(maxLen)+1))
+			for i := range buf {
+				buf[i] = byte(' ' + r.Intn('~'-' '+1))
+			}
+			return string(buf)
+		},
+		shrink: func(v interface{}) []interface{} {
+			str := v.(string)
+			if str == "" {
+				return nil
+			}
+			candidates := []interface{}{"", str[:len(str)/2], str[len(str)/2:]}
+			// drop single characters, then simplify them
+			for i := range str {
+				candidates = append(candidates, str[:i]+str[i+1:])
+			}
+			for i := range str {
+				if str[i] != 'a' {
+					candidates = append(candidates, str[:i]+"a"+str[i+1:])
+				}
+			}
+			return candidates
+		},
+	}
+}
+
+// genBool() generates true or false, shrinking towards false
+func nativeGenBool(in *Interpreter, args []interface{}) interface{} {
+	return &Generator{
+		kind: "bool",
+		generate: func(r *rand.Rand) interface{} {
+			return r.Intn(2) == 1
+		},
+		shrink: func(v interface{}) []interface{} {
+			if v.(bool) {
+				return []interface{}{false}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// The natives here read from the operating system's secure random source (crypto/rand), so unlike the
+// quickcheck generators their values can't be reproduced and are fit for tokens and identifiers.
+
+// uuid() returns a random (version 4) UUID in its usual 8-4-4-4-12 hex form
+func nativeUUID(in *Interpreter, args []interface{}) interface{} {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return RuntimeError{msg: "Can't read random bytes: " + err.Error()}
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// randomHex(n) returns n random bytes as a hex string of 2n characters
+func nativeRandomHex(in *Interpreter, args []interface{}) interface{} {
+	n, ok := args[0].(int64)
+	if !ok || n < 0 {
+		return RuntimeError{msg: "randomHex() expects a non-negative integer number of bytes."}
+	}
+	if n > maxBytesLength {
+		return bytesLimitError(n)
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return RuntimeError{msg: "Can't read random bytes: " + err.Error()}
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// runRefs implements the 'refs' subcommand: given file.lox:line:col it lists the declaration
+// and every use of the symbol found at that position.
+func runRefs(args []string) {
+	if len(args) != 1 {
+		fmt.Println("usage: glox.exe refs [script]:[line]:[col]")
+		os.Exit(64)
+	}
+	path, line, col, err := splitPosition(args[0])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	table := NewSymbolTable(parseFile(path))
+	sym := table.SymbolAt(line, col)
+	if sym == nil {
+		fmt.Printf("No symbol at %v:%d:%d.\n", path, line, col)
+		os.Exit(1)
+	}
+	fmt.Printf("%v:%d:%d: declaration of '%v'\n", path, sym.name.line, sym.name.col, sym.name.lexeme)
+	for _, ref := range sym.References() {
+		kind := "read"
+		if ref.write {
+			kind = "write"
+		}
+		fmt.Printf("%v:%d:%d: %v\n", path, ref.tkn.line, ref.tkn.col, kind)
+	}
+}
+
+// References returns every use of the symbol in source order
+func (s *Symbol) References() []*Reference {
+	refs := append([]*Reference(nil), s.refs...)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].tkn.line != refs[j].tkn.line {
+			return refs[i].tkn.line < refs[j].tkn.line
+		}
+		return refs[i].tkn.col < refs[j].tkn.col
+	})
+	return refs
+}
+
+// splitPosition splits a "path:line:col" argument. The path itself may contain colons (C:\...)
+func splitPosition(pos string) (string, int, int, error) {
+	parts := strings.Split(pos, ":")
+	if len(parts) < 3 {
+		return "", 0, 0, fmt.Errorf("Expect position of the form file:line:col, got '%v'.", pos)
+	}
+	line, lerr := strconv.Atoi(parts[len(parts)-2])
+	col, cerr := strconv.Atoi(parts[len(parts)-1])
+	if lerr != nil || cerr != nil {
+		return "", 0, 0, fmt.Errorf("Invalid line or column in '%v'.", pos)
+	}
+	if line < 1 || col < 1 {
+		return "", 0, 0, fmt.Errorf("Lines and columns start at 1, got '%v'.", pos)
+	}
+	return strings.Join(parts[:len(parts)-2], ":"), line, col, nil
+}
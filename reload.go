@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Reload re-reads a script and swaps its top-level functions into the running program, see ReloadSource
+func (in *Interpreter) Reload(path string) ([]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open file at [%v]", path)
+	}
+	return in.ReloadSource(string(contents))
+}
+
+// ReloadSource replaces the global functions of a long-running session with the top-level functions declared
+// in source, e.g. after they were edited, and adds the new ones. Nothing else in source runs, so every global
+// variable keeps its value. The swap is all or nothing: if source has errors, declares a function whose
+// name is a global variable or constant, or the write interceptor vetoes one of the functions, nothing is replaced. Values that hold an old function (e.g. a callback that was
+// stored in a variable) keep calling it. It returns the names of the functions it defined, in source order.
+func (in *Interpreter) ReloadSource(source string) ([]string, error) {
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out, lang: in.reporter.lang, dialect: in.reporter.dialect}
+	stmts := parse(source, r)
+	funcs := make([]Stmt, 0)
+	for _, stmt := range stmts {
+		if f, ok := stmt.(*FunctionStmt); ok {
+			funcs = append(funcs, f)
+		}
+	}
+	if r.hadError || !in.prepare(funcs, r) {
+		return nil, errors.New(strings.TrimSpace(out.String()))
+	}
+	names := make([]string, 0, len(funcs))
+	vals := make([]interface{}, 0, len(funcs))
+	for _, stmt := range funcs {
+		f := stmt.(*FunctionStmt)
+		name := f.name.lexeme
+		if decl, ok := in.globals.consts[name]; ok {
+			return nil, fmt.Errorf("'%v' is a constant (declared on line %d), reloading doesn't replace it", name, decl.line)
+		}
+		if old, ok := in.globals.bindings[name]; ok {
+			if _, isFn := old.(LoxCaller); !isFn {
+				return nil, fmt.Errorf("'%v' is a variable, reloading doesn't replace data", name)
+			}
+		}
+		val, err := in.decorate(&LoxFunction{FunctionStmt: f, closure: in.globals}, f.decorators)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		vals = append(vals, val)
+	}
+	olds := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		if old, ok := in.globals.bindings[name]; ok {
+			olds[name] = old
+		}
+	}
+	for i, name := range names {
+		if err := in.globals.Define(name, vals[i]); err != nil {
+			// the write interceptor vetoed it, undo the functions swapped in so far
+			for j := i - 1; j >= 0; j-- {
+				old, existed := olds[names[j]]
+				in.globals.restore(names[j], old, existed)
+			}
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// restore puts back the value a binding had before a write that is undone, or removes it if the write added
+// it. The write interceptor isn't asked, the change listener is told.
+func (e *Environment) restore(name string, old interface{}, existed bool) {
+	val := e.bindings[name]
+	if !existed {
+		delete(e.bindings, name)
+		if e.listen != nil {
+			e.listen(Change{Name: name, Kind: Removed, Old: val})
+		}
+		return
+	}
+	e.bindings[name] = old
+	e.notify(name, val, old, true)
+}
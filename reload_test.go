@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestReload checks that reloading replaces functions, keeps the globals' values and is all or nothing
+func TestReload(t *testing.T) {
+	in, out := bufferedInterpreter()
+	in.Interpret(parse("var score = 10; fun bonus() { return 1; }", in.reporter))
+	names, err := in.ReloadSource("var score = 0; fun bonus() { return score * 2; } fun extra() { return 3; }")
+	if err != nil || strings.Join(names, " ") != "bonus extra" {
+		t.Fatalf("ReloadSource returned %v, %v\n", names, err)
+	}
+	in.Interpret(parse("print bonus() + extra();", in.reporter))
+	if out.String() != "23\n" {
+		t.Errorf("Reloaded functions printed %q\n", out.String())
+	}
+	for _, source := range []string{"fun bonus() { return 0 }", "fun bonus() { return 0; } fun score() {}"} {
+		if _, err := in.ReloadSource(source); err == nil {
+			t.Errorf("%q was reloaded\n", source)
+		}
+	}
+	out.Reset()
+	in.Interpret(parse("print bonus() + score;", in.reporter))
+	if out.String() != "30\n" {
+		t.Errorf("A failed reload changed the session, it printed %q\n", out.String())
+	}
+}
+
+// TestReloadRollback checks that a reload that can't define every function leaves the old ones in place
+func TestReloadRollback(t *testing.T) {
+	in, out := bufferedInterpreter()
+	in.Interpret(parse("fun a() { return 1; } const b = a;", in.reporter))
+	if _, err := in.ReloadSource("fun a() { return 2; } fun b() {}"); err == nil {
+		t.Errorf("A constant was reloaded\n")
+	}
+	in.Globals().SetWriteInterceptor(func(name string, old, new interface{}) error {
+		if name == "c" {
+			return errors.New("c is reserved.")
+		}
+		return nil
+	})
+	if _, err := in.ReloadSource("fun a() { return 3; } fun c() {}"); err == nil {
+		t.Errorf("A vetoed function was reloaded\n")
+	}
+	in.Interpret(parse("print a(); print b();", in.reporter))
+	if out.String() != "1\n1\n" {
+		t.Errorf("A failed reload changed the session, it printed %q\n", out.String())
+	}
+	if _, ok := in.globals.bindings["c"]; ok {
+		t.Errorf("A failed reload added c\n")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrorReporter prints the errors found while lexing, parsing and running a single script and remembers
+// whether any occurred. Each run that has to be isolated from the others (e.g. parallel tests) gets its own.
+type ErrorReporter struct {
+	out                       io.Writer
+	hadError, hadRuntimeError bool
+	// dialect decides how runtime errors are laid out
+	dialect Dialect
+	// lang is the language of the messages, see the catalog in diagnostics.go. "" is English.
+	lang string
+}
+
+// reporter is the default ErrorReporter used by the command line driver and the REPL
+var reporter = &ErrorReporter{out: os.Stdout}
+
+// errorTok prints out the contents and location of the token that caused the parser to panic
+func (r *ErrorReporter) errorTok(tok Token, msg string) {
+	if tok.toktype == EOF {
+		r.report(tok.line, "at end", msg)
+	} else {
+		r.report(tok.line, "at '"+tok.lexeme+"'", msg)
+	}
+}
+
+// runtimeError reports an err that occurs at runtime. The location follows the first line of the
+// message, further lines (like the diff of a failed assertEqual) are printed after it.
+func (r *ErrorReporter) runtimeError(e RuntimeError) {
+	msg, details := e.msg, ""
+	if nl := strings.IndexByte(msg, '\n'); nl >= 0 {
+		msg, details = msg[:nl], msg[nl:]
+	}
+	r.notify(e.tkn.line, msg)
+	if r.dialect == GloxDialect {
+		if id, text := localize(msg, r.lang); id != "" {
+			msg = "Error [" + id + "]: " + text
+		}
+	}
+	fmt.Fprint(r.out, r.dialect.formatRuntimeError(msg, e.tkn.line, details, e.trace))
+	r.hadRuntimeError = true
+}
+
+// Report an error at a given line number
+func (r *ErrorReporter) report(line int, where, msg string) {
+	r.notify(line, msg)
+	if r.dialect == GloxDialect {
+		// the book's implementations don't number their errors
+		if id, text := localize(msg, r.lang); id != "" {
+			msg = text
+			where = strings.TrimSpace("[" + id + "] " + where)
+		}
+	}
+	if where == "" {
+		fmt.Fprintf(r.out, "[line %d] Error: %v\n", line, msg)
+	} else {
+		fmt.Fprintf(r.out, "[line %d] Error %v: %v\n", line, where, msg)
+	}
+	r.hadError = true
+}
+
+// notify passes an error on to the plugins. Only the default reporter does, the others report on code
+// being analyzed (hover, completion, ...) rather than run.
+func (r *ErrorReporter) notify(line int, msg string) {
+	if r == reporter {
+		id, _ := localize(msg, "")
+		notifyDiagnostic(line, id, msg)
+	}
+}
+
+// reset clears the error flags, used by the REPL so one bad line doesn't poison the session
+func (r *ErrorReporter) reset() {
+	r.hadError = false
+	r.hadRuntimeError = false
+}
+
+// parse lexes and parses a script, sending any errors to r
+func parse(script string, r *ErrorReporter) []Stmt {
+	stmts, _ := parseFixable(script, r)
+	return stmts
+}
+
+// parseTolerant is parse() for code that is being edited, it returns the best tree it can get instead of
+// dropping the statements with syntax errors (see Parser.tolerant)
+func parseTolerant(script string, r *ErrorReporter) []Stmt {
+	lexer := NewLexScanner(script)
+	lexer.reporter = r
+	parser := NewParser(lexer)
+	parser.reporter = r
+	parser.tolerant = true
+	return parser.Parse()
+}
+
+// parseFixable is parse() for callers that want to repair syntax errors, it also returns the parser's fixes
+func parseFixable(script string, r *ErrorReporter) ([]Stmt, []*Fix) {
+	lexer := NewLexScanner(script)
+	lexer.reporter = r
+	parser := NewParser(lexer)
+	parser.reporter = r
+	return parser.Parse(), parser.fixes
+}
@@ -0,0 +1,287 @@
+package main
+
+import "fmt"
+
+// Resolver is a static pass run between parsing and interpreting. It works out how many scopes lie between
+// every variable reference and the local declaration it refers to, so the interpreter reads and assigns the
+// right binding (see Environment.GetAt) even if a closure is called after a block declared a shadowing name.
+// References without a local declaration are globals, which are late bound and looked up by name.
+// Mistakes it can see without running the script are reported like syntax errors.
+type Resolver struct {
+	// locals receives the distance of every resolved reference, it is the interpreter's map
+	locals map[Expr]int
+	// scopes are the local scopes around the node being resolved, the innermost last
+	scopes []map[string]*localVar
+	// functions counts the function bodies around the node being resolved
+	functions int
+	reporter  *ErrorReporter
+}
+
+// localVar is a name declared in a local scope
+type localVar struct {
+	decl Token
+	// defined is false while the initializer of the variable is being resolved
+	defined bool
+}
+
+// resolve records the scope distances of the variable references of a parsed script in locals and reports
+// static errors to r, it returns false if there were any
+func resolve(stmts []Stmt, locals map[Expr]int, r *ErrorReporter) bool {
+	hadError := r.hadError
+	r.hadError = false
+	res := &Resolver{locals: locals, reporter: r}
+	res.resolveStmts(stmts)
+	ok := !r.hadError
+	r.hadError = r.hadError || hadError
+	return ok
+}
+
+// resolve prepares a script for this interpreter. It must see the statements before they are run, local
+// references it hasn't seen would be looked up as globals. With optimize set it folds constants too.
+// It returns false if the script can't run.
+func (in *Interpreter) resolve(stmts []Stmt) bool {
+	return in.prepare(stmts, in.reporter)
+}
+
+// prepare is resolve reporting to r, modules report their errors on their own
+func (in *Interpreter) prepare(stmts []Stmt, r *ErrorReporter) bool {
+	if !resolve(stmts, in.locals, r) {
+		return false
+	}
+	if in.strict && !checkStrict(stmts, in.globals, r) {
+		return false
+	}
+	if in.optimize {
+		foldConstants(stmts, in)
+	}
+	return true
+}
+
+func (r *Resolver) resolveStmts(stmts []Stmt) {
+	for _, s := range stmts {
+		if s != nil {
+			s.accept(r)
+		}
+	}
+}
+
+func (r *Resolver) resolveExpr(e Expr) {
+	if e != nil {
+		e.accept(r)
+	}
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]*localVar))
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// declare adds a name to the innermost scope, top-level names are globals and aren't tracked (so the
+// REPL can redefine them)
+func (r *Resolver) declare(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if prev, ok := scope[name.lexeme]; ok {
+		r.reporter.errorTok(name, fmt.Sprintf("Already a variable named '%v' in this scope (declared on line %d).", name.lexeme, prev.decl.line))
+		return
+	}
+	scope[name.lexeme] = &localVar{decl: name}
+}
+
+// define marks a declared name as ready for use
+func (r *Resolver) define(name Token) {
+	if len(r.scopes) > 0 {
+		r.scopes[len(r.scopes)-1][name.lexeme].defined = true
+	}
+}
+
+// resolveLocal records how far up the scope chain the declaration e refers to is
+func (r *Resolver) resolveLocal(e Expr, name Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.lexeme]; ok {
+			r.locals[e] = len(r.scopes) - 1 - i
+			return
+		}
+	}
+}
+
+func (r *Resolver) VisitPrintStmt(p *PrintStmt) error {
+	r.resolveExpr(p.exp)
+	return nil
+}
+
+func (r *Resolver) VisitExprStmt(e *ExprStmt) error {
+	r.resolveExpr(e.exp)
+	return nil
+}
+
+func (r *Resolver) VisitVarStmt(v *VarStmt) error {
+	// the new name already shadows outer ones in its initializer, reading it there is an error
+	r.declare(*v.name)
+	r.resolveExpr(v.init)
+	r.define(*v.name)
+	return nil
+}
+
+// assigning existing variables by destructuring finds them by name, only declarations matter here
+func (r *Resolver) VisitDestructureStmt(d *DestructureStmt) error {
+	if d.declare {
+		for _, name := range d.names {
+			r.declare(name)
+		}
+	}
+	r.resolveExpr(d.init)
+	if d.declare {
+		for _, name := range d.names {
+			r.define(name)
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) VisitBlockStmt(b *BlockStmt) error {
+	r.beginScope()
+	r.resolveStmts(b.statements)
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitIfStmt(i *IfStmt) error {
+	r.resolveExpr(i.exp)
+	r.resolveStmts([]Stmt{i.thenPart, i.elsePart})
+	return nil
+}
+
+func (r *Resolver) VisitWhileStmt(w *WhileStmt) error {
+	r.resolveExpr(w.condition)
+	r.resolveStmts([]Stmt{w.statement})
+	return nil
+}
+
+func (r *Resolver) VisitForInStmt(f *ForInStmt) error {
+	r.resolveExpr(f.collection)
+	r.beginScope()
+	r.declare(f.name)
+	r.define(f.name)
+	r.resolveStmts([]Stmt{f.body})
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitFunctionStmt(f *FunctionStmt) error {
+	for _, d := range f.decorators {
+		r.resolveExpr(d.expr)
+	}
+	r.declare(f.name)
+	r.define(f.name)
+	// the parameters and the body share the environment of a call
+	r.beginScope()
+	for _, param := range f.params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.functions++
+	r.resolveStmts(f.body)
+	r.functions--
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitReturnStmt(s *ReturnStmt) error {
+	if r.functions == 0 {
+		r.reporter.errorTok(s.keyword, "Can't return from top-level code.")
+	}
+	r.resolveExpr(s.val)
+	return nil
+}
+
+func (r *Resolver) VisitYieldStmt(y *YieldStmt) error {
+	r.resolveExpr(y.val)
+	return nil
+}
+
+// a module is resolved on its own when it is imported, it runs in the global environment
+func (r *Resolver) VisitImportStmt(i *ImportStmt) error { return nil }
+
+func (r *Resolver) VisitBadStmt(b *BadStmt) error { return nil }
+
+func (r *Resolver) VisitBinaryExpr(b *BinaryExpr) (interface{}, error) {
+	r.resolveExpr(b.left)
+	r.resolveExpr(b.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGrouping(g *Grouping) (interface{}, error) {
+	r.resolveExpr(g.exp)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteral(l *Literal) (interface{}, error) { return nil, nil }
+
+func (r *Resolver) VisitUnary(u *Unary) (interface{}, error) {
+	r.resolveExpr(u.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariable(v *Variable) (interface{}, error) {
+	if len(r.scopes) > 0 {
+		if local, ok := r.scopes[len(r.scopes)-1][v.name.lexeme]; ok && !local.defined {
+			r.reporter.errorTok(v.name, "Can't read local variable in its own initializer.")
+		}
+	}
+	r.resolveLocal(v, v.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssign(a *AssignExpr) (interface{}, error) {
+	r.resolveExpr(a.val)
+	r.resolveLocal(a, a.name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogical(l *LogicalExpr) (interface{}, error) {
+	r.resolveExpr(l.left)
+	r.resolveExpr(l.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCall(c *CallExpr) (interface{}, error) {
+	r.resolveExpr(c.callee)
+	for _, arg := range c.arguments {
+		r.resolveExpr(arg)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitTuple(t *TupleExpr) (interface{}, error) {
+	for _, val := range t.values {
+		r.resolveExpr(val)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitIs(i *IsExpr) (interface{}, error) {
+	r.resolveExpr(i.val)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndex(i *IndexExpr) (interface{}, error) {
+	r.resolveExpr(i.object)
+	r.resolveExpr(i.start)
+	r.resolveExpr(i.end)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSet(s *SetExpr) (interface{}, error) {
+	for _, elem := range s.elems {
+		r.resolveExpr(elem)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitBadExpr(b *BadExpr) (interface{}, error) { return nil, nil }
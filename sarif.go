@@ -0,0 +1,172 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// sarifSchema is the version of the Static Analysis Results Interchange Format written by 'vet -format sarif'
+// and 'fmt -d -format sarif', which code review tools and editors read
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifLog is the subset of SARIF glox writes: one run with a result per finding and the fixes that resolve them
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+// sarifRule describes a kind of finding, the diagnostic ids of the catalog are the rule ids
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion is a range of a file, the end column is the one after the last character like TextEdit's end
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+	Replacements     []sarifReplacement `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// newSARIF starts an empty log for one run of glox
+func newSARIF() *sarifLog {
+	driver := sarifDriver{Name: "glox", Version: Version, Rules: make([]sarifRule, 0)}
+	return &sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{{Tool: sarifTool{driver}, Results: make([]sarifResult, 0)}}}
+}
+
+// addRule describes a rule the first time one of its results is added
+func (l *sarifLog) addRule(id, text string) {
+	driver := &l.Runs[0].Tool.Driver
+	for _, rule := range driver.Rules {
+		if rule.ID == id {
+			return
+		}
+	}
+	driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{text}})
+}
+
+// ruleSummaries describe the warnings whose messages have placeholders, a rule's description is shared by
+// all of its results and can't name the function or variable of one of them
+var ruleSummaries = map[string]string{
+	"W002": "Function is never used.",
+	"W003": "Global variable is never read.",
+	"W005": "Local variable is never read.",
+	"W006": "Parameter is never read.",
+	"W007": "Declaration shadows one of an enclosing scope.",
+	"W008": "Call with the wrong number of arguments.",
+}
+
+// ruleDescription returns the short description of a diagnostic's rule, without placeholders
+func ruleDescription(d *diagnostic) string {
+	if summary, ok := ruleSummaries[d.id]; ok {
+		return summary
+	}
+	return d.text
+}
+
+// addWarning adds a lint warning of the file at path, with the fix suggested for it
+func (l *sarifLog) addWarning(path string, w Warning, level string) {
+	id, msg := localize(w.msg, reporter.lang)
+	if d := diagnosticByID(id); d != nil {
+		l.addRule(id, ruleDescription(d))
+	}
+	region := sarifRegion{w.tkn.line, w.tkn.col, w.tkn.line, w.tkn.col + len(w.tkn.lexeme)}
+	l.addResult(path, sarifResult{RuleID: id, Level: level, Message: sarifMessage{msg}}, region, w.fix)
+}
+
+// addResult adds a finding at region of the file at path and the fix resolving it, fix may be nil
+func (l *sarifLog) addResult(path string, result sarifResult, region sarifRegion, fix *Fix) {
+	artifact := sarifArtifact{URI: filepath.ToSlash(path)}
+	result.Locations = []sarifLocation{{sarifPhysicalLocation{artifact, region}}}
+	if fix != nil {
+		change := sarifArtifactChange{ArtifactLocation: artifact}
+		for _, edit := range fix.edits {
+			change.Replacements = append(change.Replacements, sarifReplacement{
+				DeletedRegion:   sarifRegion{edit.start.line, edit.start.col, edit.end.line, edit.end.col},
+				InsertedContent: sarifMessage{edit.text},
+			})
+		}
+		result.Fixes = []sarifFix{{Description: sarifMessage{fix.description}, ArtifactChanges: []sarifArtifactChange{change}}}
+	}
+	l.Runs[0].Results = append(l.Runs[0].Results, result)
+}
+
+// rewriteFix turns rewriting a whole file into a fix that only replaces the lines that differ, the edit
+// starts at the first changed line and ends after the last one
+func rewriteFix(description, before, after string) *Fix {
+	was, now := strings.SplitAfter(before, "\n"), strings.SplitAfter(after, "\n")
+	prefix := 0
+	for prefix < len(was) && prefix < len(now) && was[prefix] == now[prefix] {
+		prefix++
+	}
+	if prefix == len(was) && prefix == len(now) {
+		return nil
+	}
+	suffix := 0
+	for suffix < len(was)-prefix && suffix < len(now)-prefix && was[len(was)-1-suffix] == now[len(now)-1-suffix] {
+		suffix++
+	}
+	edit := TextEdit{
+		start: Position{prefix + 1, 1},
+		end:   Position{len(was) - suffix + 1, 1},
+		text:  strings.Join(now[prefix:len(now)-suffix], ""),
+	}
+	return &Fix{description: description, edits: []TextEdit{edit}}
+}
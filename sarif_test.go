@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRewriteFix checks that the fix for a rewritten file only spans the changed lines and reproduces the rewrite
+func TestRewriteFix(t *testing.T) {
+	before := "import \"b\";\nimport \"a\";\nprint 1;\n"
+	after := "import \"a\";\nimport \"b\";\nprint 1;\n"
+	fix := rewriteFix("organize imports", before, after)
+	if fix == nil || fix.edits[0].start != (Position{1, 1}) || fix.edits[0].end != (Position{3, 1}) {
+		t.Fatalf("Wrong fix: %+v\n", fix)
+	}
+	if got, _ := applyFixes(before, []*Fix{fix}); got != after {
+		t.Errorf("Applying the fix gives\n%v", got)
+	}
+	if rewriteFix("nothing", before, before) != nil {
+		t.Errorf("An unchanged file got a fix\n")
+	}
+}
+
+// TestRuleDescriptions checks that no warning's rule is described with placeholders
+func TestRuleDescriptions(t *testing.T) {
+	for _, d := range catalog {
+		if strings.HasPrefix(d.id, "W") && strings.Contains(ruleDescription(d), "%s") {
+			t.Errorf("%v is described as %q\n", d.id, ruleDescription(d))
+		}
+	}
+}
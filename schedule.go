@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// scheduleTimeFormat is used for log lines and the names of per-run log files
+const scheduleTimeFormat = "2006-01-02T15-04-05"
+
+// runSchedule implements the 'schedule' subcommand: it stays resident and runs a script every time the
+// cron expression matches. Every run gets a fresh interpreter and rereads the script, a run that is still
+// going when the next one is due causes that one to be skipped. SIGINT/SIGTERM stop the scheduler once the
+// current run (if any) finished.
+func runSchedule(args []string) {
+	flags := flag.NewFlagSet("schedule", flag.ExitOnError)
+	logDir := flags.String("log-dir", "", "write the output of every run to its own file in this directory instead of stdout")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		fmt.Println(`usage: glox.exe schedule [-log-dir dir] "*/5 * * * *" [script]`)
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	sched, err := ParseCron(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	path := flags.Arg(1)
+	if *logDir != "" {
+		if err := os.MkdirAll(*logDir, 0755); err != nil {
+			fmt.Printf("Can't create log directory [%v].\n", *logDir)
+			os.Exit(73)
+		}
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{}, 1)
+	running := false
+	for runs := 1; ; {
+		next, ok := sched.Next(time.Now())
+		if !ok {
+			fmt.Println("cron expression never matches, stopping")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			if running {
+				fmt.Println("stopping, waiting for the current run to finish")
+				<-done
+			}
+			return
+		case <-done:
+			timer.Stop()
+			running = false
+			continue
+		case <-timer.C:
+		}
+		if running {
+			fmt.Printf("[%v] run #%d skipped, the previous run is still going\n", next.Format(scheduleTimeFormat), runs)
+			runs++
+			continue
+		}
+		running = true
+		go func(n int, at time.Time) {
+			scheduledRun(path, n, at, *logDir)
+			done <- struct{}{}
+		}(runs, next)
+		runs++
+	}
+}
+
+// scheduledRun runs the script once in its own interpreter and logs the outcome
+func scheduledRun(path string, n int, at time.Time, logDir string) {
+	start := time.Now()
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out}
+	status := "ok"
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		status = "can't read script"
+	} else {
+		stmts := parse(string(contents), r)
+		if !r.hadError {
+			in := newMainInterpreter()
+			in.out = &out
+			in.reporter = r
+			in.dir = filepath.Dir(path)
+			in.Interpret(stmts)
+		}
+		if r.hadError || r.hadRuntimeError {
+			status = "failed"
+		}
+	}
+	stamp := at.Format(scheduleTimeFormat)
+	fmt.Printf("[%v] run #%d %v in %v\n", stamp, n, status, time.Since(start).Round(time.Millisecond))
+	if logDir == "" {
+		os.Stdout.Write(out.Bytes())
+		return
+	}
+	logPath := filepath.Join(logDir, fmt.Sprintf("%v-%d.log", stamp, n))
+	if err := ioutil.WriteFile(logPath, out.Bytes(), 0644); err != nil {
+		fmt.Printf("[%v] can't write log file [%v]\n", stamp, logPath)
+	}
+}
@@ -1,4 +1,4 @@
 @echo off
 go clean
 del /F /Q build\*
-go build -o build\glx.exe main.go tokentypes.go environment.go loxfunction.go natives.go parser.go lexer.go ast_expr.go ast_stmt.go ast_printer.go interpreter.go
\ No newline at end of file
+go build -o build\glx.exe main.go tokentypes.go environment.go loxfunction.go natives.go parser.go lexer.go ast_expr.go ast_stmt.go ast_printer.go interpreter.go inspect.go lint.go vet.go symbols.go refs.go outline.go callgraph.go metrics.go quickcheck.go clock.go reporter.go testrunner.go diff.go mutate.go fix.go numbers.go modules.go format.go formulas.go heap.go datetime.go cron.go schedule.go notebook.go completion.go crashreport.go version.go bytes.go generator.go hash.go random.go set.go clone.go pretty.go browse.go types.go index.go dialect.go grammar.go diagnostics.go bundle.go pack.go prelude.go decorators.go docs.go config.go check.go resolver.go complete.go signature.go fold.go hover.go inlay.go workspace.go typecheck.go glob.go sarif.go plugin.go coroutine.go step.go strict.go reload.go envdiff.go stacktrace.go template.go
\ No newline at end of file
@@ -37,11 +37,11 @@ def define_ast(outfile, typestrings):
     outfile.write("type Visitor interface {\n")
     for typ in typestrings:
         class_name = typ.split(':')[0].strip()
-        outfile.write(f"\tVisit{class_name}(c *{class_name})\n")
+        outfile.write(f"\tVisit{class_name}(c *{class_name}) (interface{{}}, error)\n")
     outfile.write("}\n")
     # write out Expr interface
     outfile.write("\ntype Expr interface {\n")
-    outfile.write("\taccept(Visitor)\n")
+    outfile.write("\taccept(Visitor) (interface{}, error)\n")
     outfile.write("}\n")
     # write out classes for each type of AST node
     for typ in typestrings:
@@ -59,8 +59,8 @@ def write_class(outfile, class_name, field_list):
     outfile.write("\n}\n")
     # write out visitor accept method for current class
     outfile.write(f"\n// accept method stub for {class_name}\n")
-    outfile.write("func (c * " + class_name + ") accept(v Visitor) {\n")
-    outfile.write(f"\tv.Visit{class_name}(c)\n")
+    outfile.write("func (c * " + class_name + ") accept(v Visitor) (interface{}, error) {\n")
+    outfile.write(f"\treturn v.Visit{class_name}(c)\n")
     outfile.write("}\n")
 
 
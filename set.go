@@ -0,0 +1,229 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// LoxSet is an unordered collection of distinct values created by set(). Like functions it is passed by
+// reference. Elements are iterated (and printed) in the order they were first added.
+type LoxSet struct {
+	index map[interface{}]int
+	elems []interface{}
+	// frozen sets can't be changed, see freeze()
+	frozen bool
+}
+
+// timeKey stands for a LoxTime in a set, times are the same element if they are the same instant
+type timeKey int64
+
+// newSet returns an empty set
+func newSet() *LoxSet {
+	return &LoxSet{index: make(map[interface{}]int)}
+}
+
+// setKey returns the value a set element is stored under, so that values the interpreter considers
+// equal ('1' and '1.0', the same instant in different timezones) are the same element.
+// ok is false for values that can't be set elements.
+func setKey(v interface{}) (key interface{}, ok bool) {
+	switch val := v.(type) {
+	case nil, bool, string, int64:
+		return val, true
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<63 {
+			return int64(val), true
+		}
+		return val, true
+	case LoxTime:
+		return timeKey(val.t.UnixNano()), true
+	case *LoxBytes, *LoxSet, Tuple:
+		// these can change after they were added, which would break the set, and Go can't hash tuples
+		return nil, false
+	}
+	// functions and generators are compared by identity
+	return v, true
+}
+
+func (s *LoxSet) has(key interface{}) bool {
+	_, ok := s.index[key]
+	return ok
+}
+
+// add inserts v under key if it isn't an element yet
+func (s *LoxSet) add(key, v interface{}) {
+	if !s.has(key) {
+		s.index[key] = len(s.elems)
+		s.elems = append(s.elems, v)
+	}
+}
+
+// remove deletes the element stored under key and reports whether there was one
+func (s *LoxSet) remove(key interface{}) bool {
+	i, ok := s.index[key]
+	if !ok {
+		return false
+	}
+	delete(s.index, key)
+	s.elems = append(s.elems[:i], s.elems[i+1:]...)
+	for k, j := range s.index {
+		if j > i {
+			s.index[k] = j - 1
+		}
+	}
+	return true
+}
+
+// filter returns a new set with the elements of s for which keep returns true
+func (s *LoxSet) filter(keep func(key interface{}) bool) *LoxSet {
+	res := newSet()
+	for _, v := range s.elems {
+		key, _ := setKey(v)
+		if keep(key) {
+			res.add(key, v)
+		}
+	}
+	return res
+}
+
+// equal reports whether two sets hold the same elements, in any order
+func (s *LoxSet) equal(other *LoxSet) bool {
+	if len(s.elems) != len(other.elems) {
+		return false
+	}
+	for key := range s.index {
+		if !other.has(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// setArgs checks the arguments of a set native: a set followed by a value that can be an element
+func setArgs(name string, args []interface{}) (*LoxSet, interface{}, error) {
+	s, ok := args[0].(*LoxSet)
+	if !ok {
+		return nil, nil, RuntimeError{msg: name + "() expects a set and a value."}
+	}
+	key, ok := setKey(args[1])
+	if !ok {
+		return nil, nil, RuntimeError{msg: "Sets, bytes and tuples can't be set elements."}
+	}
+	return s, key, nil
+}
+
+// twoSets checks the arguments of the natives combining two sets
+func twoSets(name string, args []interface{}) (*LoxSet, *LoxSet, error) {
+	a, aok := args[0].(*LoxSet)
+	b, bok := args[1].(*LoxSet)
+	if !aok || !bok {
+		return nil, nil, RuntimeError{msg: name + "() expects two sets."}
+	}
+	return a, b, nil
+}
+
+// VisitSet evaluates a set literal, repeated elements are only added once
+func (in *Interpreter) VisitSet(s *SetExpr) (interface{}, error) {
+	set := newSet()
+	for _, elem := range s.elems {
+		v, err := in.evaluate(elem)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := setKey(v)
+		if !ok {
+			return nil, RuntimeError{tkn: s.keyword, msg: "Sets, bytes and tuples can't be set elements."}
+		}
+		set.add(key, v)
+	}
+	return set, nil
+}
+
+// membership evaluates 'v in s'. Values that can't be set elements are never in a set.
+func (in *Interpreter) membership(op Token, v, s interface{}) interface{} {
+	set, ok := s.(*LoxSet)
+	if !ok {
+		return RuntimeError{tkn: op, msg: "Right operand of 'in' must be a set."}
+	}
+	key, ok := setKey(v)
+	return ok && set.has(key)
+}
+
+// set() returns a new empty set
+func nativeSet(in *Interpreter, args []interface{}) interface{} {
+	return newSet()
+}
+
+// add(s, v) adds v to the set s
+func nativeAdd(in *Interpreter, args []interface{}) interface{} {
+	s, key, err := setArgs("add", args)
+	if err != nil {
+		return err
+	}
+	if s.frozen {
+		return frozenError("add")
+	}
+	s.add(key, args[1])
+	return nil
+}
+
+// has(s, v) reports whether v is an element of s
+func nativeHas(in *Interpreter, args []interface{}) interface{} {
+	s, key, err := setArgs("has", args)
+	if err != nil {
+		return err
+	}
+	return s.has(key)
+}
+
+// remove(s, v) removes v from s, returning whether it was an element
+func nativeRemove(in *Interpreter, args []interface{}) interface{} {
+	s, key, err := setArgs("remove", args)
+	if err != nil {
+		return err
+	}
+	if s.frozen {
+		return frozenError("remove")
+	}
+	return s.remove(key)
+}
+
+// union(a, b) returns a new set with the elements of both sets
+func nativeUnion(in *Interpreter, args []interface{}) interface{} {
+	a, b, err := twoSets("union", args)
+	if err != nil {
+		return err
+	}
+	res := a.filter(func(interface{}) bool { return true })
+	for _, v := range b.elems {
+		key, _ := setKey(v)
+		res.add(key, v)
+	}
+	return res
+}
+
+// intersect(a, b) returns a new set with the elements of a that are also in b
+func nativeIntersect(in *Interpreter, args []interface{}) interface{} {
+	a, b, err := twoSets("intersect", args)
+	if err != nil {
+		return err
+	}
+	return a.filter(b.has)
+}
+
+// difference(a, b) returns a new set with the elements of a that aren't in b
+func nativeDifference(in *Interpreter, args []interface{}) interface{} {
+	a, b, err := twoSets("difference", args)
+	if err != nil {
+		return err
+	}
+	return a.filter(func(key interface{}) bool { return !b.has(key) })
+}
+
+// stringifySet formats a set like '{1, a, nil}' using the interpreter's formatting for the elements
+func (in *Interpreter) stringifySet(s *LoxSet) string {
+	strs := make([]string, len(s.elems))
+	for i, v := range s.elems {
+		strs[i] = in.stringify(v)
+	}
+	return "{" + strings.Join(strs, ", ") + "}"
+}
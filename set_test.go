@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSetTupleElements checks that a tuple given to a set native or literal is a runtime error, and never in a set
+func TestSetTupleElements(t *testing.T) {
+	for source, want := range map[string]string{
+		"add(s, f());":    "can't be set elements",
+		"has(s, f());":    "can't be set elements",
+		"remove(s, f());": "can't be set elements",
+		"print f() in s;": "false",
+		"print set{f()};": "can't be set elements. [line 2]",
+	} {
+		in, out := bufferedInterpreter()
+		in.Interpret(parse("fun f() { return 1, 2; } var s = set{};\n"+source, in.reporter))
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Wrong output of %v. Wanted: %q, Got: %q\n", source, want, out.String())
+		}
+	}
+}
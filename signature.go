@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Signature describes the function being called where the cursor is.
+// The exported fields double as the JSON format printed by 'glox signature -json'.
+type Signature struct {
+	Name   string   `json:"name"`
+	Params []string `json:"params"`
+	// Active is the index of the argument the cursor is in
+	Active int    `json:"activeParameter"`
+	Doc    string `json:"doc,omitempty"`
+}
+
+// String shows the signature with the active parameter in brackets: 'padLeft(s, [width], pad)'
+func (s *Signature) String() string {
+	params := make([]string, len(s.Params))
+	for i, param := range s.Params {
+		if i == s.Active {
+			param = "[" + param + "]"
+		}
+		params[i] = param
+	}
+	return s.Name + "(" + strings.Join(params, ", ") + ")"
+}
+
+// SignatureAt returns the signature of the innermost call whose arguments contain offset, or nil if the
+// cursor isn't in a call of a known function. The callee is looked up like a name would be resolved there:
+// local and global declarations of the script, then the bindings of env (natives and prelude if nil).
+// Natives don't name their parameters, they are shown as arg1, arg2, ...
+func SignatureAt(source string, offset int, env *Environment) *Signature {
+	switch {
+	case offset < 0:
+		offset = 0
+	case offset > len(source):
+		offset = len(source)
+	}
+	quiet := &ErrorReporter{out: ioutil.Discard}
+	// the tolerant parser closes the calls the cursor is in, they are the ones without a ')'
+	stmts := parseTolerant(source[:offset], quiet)
+	var call *CallExpr
+	Inspect(stmts, func(node interface{}) bool {
+		if c, ok := node.(*CallExpr); ok && c.paren.toktype != RightParen {
+			call = c
+		}
+		return true
+	})
+	if call == nil {
+		return nil
+	}
+	callee, ok := call.callee.(*Variable)
+	if !ok {
+		return nil
+	}
+	sig := &Signature{Name: callee.name.lexeme, Active: len(call.arguments) - 1}
+	if sig.Active < 0 {
+		sig.Active = 0
+	}
+	if fn := declaredFunction(stmts, callee.name, parseTolerant(source, quiet)); fn != nil {
+		sig.Params, sig.Doc = paramLabels(fn), fn.doc
+		return sig
+	}
+	var val interface{}
+	if env != nil {
+		val, _ = env.Get(callee.name)
+	} else {
+		for _, native := range natives {
+			if native.name == callee.name.lexeme {
+				val = native
+			}
+		}
+		for _, stmt := range prelude() {
+			if fn, ok := stmt.(*FunctionStmt); ok && fn.name.lexeme == callee.name.lexeme {
+				val = &LoxFunction{FunctionStmt: fn}
+			}
+		}
+	}
+	switch fn := val.(type) {
+	case *LoxFunction:
+		sig.Params, sig.Doc = paramLabels(fn.FunctionStmt), fn.doc
+	case *NativeFunction:
+		for i := 1; i <= fn.nargs; i++ {
+			sig.Params = append(sig.Params, fmt.Sprintf("arg%d", i))
+		}
+	default:
+		return nil
+	}
+	return sig
+}
+
+// declaredFunction returns the function declaration a callee name refers to: the symbol it resolves to in
+// the code before the cursor, or else a function declared at the top level of the whole script
+func declaredFunction(stmts []Stmt, name Token, script []Stmt) *FunctionStmt {
+	for _, ref := range NewSymbolTable(stmts).refs {
+		if ref.tkn == name {
+			fn, _ := ref.target.decl.(*FunctionStmt)
+			return fn
+		}
+	}
+	for _, stmt := range script {
+		if fn, ok := stmt.(*FunctionStmt); ok && fn.name.lexeme == name.lexeme {
+			return fn
+		}
+	}
+	return nil
+}
+
+// tokenLexemes returns the lexemes of a list of tokens, e.g. parameter names
+func tokenLexemes(tokens []Token) []string {
+	lexemes := make([]string, len(tokens))
+	for i, tok := range tokens {
+		lexemes[i] = tok.lexeme
+	}
+	return lexemes
+}
+
+// paramLabels returns the parameters of a function with their annotations: 'width: Int'
+func paramLabels(fn *FunctionStmt) []string {
+	labels := tokenLexemes(fn.params)
+	for i, typ := range fn.paramTypes {
+		if typ.lexeme != "" {
+			labels[i] += ": " + typ.lexeme
+		}
+	}
+	return labels
+}
+
+// runSignature implements the 'signature' subcommand: given file.lox:line:col it prints the signature of
+// the call the position is in
+func runSignature(args []string) {
+	flags := flag.NewFlagSet("signature", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the signature as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: glox.exe signature [-json] [script]:[line]:[col]")
+		os.Exit(64)
+	}
+	path, line, col, err := splitPosition(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	sig := SignatureAt(string(contents), positionOffset(string(contents), line, col), nil)
+	if sig == nil {
+		fmt.Printf("No call of a known function at %v:%d:%d.\n", path, line, col)
+		os.Exit(1)
+	}
+	if *asJSON {
+		printJSON(sig)
+		return
+	}
+	fmt.Println(sig)
+	if sig.Doc != "" {
+		fmt.Println(sig.Doc)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+// TestSignatureAt checks that the innermost open call is described, with the argument under the cursor
+func TestSignatureAt(t *testing.T) {
+	source := "fun area(w, h) { return w * h; }\nprint area(scale(1, 2), "
+	sig := SignatureAt(source, len(source), nil)
+	if sig == nil || sig.String() != "area(w, [h])" {
+		t.Fatalf("Wrong signature: %v\n", sig)
+	}
+	if sig := SignatureAt("print join(", 11, nil); sig == nil || sig.String() != "join([values], sep)" {
+		t.Errorf("Wrong prelude signature: %v\n", sig)
+	}
+}
+
+// TestSignatureAtOutOfRange checks that offsets outside the source are clamped instead of crashing
+func TestSignatureAtOutOfRange(t *testing.T) {
+	for _, offset := range []int{-5, -1, 0, 100} {
+		if sig := SignatureAt("print join(", offset, nil); (sig != nil) != (offset > 0) {
+			t.Errorf("Wrong signature at %d: %v\n", offset, sig)
+		}
+	}
+}
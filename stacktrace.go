@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// callFrame is a call of one of the script's functions that hasn't returned yet
+type callFrame struct {
+	// name is the function's name, line the line it was called on
+	name string
+	line int
+}
+
+// maxTraceFrames is the number of calls a stack trace lists at most, deep recursion shows the innermost and
+// the outermost half of them
+const maxTraceFrames = 20
+
+// stackTrace returns a copy of the calls in progress, the innermost last
+func (in *Interpreter) stackTrace() []callFrame {
+	trace := make([]callFrame, len(in.frames))
+	copy(trace, in.frames)
+	return trace
+}
+
+// formatTrace lists the calls a runtime error happened in below its message, the innermost first
+func formatTrace(trace []callFrame) string {
+	var b strings.Builder
+	for i := len(trace) - 1; i >= 0; i-- {
+		if skipped := len(trace) - maxTraceFrames; skipped > 0 && i == len(trace)-1-maxTraceFrames/2 {
+			fmt.Fprintf(&b, "\n  ... %d more calls", skipped)
+			i -= skipped - 1
+			continue
+		}
+		fmt.Fprintf(&b, "\n  in %s(), called on line %d", trace[i].name, trace[i].line)
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package main
+
+import "errors"
+
+/*
+Start and Step let a host run a script a budget of statements at a time, e.g. every entity's script for a
+few hundred statements per frame. Like a generator's body, the script runs on its own goroutine: execute()
+spends the budget, and once it is used up the goroutine hands control back to Step and waits for the next
+one, in the middle of whatever expression or call it was in.
+*/
+
+// Execution is a script started with Start, it runs when Step is called and is done when the script ends
+type Execution struct {
+	in *Interpreter
+	// grants carries each Step's budget to the script, it is closed by Stop
+	grants chan int
+	// pauses tells Step that the script used up its budget (false) or finished (true)
+	pauses chan bool
+	budget int
+	// stopped is set once the script saw that Stop was called, it is unwinding
+	stopped bool
+	done    bool
+	err     error
+}
+
+// Start prepares a script to run with Step, nothing runs until then. A script with static errors isn't
+// started, they are reported like Interpret() does. The interpreter must not run anything else until the
+// execution is done or stopped.
+func (in *Interpreter) Start(stmts []Stmt) (*Execution, error) {
+	if !in.resolve(stmts) {
+		return nil, errors.New("the script has static errors")
+	}
+	e := &Execution{in: in, grants: make(chan int), pauses: make(chan bool)}
+	in.stepper = e
+	go func() {
+		defer func() {
+			in.stepper = nil
+			e.pauses <- true
+		}()
+		if e.budget = <-e.grants; e.budget <= 0 && !e.wait() {
+			return
+		}
+		for _, stmt := range stmts {
+			if err := in.execute(stmt); err != nil {
+				if rerr, ok := err.(RuntimeError); ok && !e.stopped {
+					e.err = rerr
+				}
+				return
+			}
+		}
+	}()
+	return e, nil
+}
+
+// Step runs the script for at most n more statements, counting those of called functions. It returns
+// whether the script is done and the runtime error that ended it, if any. The output printed so far is
+// flushed.
+func (e *Execution) Step(n int) (done bool, err error) {
+	if e.done {
+		return true, e.err
+	}
+	if n < 0 {
+		n = 0
+	}
+	e.grants <- n
+	e.done = <-e.pauses
+	e.in.flush()
+	return e.done, e.err
+}
+
+// Done reports whether the script ended or was stopped
+func (e *Execution) Done() bool {
+	return e.done
+}
+
+// Stop abandons a script that isn't done, letting its goroutine exit. The interpreter can be used again.
+func (e *Execution) Stop() {
+	if e.done {
+		return
+	}
+	close(e.grants)
+	for !<-e.pauses {
+	}
+	e.done = true
+}
+
+// spend takes one statement from the budget, waiting for the next Step if it is used up. It returns false
+// if the execution was stopped instead, the script has to unwind.
+func (e *Execution) spend() bool {
+	if e.budget == 0 && !e.wait() {
+		return false
+	}
+	e.budget--
+	return true
+}
+
+// wait hands control back to Step until it grants a budget of at least one statement
+func (e *Execution) wait() bool {
+	for e.budget <= 0 {
+		if e.stopped {
+			return false
+		}
+		e.pauses <- false
+		budget, ok := <-e.grants
+		e.budget, e.stopped = budget, !ok
+	}
+	return true
+}
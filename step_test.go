@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+// TestStep checks that a script runs a budget of statements at a time, pausing inside calls, and can be stopped
+func TestStep(t *testing.T) {
+	in, out := bufferedInterpreter()
+	exec, err := in.Start(parse("fun two() { print 1; print 2; } two(); print 3;", in.reporter))
+	if err != nil {
+		t.Fatalf("Start failed: %v\n", err)
+	}
+	// the function declaration, the call statement and the first print
+	if done, _ := exec.Step(3); done || out.String() != "1\n" {
+		t.Errorf("First step printed %q\n", out.String())
+	}
+	if done, err := exec.Step(10); !done || err != nil || out.String() != "1\n2\n3\n" {
+		t.Errorf("Second step printed %q, done %v, error %v\n", out.String(), done, err)
+	}
+	exec, _ = in.Start(parse("var n = 0; while (true) n = n + 1;", in.reporter))
+	exec.Step(100)
+	exec.Stop()
+	if n, _ := in.globals.Get(Token{lexeme: "n"}); !exec.Done() || n.(int64) < 40 {
+		t.Errorf("Stopped execution: done %v, n = %v\n", exec.Done(), n)
+	}
+	exec, _ = in.Start(parse("print nil + 1;", in.reporter))
+	if done, err := exec.Step(5); !done || err == nil {
+		t.Errorf("Runtime error wasn't returned: done %v, error %v\n", done, err)
+	}
+}
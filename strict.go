@@ -0,0 +1,71 @@
+package main
+
+import "fmt"
+
+// uninitialized is the value of a variable declared without an initializer under -strict, reading it is a
+// runtime error until it is assigned
+type uninitialized struct{}
+
+func (uninitialized) String() string {
+	return "<uninitialized>"
+}
+
+// checkStrict reports what -strict forbids before a script runs: assignments to names that are declared
+// nowhere and calls of known functions with the wrong number of arguments. globals holds the names that
+// exist already (natives, the prelude and earlier REPL lines). It returns false if there were errors.
+func checkStrict(stmts []Stmt, globals *Environment, r *ErrorReporter) bool {
+	hadError := r.hadError
+	r.hadError = false
+	table := NewSymbolTable(stmts)
+	for _, ref := range table.unresolved {
+		if _, defined := globals.bindings[ref.tkn.lexeme]; ref.write && !defined {
+			r.errorTok(ref.tkn, "Assignment to undeclared variable '"+ref.tkn.lexeme+"'.")
+		}
+	}
+	for _, site := range table.calls {
+		if params, ok := knownArity(table, site, globals); ok && params != site.args {
+			name := site.callee.tkn.lexeme
+			r.errorTok(site.callee.tkn, fmt.Sprintf("'%v' is called with the wrong number of arguments: %d given, %d expected.", name, site.args, params))
+		}
+	}
+	ok := !r.hadError
+	r.hadError = r.hadError || hadError
+	return ok
+}
+
+// knownArity returns the number of parameters of the function a call calls, if it is known statically: a
+// function of the script that is never reassigned or decorated, or else a function or native of globals
+// (nil for none) the script doesn't assign to
+func knownArity(table *SymbolTable, site *CallSite, globals *Environment) (int, bool) {
+	if site.callee == nil {
+		return 0, false
+	}
+	if sym := site.callee.target; sym != nil {
+		fn, ok := sym.decl.(*FunctionStmt)
+		if !ok || sym.kind != FunSymbol || len(fn.decorators) > 0 {
+			return 0, false
+		}
+		for _, ref := range sym.refs {
+			if ref.write {
+				return 0, false
+			}
+		}
+		return len(fn.params), true
+	}
+	if globals == nil {
+		return 0, false
+	}
+	name := site.callee.tkn.lexeme
+	for _, ref := range table.unresolved {
+		if ref.write && ref.tkn.lexeme == name {
+			return 0, false
+		}
+	}
+	switch fn := globals.bindings[name].(type) {
+	case *NativeFunction:
+		return fn.nargs, true
+	case *LoxFunction:
+		return len(fn.params), true
+	}
+	return 0, false
+}
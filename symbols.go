@@ -0,0 +1,331 @@
+package main
+
+// Each kind of declaration a Symbol can come from
+const (
+	VarSymbol = iota
+	FunSymbol
+	ParamSymbol
+)
+
+// SymbolKind is an "enum-like" wrapper for the constants above
+type SymbolKind int
+
+// Symbol is a single declaration (variable, function or parameter) found in a script
+type Symbol struct {
+	name   Token
+	kind   SymbolKind
+	global bool
+	// fun is the function the symbol was declared in, nil for top-level declarations
+	fun *Symbol
+	// decl is the declaring statement, nil for parameters
+	decl Stmt
+	refs []*Reference
+	// shadows is the declaration of the same name in an enclosing scope this one hides, if any
+	shadows *Symbol
+}
+
+// Reference is a single use of a symbol: a read, an assignment or a call
+type Reference struct {
+	tkn   Token
+	write bool
+	// call is true if the reference is the callee of a call expression
+	call bool
+	// from is the function the reference appears in, nil for top-level code
+	from   *Symbol
+	target *Symbol
+}
+
+// CallSite is a single call expression
+type CallSite struct {
+	paren Token
+	from  *Symbol
+	// callee is the reference to the called name, nil if the callee isn't a plain name (e.g. f()())
+	callee *Reference
+	// args is the number of arguments
+	args int
+}
+
+// SymbolTable holds every declaration in a script and every reference that could be resolved to one
+type SymbolTable struct {
+	symbols []*Symbol
+	refs    []*Reference
+	// unresolved references are names with no declaration in the script (natives, typos, ...)
+	unresolved []*Reference
+	calls      []*CallSite
+}
+
+// symbolCollector is a visitor that builds a SymbolTable by tracking lexical scopes the same way the
+// interpreter does. Globals are late bound, so references to them are resolved once the whole script was seen.
+type symbolCollector struct {
+	table   *SymbolTable
+	scopes  []map[string]*Symbol
+	globals map[string]*Symbol
+	fun     *Symbol
+	// pending holds references to names that weren't found in any local scope
+	pending []*Reference
+}
+
+// NewSymbolTable collects the symbols of the given (already parsed) script
+func NewSymbolTable(stmts []Stmt) *SymbolTable {
+	c := &symbolCollector{
+		table:   &SymbolTable{},
+		globals: make(map[string]*Symbol),
+	}
+	c.resolveStmts(stmts)
+	for _, ref := range c.pending {
+		if sym, ok := c.globals[ref.tkn.lexeme]; ok {
+			c.bind(ref, sym)
+		} else {
+			c.table.unresolved = append(c.table.unresolved, ref)
+		}
+	}
+	return c.table
+}
+
+func (c *symbolCollector) resolveStmts(stmts []Stmt) {
+	for _, s := range stmts {
+		if s != nil {
+			s.accept(c)
+		}
+	}
+}
+
+func (c *symbolCollector) resolveExpr(e Expr) {
+	if e != nil {
+		e.accept(c)
+	}
+}
+
+// declare adds a new symbol to the innermost scope (or the global scope)
+func (c *symbolCollector) declare(name Token, kind SymbolKind, decl Stmt) *Symbol {
+	sym := &Symbol{name: name, kind: kind, fun: c.fun, decl: decl, global: len(c.scopes) == 0}
+	if sym.global {
+		c.globals[name.lexeme] = sym
+	} else {
+		for i := len(c.scopes) - 2; i >= 0 && sym.shadows == nil; i-- {
+			sym.shadows = c.scopes[i][name.lexeme]
+		}
+		if sym.shadows == nil {
+			// only the globals declared so far, a later global doesn't exist yet when the scope is entered
+			sym.shadows = c.globals[name.lexeme]
+		}
+		c.scopes[len(c.scopes)-1][name.lexeme] = sym
+	}
+	c.table.symbols = append(c.table.symbols, sym)
+	return sym
+}
+
+// reference records a use of 'name', resolving it against the local scopes from the inside out
+func (c *symbolCollector) reference(name Token, write bool) *Reference {
+	ref := &Reference{tkn: name, write: write, from: c.fun}
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if sym, ok := c.scopes[i][name.lexeme]; ok {
+			c.bind(ref, sym)
+			return ref
+		}
+	}
+	c.pending = append(c.pending, ref)
+	return ref
+}
+
+func (c *symbolCollector) bind(ref *Reference, sym *Symbol) {
+	ref.target = sym
+	sym.refs = append(sym.refs, ref)
+	c.table.refs = append(c.table.refs, ref)
+}
+
+func (c *symbolCollector) beginScope() {
+	c.scopes = append(c.scopes, make(map[string]*Symbol))
+}
+
+func (c *symbolCollector) endScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *symbolCollector) VisitPrintStmt(p *PrintStmt) error {
+	c.resolveExpr(p.exp)
+	return nil
+}
+
+func (c *symbolCollector) VisitExprStmt(e *ExprStmt) error {
+	c.resolveExpr(e.exp)
+	return nil
+}
+
+func (c *symbolCollector) VisitVarStmt(v *VarStmt) error {
+	// the initializer is evaluated before the new binding exists
+	c.resolveExpr(v.init)
+	c.declare(*v.name, VarSymbol, v)
+	return nil
+}
+
+func (c *symbolCollector) VisitDestructureStmt(d *DestructureStmt) error {
+	c.resolveExpr(d.init)
+	for _, name := range d.names {
+		if d.declare {
+			c.declare(name, VarSymbol, d)
+		} else {
+			c.reference(name, true)
+		}
+	}
+	return nil
+}
+
+func (c *symbolCollector) VisitBlockStmt(b *BlockStmt) error {
+	c.beginScope()
+	c.resolveStmts(b.statements)
+	c.endScope()
+	return nil
+}
+
+func (c *symbolCollector) VisitIfStmt(i *IfStmt) error {
+	c.resolveExpr(i.exp)
+	c.resolveStmts([]Stmt{i.thenPart, i.elsePart})
+	return nil
+}
+
+func (c *symbolCollector) VisitWhileStmt(w *WhileStmt) error {
+	c.resolveExpr(w.condition)
+	c.resolveStmts([]Stmt{w.statement})
+	return nil
+}
+
+func (c *symbolCollector) VisitForInStmt(f *ForInStmt) error {
+	c.resolveExpr(f.collection)
+	// the loop variable lives in its own scope around the body
+	c.beginScope()
+	c.declare(f.name, VarSymbol, f)
+	c.resolveStmts([]Stmt{f.body})
+	c.endScope()
+	return nil
+}
+
+func (c *symbolCollector) VisitFunctionStmt(f *FunctionStmt) error {
+	for _, d := range f.decorators {
+		c.resolveExpr(d.expr)
+	}
+	sym := c.declare(f.name, FunSymbol, f)
+	enclosing := c.fun
+	c.fun = sym
+	c.beginScope()
+	for _, param := range f.params {
+		c.declare(param, ParamSymbol, nil)
+	}
+	c.resolveStmts(f.body)
+	c.endScope()
+	c.fun = enclosing
+	return nil
+}
+
+func (c *symbolCollector) VisitReturnStmt(r *ReturnStmt) error {
+	c.resolveExpr(r.val)
+	return nil
+}
+
+func (c *symbolCollector) VisitYieldStmt(y *YieldStmt) error {
+	c.resolveExpr(y.val)
+	return nil
+}
+
+// imported declarations aren't part of the table, references to them end up unresolved
+func (c *symbolCollector) VisitImportStmt(i *ImportStmt) error { return nil }
+
+func (c *symbolCollector) VisitBadStmt(b *BadStmt) error { return nil }
+
+func (c *symbolCollector) VisitBadExpr(b *BadExpr) (interface{}, error) { return nil, nil }
+
+func (c *symbolCollector) VisitBinaryExpr(b *BinaryExpr) (interface{}, error) {
+	c.resolveExpr(b.left)
+	c.resolveExpr(b.right)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitGrouping(g *Grouping) (interface{}, error) {
+	c.resolveExpr(g.exp)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitLiteral(l *Literal) (interface{}, error) { return nil, nil }
+
+func (c *symbolCollector) VisitUnary(u *Unary) (interface{}, error) {
+	c.resolveExpr(u.right)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitVariable(v *Variable) (interface{}, error) {
+	c.reference(v.name, false)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitAssign(a *AssignExpr) (interface{}, error) {
+	c.resolveExpr(a.val)
+	c.reference(a.name, true)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitLogical(l *LogicalExpr) (interface{}, error) {
+	c.resolveExpr(l.left)
+	c.resolveExpr(l.right)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitCall(call *CallExpr) (interface{}, error) {
+	site := &CallSite{paren: call.paren, from: c.fun, args: len(call.arguments)}
+	if v, ok := call.callee.(*Variable); ok {
+		site.callee = c.reference(v.name, false)
+		site.callee.call = true
+	} else {
+		c.resolveExpr(call.callee)
+	}
+	c.table.calls = append(c.table.calls, site)
+	for _, arg := range call.arguments {
+		c.resolveExpr(arg)
+	}
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitIndex(i *IndexExpr) (interface{}, error) {
+	c.resolveExpr(i.object)
+	c.resolveExpr(i.start)
+	c.resolveExpr(i.end)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitIs(i *IsExpr) (interface{}, error) {
+	c.resolveExpr(i.val)
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitSet(s *SetExpr) (interface{}, error) {
+	for _, elem := range s.elems {
+		c.resolveExpr(elem)
+	}
+	return nil, nil
+}
+
+func (c *symbolCollector) VisitTuple(t *TupleExpr) (interface{}, error) {
+	for _, val := range t.values {
+		c.resolveExpr(val)
+	}
+	return nil, nil
+}
+
+// SymbolAt returns the symbol that is declared or referenced by the token at line:col, or nil if there is none
+func (t *SymbolTable) SymbolAt(line, col int) *Symbol {
+	for _, sym := range t.symbols {
+		if covers(sym.name, line, col) {
+			return sym
+		}
+	}
+	for _, ref := range t.refs {
+		if covers(ref.tkn, line, col) {
+			return ref.target
+		}
+	}
+	return nil
+}
+
+// covers reports whether line:col falls inside the lexeme of tok
+func covers(tok Token, line, col int) bool {
+	return tok.line == line && col >= tok.col && col < tok.col+len(tok.lexeme)
+}
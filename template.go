@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+EvalTemplateExpr evaluates the Lox expressions users write into the templates of a web host. The profile is
+deliberately small: one expression and nothing else, no assignment, the names of the host's data and a few
+pure helpers as the only variables and a limit on the size of every string. The helpers are natives rather
+than the prelude's functions of the same names, so an expression never runs a statement and its cost is
+bounded by the size of its data. glox has no property access, the host passes flat data whose lists are
+indexed like tuples.
+*/
+
+// maxTemplateString is the longest string, in bytes, that template data can hold or an expression can build
+const maxTemplateString = 64 << 10
+
+// templateHelpers are the functions a template expression can call besides its data, none of them can build
+// a string longer than its arguments
+var templateHelpers = []*NativeFunction{
+	{"length", 1, templateLength},
+	{"reverse", 1, templateReverse},
+	{"startsWith", 2, templateStringTest("startsWith", strings.HasPrefix)},
+	{"endsWith", 2, templateStringTest("endsWith", strings.HasSuffix)},
+	{"contains", 2, templateStringTest("contains", strings.Contains)},
+	{"has", 2, nativeHas},
+}
+
+// length(v) returns the number of characters of a string or elements of a set or tuple
+func templateLength(in *Interpreter, args []interface{}) interface{} {
+	switch v := args[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v))
+	case *LoxSet:
+		return int64(len(v.elems))
+	case Tuple:
+		return int64(len(v))
+	}
+	return RuntimeError{msg: "length() expects a string, set or tuple."}
+}
+
+// reverse(v) returns the characters of a string or the elements of a tuple in reverse order
+func templateReverse(in *Interpreter, args []interface{}) interface{} {
+	switch v := args[0].(type) {
+	case string:
+		r := []rune(v)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r)
+	case Tuple:
+		t := make(Tuple, len(v))
+		for i, elem := range v {
+			t[len(v)-1-i] = elem
+		}
+		return t
+	}
+	return RuntimeError{msg: "reverse() expects a string or tuple."}
+}
+
+// templateStringTest returns a helper comparing two strings with test
+func templateStringTest(name string, test func(s, sub string) bool) func(*Interpreter, []interface{}) interface{} {
+	return func(in *Interpreter, args []interface{}) interface{} {
+		s, ok := args[0].(string)
+		sub, subOk := args[1].(string)
+		if !ok || !subOk {
+			return RuntimeError{msg: name + "() expects two strings."}
+		}
+		return test(s, sub)
+	}
+}
+
+// EvalTemplateExpr evaluates a single expression against the host's data and returns its value the way print
+// would show it. Every key of data is a variable of the expression, its values can be nil, booleans, numbers,
+// strings and lists ([]interface{} or []string, indexed like tuples). Syntax errors, assignments, runtime
+// errors and strings longer than 64 KiB are returned as errors; nothing the expression does reaches the host.
+func EvalTemplateExpr(expr string, data map[string]interface{}) (string, error) {
+	var errs bytes.Buffer
+	r := &ErrorReporter{out: &errs}
+	lexer := NewLexScanner(expr)
+	lexer.reporter = r
+	p := NewParser(lexer)
+	p.reporter = r
+	e, err := p.expression()
+	if err == nil && !p.isAtEnd() {
+		err = p.getError(*p.Peek(), "Expect end of expression.")
+	}
+	if err != nil || r.hadError {
+		return "", errors.New(strings.TrimSpace(errs.String()))
+	}
+	Inspect([]Stmt{&ExprStmt{exp: e}}, func(node interface{}) bool {
+		if a, ok := node.(*AssignExpr); ok && err == nil {
+			err = fmt.Errorf("[line %d] Error at '%v': Template expressions can't assign.", a.name.line, a.name.lexeme)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return "", err
+	}
+	in, err := newTemplateInterpreter(data)
+	if err != nil {
+		return "", err
+	}
+	val, err := in.evaluate(e)
+	if err != nil {
+		return "", err
+	}
+	str := in.stringify(val)
+	if len(str) > maxTemplateString {
+		return "", fmt.Errorf("the value of a template expression is longer than %d bytes", maxTemplateString)
+	}
+	return str, nil
+}
+
+// newTemplateInterpreter returns an interpreter whose only globals are the template helpers and the data
+func newTemplateInterpreter(data map[string]interface{}) (*Interpreter, error) {
+	in := NewInterpreter()
+	in.out = ioutil.Discard
+	in.maxString = maxTemplateString
+	env := NewEnvironment(nil)
+	for _, fn := range templateHelpers {
+		env.Define(fn.name, fn)
+	}
+	for name, v := range data {
+		if !isTemplateName(name) {
+			return nil, fmt.Errorf("'%v' can't be the name of template data", name)
+		}
+		val, err := templateValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("template data '%v': %v", name, err)
+		}
+		env.Define(name, val)
+	}
+	in.globals, in.env = env, env
+	return in, nil
+}
+
+// isTemplateName reports whether a key of template data can be used as a variable: an identifier that isn't
+// a reserved word
+func isTemplateName(name string) bool {
+	if name == "" || !isAlpha(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isAlphaNumeric(name[i]) {
+			return false
+		}
+	}
+	_, reserved := reservedWords[name]
+	return !reserved
+}
+
+// templateValue converts a value of the host's data to the Lox value a template expression sees
+func templateValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, bool, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case string:
+		if len(v) > maxTemplateString {
+			return nil, fmt.Errorf("strings are limited to %d bytes", maxTemplateString)
+		}
+		return v, nil
+	case []string:
+		t := make(Tuple, len(v))
+		for i, s := range v {
+			val, err := templateValue(s)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = val
+		}
+		return t, nil
+	case []interface{}:
+		t := make(Tuple, len(v))
+		for i, elem := range v {
+			val, err := templateValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = val
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("values of type %T can't be used in templates", v)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEvalTemplateExpr checks that template expressions see the host's data and helpers but nothing else
+func TestEvalTemplateExpr(t *testing.T) {
+	data := map[string]interface{}{"name": "Ada", "items": []string{"a", "b"}, "count": 2, "big": strings.Repeat("x", 40000)}
+	for expr, want := range map[string]string{
+		`"Hello, " + name + "!"`:        "Hello, Ada!",
+		`items[1] + " of " + count`:     "b of 2",
+		`startsWith(name, "A")`:         "true",
+		`count > 1 and "many" or "one"`: "many",
+		`length(items)`:                 "2",
+		`reverse(items)[0]`:             "b",
+		`length(reverse(big))`:          "40000",
+		`contains(big, "y")`:            "false",
+	} {
+		got, err := EvalTemplateExpr(expr, data)
+		if err != nil || got != want {
+			t.Errorf("Wrong value of %v. Wanted: %q, Got: %q (%v)\n", expr, want, got, err)
+		}
+	}
+	for _, expr := range []string{"name = 1", "clock()", "readFileBytes(name)", "name; print name", "big + big", "repeat(name, 3)"} {
+		if got, err := EvalTemplateExpr(expr, data); err == nil {
+			t.Errorf("%v wasn't refused, got %q\n", expr, got)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+A test is any .lox file. Comments in the file control how it is checked:
+
+	// expect: <line>         the next line of output must be exactly <line>
+	// expect error: <text>   the next line of output must be an error message containing <text>
+	// skip: <reason>         don't run the file at all
+	// only: <reason>         if any file has this directive, only those files are run
+	// dialect: <name>        run the file as jlox or clox would (see -dialect)
+	// snapshot               compare the whole output against testdata/__snapshots__/<file>.snap
+
+A file without expectations passes if it runs without errors. Snapshots are written on the first
+run (or when running with -update) and diffed against the output on every run after that.
+*/
+
+// testDirective matches the comments the test runner understands
+var testDirective = regexp.MustCompile(`//\s*(expect error|expect|skip|only|dialect):\s?(.*)$`)
+
+// snapshotDirective marks a test whose output is checked against a stored snapshot
+var snapshotDirective = regexp.MustCompile(`//\s*snapshot\s*$`)
+
+// LoxTest is a single test file and the expectations parsed from its comments
+type LoxTest struct {
+	path     string
+	source   string
+	expect   []testExpectation
+	skip     bool
+	only     bool
+	snapshot bool
+	dialect  Dialect
+}
+
+// testExpectation is a single expected line of output
+type testExpectation struct {
+	line  int
+	text  string
+	error bool
+}
+
+// TestResult is the outcome of running a single test file
+type TestResult struct {
+	test     *LoxTest
+	skipped  bool
+	failures []string
+	output   string
+	elapsed  time.Duration
+	// note is extra information for verbose output, e.g. that a snapshot was written
+	note  string
+	steps int
+}
+
+// LoadTest reads a test file and parses its directives
+func LoadTest(path string) (*LoxTest, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &LoxTest{path: path, source: string(contents)}
+	for i, line := range strings.Split(t.source, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if snapshotDirective.MatchString(line) {
+			t.snapshot = true
+			continue
+		}
+		m := testDirective.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		switch m[1] {
+		case "expect":
+			t.expect = append(t.expect, testExpectation{line: i + 1, text: m[2]})
+		case "expect error":
+			t.expect = append(t.expect, testExpectation{line: i + 1, text: m[2], error: true})
+		case "skip":
+			t.skip = true
+		case "only":
+			t.only = true
+		case "dialect":
+			d, err := ParseDialect(strings.TrimSpace(m[2]))
+			if err != nil {
+				return nil, fmt.Errorf("%v:%d: %v", path, i+1, err)
+			}
+			t.dialect = d
+		}
+	}
+	return t, nil
+}
+
+// Run executes the test in its own interpreter (on a virtual clock) and checks the captured output.
+// If updateSnapshots is set, snapshot tests overwrite their stored output instead of comparing against it.
+func (t *LoxTest) Run(updateSnapshots bool) *TestResult {
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out}
+	stmts := parse(t.source, r)
+	return t.runParsed(stmts, r, &out, 0, updateSnapshots)
+}
+
+// runParsed runs the already parsed statements of the test and checks the output.
+// A positive maxSteps stops the script after that many statements.
+func (t *LoxTest) runParsed(stmts []Stmt, r *ErrorReporter, out *bytes.Buffer, maxSteps int, updateSnapshots bool) *TestResult {
+	start := time.Now()
+	result := &TestResult{test: t}
+	r.dialect = t.dialect
+	if !r.hadError {
+		in := NewTestInterpreter()
+		in.out = out
+		in.reporter = r
+		in.maxSteps = maxSteps
+		in.dialect = t.dialect
+		in.dir = filepath.Dir(t.path)
+		in.Interpret(stmts)
+		result.steps = in.steps
+	}
+	result.output = out.String()
+	result.elapsed = time.Since(start)
+	if t.snapshot {
+		// errors are part of the snapshot like any other output
+		result.failures, result.note = t.checkSnapshot(result.output, updateSnapshots)
+	} else {
+		result.failures = t.check(result.output, r.hadError || r.hadRuntimeError)
+	}
+	return result
+}
+
+// snapshotPath returns where the snapshot of a test file is stored
+func (t *LoxTest) snapshotPath() string {
+	return filepath.Join(filepath.Dir(t.path), "testdata", "__snapshots__", filepath.Base(t.path)+".snap")
+}
+
+// checkSnapshot compares the output of a run with the stored snapshot, writing it if it doesn't exist yet
+func (t *LoxTest) checkSnapshot(output string, update bool) ([]string, string) {
+	path := t.snapshotPath()
+	stored, err := ioutil.ReadFile(path)
+	if update || os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return []string{"can't write snapshot: " + err.Error()}, ""
+		}
+		if err := ioutil.WriteFile(path, []byte(output), 0644); err != nil {
+			return []string{"can't write snapshot: " + err.Error()}, ""
+		}
+		return nil, "wrote snapshot " + path
+	}
+	if err != nil {
+		return []string{"can't read snapshot: " + err.Error()}, ""
+	}
+	if string(stored) == output {
+		return nil, ""
+	}
+	failures := []string{"output doesn't match snapshot " + path + " (run with -update to accept it):"}
+	for _, line := range diffLines(strings.TrimSuffix(string(stored), "\n"), strings.TrimSuffix(output, "\n")) {
+		failures = append(failures, "  "+line)
+	}
+	return failures, ""
+}
+
+// check compares the output of a run against the test's expectations
+func (t *LoxTest) check(output string, hadError bool) []string {
+	failures := make([]string, 0)
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if output == "" {
+		lines = nil
+	}
+	expectsError := false
+	for i, exp := range t.expect {
+		expectsError = expectsError || exp.error
+		switch {
+		case i >= len(lines):
+			failures = append(failures, fmt.Sprintf("line %d: missing output, expected %q", exp.line, exp.text))
+		case exp.error && !strings.Contains(lines[i], exp.text):
+			failures = append(failures, fmt.Sprintf("line %d: expected an error containing %q, got %q", exp.line, exp.text, lines[i]))
+		case !exp.error && lines[i] != exp.text:
+			failures = append(failures, fmt.Sprintf("line %d: expected %q, got %q", exp.line, exp.text, lines[i]))
+		}
+	}
+	if len(t.expect) > 0 && len(lines) > len(t.expect) {
+		failures = append(failures, fmt.Sprintf("unexpected output %q", lines[len(t.expect)]))
+	}
+	if hadError && !expectsError && len(failures) == 0 {
+		failures = append(failures, "unexpected error: "+strings.TrimSpace(output))
+	}
+	return failures
+}
+
+// FindTests collects every .lox file in the given files and directories (recursively), sorted by path
+func FindTests(paths []string) ([]string, error) {
+	found := make([]string, 0)
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// testdata directories hold snapshots and fixtures (like imported modules), not tests
+			if info.IsDir() && info.Name() == "testdata" && path != root {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && (path == root || filepath.Ext(path) == ".lox") {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+// RunTests runs the given tests using up to 'parallel' goroutines, results are in the same order as tests
+func RunTests(tests []*LoxTest, parallel int, updateSnapshots bool) []*TestResult {
+	results := make([]*TestResult, len(tests))
+	// honour 'only' directives
+	only := false
+	for _, t := range tests {
+		only = only || t.only
+	}
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if tests[i].skip || (only && !tests[i].only) {
+					results[i] = &TestResult{test: tests[i], skipped: true}
+				} else {
+					results[i] = tests[i].Run(updateSnapshots)
+				}
+			}
+		}()
+	}
+	for i := range tests {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
+
+// runTest implements the 'test' subcommand
+func runTest(args []string) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	filter := flags.String("run", "", "only run test files whose path matches this regular expression")
+	parallel := flags.Int("p", runtime.NumCPU(), "number of test files to run in parallel")
+	verbose := flags.Bool("v", false, "print every test and the output of failing tests")
+	update := flags.Bool("update", false, "rewrite the stored output of snapshot tests")
+	flags.Parse(args)
+	if *parallel < 1 {
+		*parallel = 1
+	}
+	runRe, err := regexp.Compile(*filter)
+	if err != nil {
+		fmt.Printf("Invalid -run pattern: %v\n", err)
+		os.Exit(64)
+	}
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	paths, err := FindTests(roots)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(66)
+	}
+	tests := make([]*LoxTest, 0)
+	for _, path := range paths {
+		if !runRe.MatchString(filepath.ToSlash(path)) {
+			continue
+		}
+		t, err := LoadTest(path)
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			fmt.Printf("Can't open file at [%v].\n", path)
+			os.Exit(66)
+		} else if err != nil {
+			fmt.Println(err)
+			os.Exit(65)
+		}
+		tests = append(tests, t)
+	}
+	passed, failed, skipped := 0, 0, 0
+	for _, res := range RunTests(tests, *parallel, *update) {
+		switch {
+		case res.skipped:
+			skipped++
+			if *verbose {
+				fmt.Printf("--- SKIP: %v\n", res.test.path)
+			}
+		case len(res.failures) > 0:
+			failed++
+			fmt.Printf("--- FAIL: %v (%.2fs)\n", res.test.path, res.elapsed.Seconds())
+			for _, f := range res.failures {
+				fmt.Printf("    %v\n", f)
+			}
+			if *verbose && res.output != "" {
+				fmt.Printf("    output:\n%v", indent(res.output, "        "))
+			}
+		default:
+			passed++
+			if *verbose {
+				fmt.Printf("--- PASS: %v (%.2fs)\n", res.test.path, res.elapsed.Seconds())
+				if res.note != "" {
+					fmt.Printf("    %v\n", res.note)
+				}
+			}
+		}
+	}
+	fmt.Printf("%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// indent prefixes every line of s
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
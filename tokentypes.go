@@ -18,6 +18,10 @@ const (
 	Semicolon
 	Slash
 	Star
+	Percent
+	LeftBracket
+	RightBracket
+	Colon
 
 	// one or two character tokens
 	Bang
@@ -28,6 +32,9 @@ const (
 	GreaterEqual
 	Less
 	LessEqual
+	QuestionQuestion
+	PipeGreater
+	At
 
 	// literals
 	Identifier
@@ -37,11 +44,15 @@ const (
 	// keywords
 	And
 	Class
+	ConstTok
 	Else
 	FalseTok
 	Fun
 	ForTok
 	IfTok
+	ImportTok
+	InTok
+	IsTok
 	NilTok
 	OrTok
 	PrintTok
@@ -51,6 +62,7 @@ const (
 	TrueTok
 	VarTok
 	WhileTok
+	YieldTok
 
 	// End of File
 	EOF
@@ -67,6 +79,7 @@ type Token struct {
 	lexeme  string
 	literal interface{}
 	line    int
+	col     int // 1-based column of the first character of the lexeme
 }
 
 // simple string representation for a token
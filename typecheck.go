@@ -0,0 +1,237 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// anyType stands for a value of any type, in an annotation it opts out of checking
+const anyType = "Any"
+
+// typeChecker is a best-effort static type checker for annotated code. It is gradual: the type of an
+// expression is only known if it follows from literals, operators, annotated variables and parameters and
+// the annotated results of calls; everything else is "" and matches any type. Only what is certain to be
+// wrong at runtime is reported, before the script runs.
+type typeChecker struct {
+	reporter *ErrorReporter
+	// refs maps the name token of every resolved reference to the symbol it refers to
+	refs map[Token]*Symbol
+	// paramTypes maps the name token of every annotated parameter to its type
+	paramTypes map[Token]Token
+}
+
+// checkTypes reports the type errors of a parsed and resolved script to r, it returns false if there were any
+func checkTypes(stmts []Stmt, r *ErrorReporter) bool {
+	hadError := r.hadError
+	r.hadError = false
+	c := &typeChecker{reporter: r, refs: make(map[Token]*Symbol), paramTypes: make(map[Token]Token)}
+	for _, ref := range NewSymbolTable(stmts).refs {
+		c.refs[ref.tkn] = ref.target
+	}
+	Inspect(stmts, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *FunctionStmt:
+			for i, typ := range n.paramTypes {
+				c.checkName(typ)
+				if typ.lexeme != "" {
+					c.paramTypes[n.params[i]] = typ
+				}
+			}
+			c.checkName(n.returnType)
+			c.checkReturns(n)
+		case *VarStmt:
+			c.checkName(n.typ)
+			if n.init != nil {
+				c.expect(n.typ.lexeme, n.init, *n.name)
+			}
+		case *AssignExpr:
+			if sym := c.refs[n.name]; sym != nil {
+				c.expect(c.declared(sym), n.val, n.name)
+			}
+		case *CallExpr:
+			if fn := c.callee(n); fn != nil {
+				for i, arg := range n.arguments {
+					if i < len(fn.paramTypes) {
+						c.expect(fn.paramTypes[i].lexeme, arg, n.paren)
+					}
+				}
+			}
+		}
+		return true
+	})
+	ok := !r.hadError
+	r.hadError = r.hadError || hadError
+	return ok
+}
+
+// checkName reports an annotation that doesn't name a type
+func (c *typeChecker) checkName(typ Token) {
+	if _, ok := builtinTypes[typ.lexeme]; !ok && typ.lexeme != "" && typ.lexeme != anyType {
+		c.reporter.errorTok(typ, "Unknown type '"+typ.lexeme+"' in annotation.")
+	}
+}
+
+// checkReturns checks the values returned by a function against its annotated result type. Nested functions
+// are checked on their own.
+func (c *typeChecker) checkReturns(fn *FunctionStmt) {
+	if fn.returnType.lexeme == "" || fn.generator {
+		return
+	}
+	Inspect(fn.body, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *FunctionStmt:
+			return false
+		case *ReturnStmt:
+			if n.val == nil {
+				c.expect(fn.returnType.lexeme, &Literal{val: nil}, n.keyword)
+			} else {
+				c.expect(fn.returnType.lexeme, n.val, n.keyword)
+			}
+		}
+		return true
+	})
+}
+
+// expect reports e if its type is known and isn't the wanted one. The error is reported where e starts, or
+// at tkn if e was synthesized.
+func (c *typeChecker) expect(want string, e Expr, tkn Token) {
+	got := c.typeOf(e)
+	if assignable(want, got) {
+		return
+	}
+	if start := exprStart(e); start.line > 0 {
+		tkn = start
+	}
+	c.reporter.errorTok(tkn, "Type mismatch: expected "+want+" but got "+got+".")
+}
+
+// assignable reports whether a value of type got may be stored where want is expected. Unknown types match
+// anything (misspelled annotations included, they are reported once), and so does Number an Int or a Float:
+// the checker can't tell which one it is.
+func assignable(want, got string) bool {
+	_, known := builtinTypes[want]
+	switch {
+	case !known || got == "" || want == got:
+		return true
+	case want == "Number":
+		return got == "Int" || got == "Float"
+	case got == "Number":
+		return want == "Int" || want == "Float"
+	}
+	return false
+}
+
+// declared returns the annotated type of a symbol, "" if it has none
+func (c *typeChecker) declared(sym *Symbol) string {
+	switch sym.kind {
+	case FunSymbol:
+		return "Function"
+	case ParamSymbol:
+		return c.paramTypes[sym.name].lexeme
+	}
+	if v, ok := sym.decl.(*VarStmt); ok {
+		return v.typ.lexeme
+	}
+	return ""
+}
+
+// callee returns the declaration of the function a call calls, if it is a function of the script. Like
+// knownArity it gives up on functions that are reassigned or decorated, the call may run something else.
+func (c *typeChecker) callee(call *CallExpr) *FunctionStmt {
+	v, ok := call.callee.(*Variable)
+	if !ok {
+		return nil
+	}
+	sym := c.refs[v.name]
+	if sym == nil || sym.kind != FunSymbol {
+		return nil
+	}
+	fn, ok := sym.decl.(*FunctionStmt)
+	if !ok || len(fn.decorators) > 0 {
+		return nil
+	}
+	for _, ref := range sym.refs {
+		if ref.write {
+			return nil
+		}
+	}
+	return fn
+}
+
+// typeOf infers the type of an expression, "" if it isn't known statically
+func (c *typeChecker) typeOf(e Expr) string {
+	switch exp := e.(type) {
+	case *Literal:
+		return typeOf(exp.val)
+	case *Grouping:
+		return c.typeOf(exp.exp)
+	case *Variable:
+		if sym := c.refs[exp.name]; sym != nil {
+			if typ := c.declared(sym); typ != anyType {
+				return typ
+			}
+		}
+	case *AssignExpr:
+		return c.typeOf(exp.val)
+	case *IsExpr:
+		return "Boolean"
+	case *Unary:
+		if exp.op.toktype == Bang {
+			return "Boolean"
+		}
+		if operand := c.typeOf(exp.right); assignable("Number", operand) && operand != "" {
+			return operand
+		}
+	case *LogicalExpr:
+		if left := c.typeOf(exp.left); left == c.typeOf(exp.right) {
+			return left
+		}
+	case *CallExpr:
+		if fn := c.callee(exp); fn != nil && !fn.generator && fn.returnType.lexeme != anyType {
+			return fn.returnType.lexeme
+		}
+	case *BinaryExpr:
+		left, right := c.typeOf(exp.left), c.typeOf(exp.right)
+		switch exp.op.toktype {
+		case Comma:
+			return right
+		case EqualEqual, BangEqual, Greater, GreaterEqual, Less, LessEqual, InTok:
+			return "Boolean"
+		case Plus:
+			if left == "String" || right == "String" {
+				return "String"
+			}
+			return numericResult(left, right)
+		case Minus, Star, Slash, Percent:
+			return numericResult(left, right)
+		}
+	}
+	return ""
+}
+
+// numericResult is the type of an arithmetic operation on two numbers: Int if both are integers and Float
+// if either is a float
+func numericResult(left, right string) string {
+	switch {
+	case left == "Float" && assignable("Number", right) && right != "", right == "Float" && assignable("Number", left) && left != "":
+		return "Float"
+	case left == "Int" && right == "Int":
+		return "Int"
+	case left != "" && right != "" && assignable("Number", left) && assignable("Number", right):
+		return "Number"
+	}
+	return ""
+}
+
+// typeCheckSource implements the -check flag: it type checks a script about to run and exits if there are
+// mismatches. Syntax errors are left for running the script to report.
+func typeCheckSource(source string) {
+	quiet := &ErrorReporter{out: ioutil.Discard}
+	stmts := parse(source, quiet)
+	if quiet.hadError || !resolve(stmts, make(map[Expr]int), quiet) {
+		return
+	}
+	if !checkTypes(stmts, reporter) {
+		os.Exit(65)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestCheckTypes checks that only mismatches the annotations make certain are reported
+func TestCheckTypes(t *testing.T) {
+	source := `fun scale(x: Number, by): Float { return x * by; }
+var a: Int = 1 + 2;
+var b: Float = scale(a, "?");
+var c: String = 2 * 3;
+var d: Any = "x";
+d = 1;
+scale("1", 2);
+`
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out}
+	stmts := parse(source, r)
+	if r.hadError {
+		t.Fatalf("Annotations don't parse: %v", out.String())
+	}
+	if checkTypes(stmts, r) {
+		t.Fatalf("No type errors reported\n")
+	}
+	want := "[line 4] Error [E050] at '2': Type mismatch: expected String but got Int.\n" +
+		"[line 7] Error [E050] at '\"1\"': Type mismatch: expected Number but got String.\n"
+	if got := out.String(); got != want {
+		t.Errorf("Got errors\n%v\nwanted\n%v", got, want)
+	}
+}
+
+// TestCheckTypesReassigned checks that calls of a reassigned or decorated function aren't checked against
+// its declaration, they may call another function
+func TestCheckTypesReassigned(t *testing.T) {
+	for _, source := range []string{
+		"fun g(s: String) {}\nfun h(n: Number) {}\nh = g;\nh(\"s\");\n",
+		"fun id(f) { return f; }\n@id\nfun h(n: Number) {}\nh(\"s\");\n",
+	} {
+		var out bytes.Buffer
+		r := &ErrorReporter{out: &out}
+		if !checkTypes(parse(source, r), r) {
+			t.Errorf("Type errors reported for\n%v%v", source, out.String())
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+// builtinTypes maps the type names usable with 'is' to a test for values of that type
+var builtinTypes = map[string]func(v interface{}) bool{
+	"Nil":       func(v interface{}) bool { return v == nil },
+	"Boolean":   func(v interface{}) bool { _, ok := v.(bool); return ok },
+	"Number":    isNumber,
+	"Int":       func(v interface{}) bool { _, ok := v.(int64); return ok },
+	"Float":     func(v interface{}) bool { _, ok := v.(float64); return ok },
+	"String":    func(v interface{}) bool { _, ok := v.(string); return ok },
+	"Function":  func(v interface{}) bool { _, ok := v.(LoxCaller); return ok },
+	"Set":       func(v interface{}) bool { _, ok := v.(*LoxSet); return ok },
+	"Bytes":     func(v interface{}) bool { _, ok := v.(*LoxBytes); return ok },
+	"Time":      func(v interface{}) bool { _, ok := v.(LoxTime); return ok },
+	"Generator": func(v interface{}) bool { _, ok := v.(*LoxGenerator); return ok },
+	"Coroutine": func(v interface{}) bool { _, ok := v.(*LoxCoroutine); return ok },
+	"Tuple":     func(v interface{}) bool { _, ok := v.(Tuple); return ok },
+}
+
+// VisitIs evaluates 'value is TypeName' for the built-in type names
+func (in *Interpreter) VisitIs(i *IsExpr) (interface{}, error) {
+	val, err := in.evaluate(i.val)
+	if err != nil {
+		return nil, err
+	}
+	test, ok := builtinTypes[i.typeName.lexeme]
+	if !ok {
+		return nil, RuntimeError{tkn: i.typeName, msg: "Unknown type '" + i.typeName.lexeme + "'."}
+	}
+	return test(val), nil
+}
+
+// typeNames lists the type names in the order typeOf tries them, the most specific first
+var typeNames = []string{"Nil", "Boolean", "Int", "Float", "String", "Function", "Set", "Bytes", "Time", "Generator", "Coroutine", "Tuple"}
+
+// typeOf returns the most specific built-in type name of a value
+func typeOf(v interface{}) string {
+	for _, name := range typeNames {
+		if builtinTypes[name](v) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Version is the release of glox
+	Version = "v0.0.1"
+	// APIVersion is the "major.minor" version of the embedding API (NewInterpreter, Globals, the
+	// environment's write interceptor, snapshots and change listener, FormulaSet, plugins, coroutines,
+	// Step, Reload, EvalTemplateExpr, ...). The major number changes when that API breaks, the minor
+	// number when something is added to it.
+	APIVersion = "1.6"
+	// backend names the execution strategy, glox only has the tree-walking interpreter
+	backend = "tree-walk"
+)
+
+// languageFeatures lists the language extensions on top of the book's Lox that this build supports, a new
+// extension adds its name here
+var languageFeatures = []string{
+	"for-in", "int64", "checked-int", "const", "import", "multiple-return", "nil-coalescing", "time",
+	"bytes", "generators", "comma", "string-compare", "sets", "raw-strings", "multiline-strings", "is",
+	"slicing", "set-literals", "pipeline", "decorators", "docstrings", "digit-separators", "hex-literals",
+	"annotations", "coroutines",
+}
+
+// CheckAPIVersion reports whether an embedder written against the given "major.minor" API version
+// can use this build: the major versions must match and the minor version must not be newer.
+func CheckAPIVersion(required string) error {
+	major, minor, err := splitAPIVersion(required)
+	if err != nil {
+		return err
+	}
+	haveMajor, haveMinor, _ := splitAPIVersion(APIVersion)
+	if major != haveMajor {
+		return fmt.Errorf("glox API %v is incompatible with the required API %v", APIVersion, required)
+	}
+	if minor > haveMinor {
+		return fmt.Errorf("glox API %v is older than the required API %v", APIVersion, required)
+	}
+	return nil
+}
+
+// splitAPIVersion parses a "major.minor" version, a missing minor number counts as 0
+func splitAPIVersion(v string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 2)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid API version %q", v)
+	}
+	minor := 0
+	if len(parts) == 2 {
+		if minor, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, fmt.Errorf("invalid API version %q", v)
+		}
+	}
+	return major, minor, nil
+}
+
+// printVersion implements the -version flag
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "glox %v\n", Version)
+	fmt.Fprintf(w, "api: %v\n", APIVersion)
+	fmt.Fprintf(w, "backend: %v\n", backend)
+	fmt.Fprintf(w, "prelude: %v\n", PreludeVersion)
+	fmt.Fprintf(w, "features: %v\n", strings.Join(languageFeatures, " "))
+	if len(plugins) > 0 {
+		fmt.Fprintf(w, "plugins: %v\n", strings.Join(pluginNames(), " "))
+	}
+	fmt.Fprintf(w, "go: %v %v/%v\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	if info.Main.Path != "" {
+		fmt.Fprintf(w, "module: %v %v\n", info.Main.Path, info.Main.Version)
+	}
+	for _, s := range info.Settings {
+		if strings.HasPrefix(s.Key, "vcs.") {
+			fmt.Fprintf(w, "%v: %v\n", s.Key, s.Value)
+		}
+	}
+}
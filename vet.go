@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// runVet implements the 'vet' subcommand, which runs the opt-in static analysis passes over a script
+// without executing it. The exit status is 1 if any warnings were reported.
+func runVet(args []string) {
+	flags := flag.NewFlagSet("vet", flag.ExitOnError)
+	floatEq := flags.Bool("float-eq", false, "flag '=='/'!=' between computed numbers")
+	deadCode := flags.Bool("dead-code", false, "report functions and globals never used from the top level")
+	assignCond := flags.Bool("assign-cond", false, "flag assignments used as if/while conditions")
+	unused := flags.Bool("unused", false, "report locals and parameters never read and local functions never used")
+	shadow := flags.Bool("shadow", false, "flag local declarations hiding one of an enclosing scope")
+	arity := flags.Bool("arity", false, "flag calls of the script's functions with the wrong number of arguments")
+	fix := flags.Bool("fix", false, "apply the suggested fixes (and fix missing ';') in place")
+	format := flags.String("format", "text", "output format: text or sarif")
+	flags.Parse(args)
+	if flags.NArg() != 1 || (*format != "text" && *format != "sarif") {
+		fmt.Println("usage: glox.exe vet [flags] [script]")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	lint := func(stmts []Stmt) []Warning {
+		warnings := make([]Warning, 0)
+		if *floatEq {
+			warnings = append(warnings, lintFloatEquality(stmts)...)
+		}
+		if *deadCode {
+			warnings = append(warnings, lintDeadCode(NewSymbolTable(stmts))...)
+		}
+		if *assignCond {
+			warnings = append(warnings, lintAssignCondition(stmts)...)
+		}
+		if *unused {
+			warnings = append(warnings, lintUnused(NewSymbolTable(stmts))...)
+		}
+		if *shadow {
+			warnings = append(warnings, lintShadowing(NewSymbolTable(stmts))...)
+		}
+		if *arity {
+			warnings = append(warnings, lintArity(NewSymbolTable(stmts))...)
+		}
+		return uniqueWarnings(warnings)
+	}
+	var warnings []Warning
+	if *fix {
+		warnings = fixFile(flags.Arg(0), lint)
+	} else {
+		warnings = lint(parseFile(flags.Arg(0)))
+	}
+	if *format == "sarif" {
+		log := newSARIF()
+		for _, w := range warnings {
+			log.addWarning(flags.Arg(0), w, "warning")
+		}
+		printJSON(log)
+	} else {
+		for _, w := range warnings {
+			fmt.Println(w.format(reporter.lang))
+		}
+	}
+	if len(warnings) > 0 {
+		os.Exit(1)
+	}
+}
+
+// warnUnusedIn implements the --warn-unused flag: it prints the unused locals, parameters and local functions of
+// the script about to run, see warnBeforeRun
+func warnUnusedIn(path, source string) {
+	warnBeforeRun(path, source, lintUnused)
+}
+
+// warnArityIn prints the calls of the script's own functions with the wrong number of arguments before it runs,
+// they would only fail once they are reached. glox.toml can turn W008 off, -strict makes these calls errors instead
+// and the jlox and clox dialects don't warn, like the book's implementations.
+func warnArityIn(path, source string) {
+	severities, err := severitiesFromConfig(project.config)
+	if *strict || interpreter.dialect != GloxDialect || (err == nil && severities.of("W008") == SeverityIgnore) {
+		return
+	}
+	warnBeforeRun(path, source, lintArity)
+}
+
+// warnBeforeRun prints the warnings of a lint for the script about to run on stderr, prefixed by their position,
+// so the script's own output isn't mixed with them. A script with syntax errors isn't linted, running it reports them.
+func warnBeforeRun(path, source string, lint func(*SymbolTable) []Warning) {
+	stmts := parse(source, &ErrorReporter{out: ioutil.Discard})
+	for _, w := range lint(NewSymbolTable(stmts)) {
+		fmt.Fprintf(os.Stderr, "%v:%d:%d: %v\n", path, w.tkn.line, w.tkn.col, w.format(reporter.lang))
+	}
+}
+
+// fixFile rewrites a script with the fixes for its syntax errors and lint warnings applied, and returns
+// the warnings that are left afterwards. The file is only written if the fixed script parses cleanly.
+func fixFile(path string, lint func([]Stmt) []Warning) []Warning {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Can't open file at [%v].\n", path)
+		os.Exit(66)
+	}
+	source := string(contents)
+	var errs bytes.Buffer
+	r := &ErrorReporter{out: &errs}
+	stmts, fixes := parseFixable(source, r)
+	applied := 0
+	if r.hadError && len(fixes) > 0 {
+		source, applied = applyFixes(source, fixes)
+		errs.Reset()
+		r.reset()
+		stmts, _ = parseFixable(source, r)
+	}
+	if r.hadError {
+		fmt.Print(errs.String())
+		os.Exit(65)
+	}
+	// fixes may overlap, so keep going until every remaining warning either has no fix or a conflicting one
+	for {
+		fixes = fixes[:0]
+		for _, w := range lint(stmts) {
+			if w.fix != nil {
+				fixes = append(fixes, w.fix)
+			}
+		}
+		var n int
+		source, n = applyFixes(source, fixes)
+		if n == 0 {
+			break
+		}
+		applied += n
+		stmts, _ = parseFixable(source, r)
+		if r.hadError {
+			// a fix that breaks the script is a bug in the lint, not something to write back
+			fmt.Print(errs.String())
+			os.Exit(70)
+		}
+	}
+	if applied > 0 {
+		if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+			fmt.Printf("Can't write file at [%v].\n", path)
+			os.Exit(74)
+		}
+		fmt.Printf("%v: applied %d fixes\n", path, applied)
+	}
+	return lint(stmts)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Workspace holds the analysis of every script of a project and re-analyzes only what an edit affects: the
+// edited files and, following the imports backwards, the files that import them. Its results are what
+// 'glox check ./...' reports, the -watch flag keeps one workspace up to date while files are edited.
+type Workspace struct {
+	searchPath []string
+	files      map[string]*fileAnalysis
+	// importers maps the path of a module to the paths of the workspace's files importing it
+	importers map[string]map[string]bool
+}
+
+// fileAnalysis is what is known about one file of a workspace, paths are absolute
+type fileAnalysis struct {
+	source string
+	hash   [sha256.Size]byte
+	// imports are the paths the file's imports were looked up at, up to the modules that were found
+	imports []string
+	// syntaxErrors is true if the file can't be parsed or resolved, its importers report it
+	syntaxErrors bool
+	// errors are the formatted syntax, static, type and import errors
+	errors   []string
+	warnings []Warning
+}
+
+// NewWorkspace creates an empty workspace, imports are looked up in the importing file's directory and searchPath
+func NewWorkspace(searchPath []string) *Workspace {
+	return &Workspace{searchPath: searchPath, files: make(map[string]*fileAnalysis), importers: make(map[string]map[string]bool)}
+}
+
+// Update makes paths the files of the workspace. Files whose contents changed since the last update (or that
+// are new) are analyzed again, and so are the files importing them, directly or not. Files that are gone are
+// dropped. It returns the paths of the files that were analyzed, sorted.
+func (w *Workspace) Update(paths []string) []string {
+	dirty := make(map[string]bool)
+	current := make(map[string]bool)
+	sources := make(map[string]string)
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		current[path] = true
+		sources[path] = string(contents)
+		if f, ok := w.files[path]; !ok || f.hash != sha256.Sum256(contents) {
+			dirty[path] = true
+		}
+	}
+	for path := range w.files {
+		if !current[path] {
+			// its importers now report a missing module
+			dirty[path] = true
+			w.forget(path)
+			delete(w.files, path)
+		}
+	}
+	affected := make(map[string]bool)
+	queue := make([]string, 0, len(dirty))
+	for path := range dirty {
+		queue = append(queue, path)
+	}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if affected[path] {
+			continue
+		}
+		affected[path] = true
+		for importer := range w.importers[path] {
+			queue = append(queue, importer)
+		}
+	}
+	analyzed := make([]string, 0, len(affected))
+	for path := range affected {
+		if current[path] {
+			analyzed = append(analyzed, path)
+		}
+	}
+	sort.Strings(analyzed)
+	// every file is parsed before any import is checked, an importer may come before the module it imports
+	for _, path := range analyzed {
+		w.analyze(path, sources[path])
+	}
+	for _, path := range analyzed {
+		w.checkImports(path)
+	}
+	return analyzed
+}
+
+// forget removes the import edges of a file from the graph
+func (w *Workspace) forget(path string) {
+	if f, ok := w.files[path]; ok {
+		for _, module := range f.imports {
+			delete(w.importers[module], path)
+		}
+	}
+}
+
+// analyze parses, resolves, type checks and lints one file
+func (w *Workspace) analyze(path, source string) {
+	w.forget(path)
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out, lang: reporter.lang}
+	stmts := parse(source, r)
+	f := &fileAnalysis{source: source, hash: sha256.Sum256([]byte(source))}
+	f.syntaxErrors = r.hadError || !resolve(stmts, make(map[Expr]int), r)
+	if !f.syntaxErrors {
+		// type errors don't keep the file from being imported, the module runs regardless
+		checkTypes(stmts, &ErrorReporter{out: &out, lang: reporter.lang})
+		f.warnings = lintAll(stmts)
+	}
+	Inspect(stmts, func(node interface{}) bool {
+		if i, ok := node.(*ImportStmt); ok && i.path.literal != nil {
+			// every path the import is looked up at before it is found is an edge too: a module created there
+			// is analyzed as a new file, and the importer with it
+			for _, module := range importCandidates(i.path.literal.(string), filepath.Dir(path), w.searchPath) {
+				f.imports = append(f.imports, module)
+				if w.importers[module] == nil {
+					w.importers[module] = make(map[string]bool)
+				}
+				w.importers[module][path] = true
+				if info, err := os.Stat(module); err == nil && !info.IsDir() {
+					break
+				}
+			}
+		}
+		return true
+	})
+	f.errors = splitLines(out.String())
+	w.files[path] = f
+}
+
+// checkImports reports the imports of a file that can't be run: modules that can't be found and modules of
+// the workspace that have syntax errors. Running the file would fail with these errors when it gets there.
+func (w *Workspace) checkImports(path string) {
+	f := w.files[path]
+	stmts := parse(f.source, &ErrorReporter{out: ioutil.Discard})
+	var out bytes.Buffer
+	r := &ErrorReporter{out: &out, lang: reporter.lang}
+	Inspect(stmts, func(node interface{}) bool {
+		i, ok := node.(*ImportStmt)
+		if !ok || i.path.literal == nil {
+			return true
+		}
+		module, found := resolveImport(i.path.literal.(string), filepath.Dir(path), w.searchPath)
+		if !found {
+			r.errorTok(i.path, "Can't find module "+i.path.lexeme+".")
+		} else if m, ok := w.files[module]; ok && m.syntaxErrors {
+			r.errorTok(i.path, "Module "+i.path.lexeme+" has syntax errors.")
+		}
+		return true
+	})
+	f.errors = append(f.errors, splitLines(out.String())...)
+}
+
+// Diagnostics returns the errors and the warnings of a file of the workspace
+func (w *Workspace) Diagnostics(path string) (errors []string, warnings []Warning) {
+	if path, err := filepath.Abs(path); err == nil {
+		if f, ok := w.files[path]; ok {
+			return f.errors, f.warnings
+		}
+	}
+	return nil, nil
+}
+
+// splitLines splits output into its lines, without an empty one for the final newline
+func splitLines(out string) []string {
+	if out == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+}
+
+// isWorkspacePattern reports whether a 'glox check' argument names every script below a directory, like 'dir/...'
+func isWorkspacePattern(arg string) bool {
+	return arg == "..." || strings.HasSuffix(filepath.ToSlash(arg), "/...")
+}
+
+// workspaceFiles lists the .lox files below dir, skipping hidden directories
+func workspaceFiles(dir string) []string {
+	paths := make([]string, 0)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && path != dir && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".lox") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// checkWorkspace implements 'glox check' for several files, given as paths, 'dir/...' or globs: every
+// script is checked and its diagnostics are printed prefixed by its path. With watch it then polls the files
+// (expanding the patterns again, so new files are picked up), reporting the ones an edit affected, until it is
+// interrupted. It returns the number of errors of the first check.
+func checkWorkspace(args []string, searchPath []string, severities Severities, watch bool) int {
+	paths, err := expandArgs(args)
+	if err != nil {
+		fmt.Printf("Invalid argument: %v.\n", err)
+		os.Exit(64)
+	}
+	w := NewWorkspace(searchPath)
+	report := func(paths []string) int {
+		errors := 0
+		for _, path := range paths {
+			prefix := displayPath(path) + ": "
+			errs, warnings := w.Diagnostics(path)
+			reported := len(errs)
+			for _, e := range errs {
+				errors++
+				fmt.Println(prefix + e)
+			}
+			for _, warning := range warnings {
+				id, _ := localize(warning.msg, "")
+				switch severities.of(id) {
+				case SeverityError:
+					errors++
+					reported++
+					fmt.Println(prefix + warning.formatAs("Error", reporter.lang))
+				case SeverityWarning:
+					reported++
+					fmt.Println(prefix + warning.format(reporter.lang))
+				}
+			}
+			if watch && reported == 0 {
+				fmt.Println(prefix + "ok")
+			}
+		}
+		return errors
+	}
+	errors := report(w.Update(paths))
+	for watch {
+		time.Sleep(time.Second)
+		if paths, err := expandArgs(args); err == nil {
+			report(w.Update(paths))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestWorkspaceUpdate checks that an edit re-analyzes the edited module and its importers, and nothing else
+func TestWorkspaceUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glox-workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	util, main, other := filepath.Join(dir, "util.lox"), filepath.Join(dir, "main.lox"), filepath.Join(dir, "other.lox")
+	files := map[string]string{util: "fun twice(n) { return n * 2; }\n", main: "import \"./util.lox\";\nprint twice(1);\n", other: "print 1;\n"}
+	for path, source := range files {
+		if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{main, other, util}
+	w := NewWorkspace(nil)
+	if got := w.Update(paths); len(got) != 3 {
+		t.Fatalf("The first update analyzed %v, wanted every file\n", got)
+	}
+	if got := w.Update(paths); len(got) != 0 {
+		t.Errorf("An update without edits analyzed %v\n", got)
+	}
+	ioutil.WriteFile(util, []byte("fun twice(n) { return n * ; }\n"), 0644)
+	if got := w.Update(paths); !reflect.DeepEqual(got, []string{main, util}) {
+		t.Errorf("Editing the module analyzed %v, wanted it and its importer\n", got)
+	}
+	if errs, _ := w.Diagnostics(main); len(errs) != 1 {
+		t.Errorf("The importer of a broken module reports %v\n", errs)
+	}
+}
+
+// TestWorkspaceMissingModule checks that creating a module a file imports re-analyzes the importer
+func TestWorkspaceMissingModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glox-workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	util, main := filepath.Join(dir, "util.lox"), filepath.Join(dir, "main.lox")
+	if err := ioutil.WriteFile(main, []byte("import \"./util.lox\";\nprint twice(1);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWorkspace(nil)
+	w.Update([]string{main})
+	if errs, _ := w.Diagnostics(main); len(errs) != 1 {
+		t.Fatalf("The import of a missing module reports %v\n", errs)
+	}
+	if err := ioutil.WriteFile(util, []byte("fun twice(n) { return n * 2; }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Update([]string{main, util}); !reflect.DeepEqual(got, []string{main, util}) {
+		t.Errorf("Creating the module analyzed %v, wanted it and its importer\n", got)
+	}
+	if errs, _ := w.Diagnostics(main); len(errs) != 0 {
+		t.Errorf("The importer still reports %v\n", errs)
+	}
+}